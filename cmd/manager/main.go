@@ -29,6 +29,8 @@ import (
 	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis"
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
 	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/webhook"
 	"github.com/Orange-OpenSource/cassandra-k8s-operator/version"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
@@ -49,8 +51,11 @@ var (
 )
 
 const (
-	LogLevelEnvVar     = "LOG_LEVEL"
-	ResyncPeriodEnvVar = "RESYNC_PERIOD"
+	LogLevelEnvVar       = "LOG_LEVEL"
+	ResyncPeriodEnvVar   = "RESYNC_PERIOD"
+	WebhookEnabledEnvVar = "WEBHOOK_ENABLED"
+
+	MaxConcurrentReconcilesEnvVar = "MAX_CONCURRENT_RECONCILES"
 )
 
 //to be set by compilator with -ldflags "-X main.compileDate=`date -u +.%Y%m%d.%H%M%S`"
@@ -64,6 +69,25 @@ func printVersion() {
 	logrus.Infof("cassandra-k8s-operator Compilation Date: %s", compileDate)
 	logrus.Infof("cassandra-k8s-operator LogLevel: %v", getLogLevel())
 	logrus.Infof("cassandra-k8s-operator ResyncPeriod: %v", getResyncPeriod())
+	logrus.Infof("cassandra-k8s-operator WebhookEnabled: %v", getWebhookEnabled())
+	logrus.Infof("cassandra-k8s-operator MaxConcurrentReconciles: %v", getMaxConcurrentReconciles())
+}
+
+//getMaxConcurrentReconciles returns the number of CassandraClusters which can be reconciled at the same time,
+//1 by default
+func getMaxConcurrentReconciles() int {
+	maxConcurrentReconciles, err := strconv.Atoi(os.Getenv(MaxConcurrentReconcilesEnvVar))
+	if err != nil || maxConcurrentReconciles < 1 {
+		return 1
+	}
+	return maxConcurrentReconciles
+}
+
+//getWebhookEnabled returns true if the admission webhooks must be served, they need the permissions to manage
+//webhook configurations
+func getWebhookEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(WebhookEnabledEnvVar))
+	return err == nil && enabled
 }
 
 func getLogLevel() logrus.Level {
@@ -187,11 +211,30 @@ func main() {
 	}
 
 	// Setup all Controllers
+	cassandracluster.MaxConcurrentReconciles = getMaxConcurrentReconciles()
 	if err := controller.AddToManager(mgr); err != nil {
 		logrus.Error(err)
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if getWebhookEnabled() {
+		operatorNamespace, err := k8sutil.GetOperatorNamespace()
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		operatorName, err := k8sutil.GetOperatorName()
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := webhook.AddToManager(mgr, namespace, operatorNamespace, operatorName); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+	}
+
 	// Create Service object to expose the metrics port.
 	servicePorts := []v1.ServicePort{
 		{Name: "metricsPort", TargetPort: intstr.FromInt(int(metricsPort))},
@@ -0,0 +1,136 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"path"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//StorageAccessKeyIDKey is the key of the access key ID in the storage credentials secret
+	StorageAccessKeyIDKey = "accessKeyID"
+	//StorageSecretAccessKeyKey is the key of the secret access key in the storage credentials secret
+	StorageSecretAccessKeyKey = "secretAccessKey"
+)
+
+//BackupStorage defines the S3-compatible object store where the snapshot files are uploaded
+type BackupStorage struct {
+	//Endpoint of the object store (host:port), without scheme
+	Endpoint string `json:"endpoint"`
+	//Bucket where the files are uploaded, it must already exist
+	Bucket string `json:"bucket"`
+	//Prefix prepended to every object key
+	Prefix string `json:"prefix,omitempty"`
+	//Region of the bucket, if empty it is discovered from the object store
+	Region string `json:"region,omitempty"`
+	//Insecure disables TLS when connecting to the object store
+	Insecure bool `json:"insecure,omitempty"`
+	//Secret holding the accessKeyID and secretAccessKey keys used to authenticate
+	CredentialsSecret v1.LocalObjectReference `json:"credentialsSecret"`
+}
+
+//RackKeyPrefix returns the prefix of the keys used to store the files of a backup for a dc-rack
+//The files of a pod are stored under <prefix>/<backup>/<dc-rack>/<ordinal>/ so that they can be mapped back to
+//the pod with the same ordinal in the same dc-rack when restoring
+func (storage BackupStorage) RackKeyPrefix(backupName, dcRackName string) string {
+	return path.Join(storage.Prefix, backupName, dcRackName) + "/"
+}
+
+//PodKeyPrefix returns the prefix of the keys used to store the files of a backup for a pod
+func (storage BackupStorage) PodKeyPrefix(backupName, dcRackName string, ordinal int) string {
+	return storage.RackKeyPrefix(backupName, dcRackName) + strconv.Itoa(ordinal) + "/"
+}
+
+// CassandraBackupSpec defines the desired state of CassandraBackup
+type CassandraBackupSpec struct {
+	//Name of the CassandraCluster to backup, it must live in the same namespace
+	Cluster string `json:"cluster"`
+	//Name of the snapshot taken on each node, default to the name of the CassandraBackup
+	SnapshotName string `json:"snapshotName,omitempty"`
+	//List of keyspaces to backup, all keyspaces are taken if empty
+	Keyspaces []string `json:"keyspaces,omitempty"`
+	//Storage where the snapshot files are uploaded
+	Storage BackupStorage `json:"storage"`
+}
+
+//BackupPodStatus defines the progress of the backup on one Cassandra pod
+type BackupPodStatus struct {
+	//Name of the dc-rack the pod belongs to
+	DCRackName string `json:"dcRackName,omitempty"`
+	//Status of the backup on this pod: ToDo, Ongoing, Done or Error
+	Status string `json:"status,omitempty"`
+	//Number of files uploaded
+	Files int32 `json:"files,omitempty"`
+	//Number of bytes uploaded
+	Bytes int64 `json:"bytes,omitempty"`
+	//Last error seen on this pod
+	Error string `json:"error,omitempty"`
+	//Number of failed attempts to upload the snapshot of this pod
+	Attempts int32 `json:"attempts,omitempty"`
+
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+}
+
+// CassandraBackupStatus defines the observed state of CassandraBackup
+type CassandraBackupStatus struct {
+	//Phase of the backup: Ongoing, Done or Error
+	Phase string `json:"phase,omitempty"`
+	//Name of the snapshot taken on each node
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+
+	//Progress of the backup for each pod of the cluster
+	Pods map[string]*BackupPodStatus `json:"pods,omitempty"`
+}
+
+//GetSnapshotName returns the name of the snapshot to take on each node
+func (cb *CassandraBackup) GetSnapshotName() string {
+	if cb.Spec.SnapshotName != "" {
+		return cb.Spec.SnapshotName
+	}
+	return cb.Name
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackup is the Schema for the cassandrabackups API
+// +k8s:openapi-gen=true
+type CassandraBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraBackupSpec   `json:"spec,omitempty"`
+	Status CassandraBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackupList contains a list of CassandraBackup
+type CassandraBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraBackup{}, &CassandraBackupList{})
+}
@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,6 +38,9 @@ const (
 	DefaultCassandraDC   string = "dc1"
 	DefaultCassandraRack string = "rack1"
 
+	//DefaultSeedsPerDC is the number of seeds of a DC when SeedsPerDC is not set
+	DefaultSeedsPerDC int32 = 3
+
 	DefaultTerminationGracePeriodSeconds = 1800
 
 	//DefaultDelayWait: wait 20 seconds (2x resyncPeriod) prior to follow status of an operation
@@ -48,6 +53,38 @@ const (
 
 	//DefaultUserID is the default ID to use in cassandra image (RunAsUser)
 	DefaultUserID int64 = 1000
+
+	//DefaultTLSCertificateDuration is the default validity of the certificates of the nodes
+	DefaultTLSCertificateDuration = 365 * 24 * time.Hour
+	//DefaultTLSRenewBefore is the default delay before their expiration to renew the certificates of the nodes
+	DefaultTLSRenewBefore = 30 * 24 * time.Hour
+)
+
+//Values of AuthenticationSpec.DefaultUser and AuthenticationStatus.DefaultUser
+const (
+	DefaultUserDisable         string = "Disable"
+	DefaultUserChangePassword  string = "ChangePassword"
+	DefaultUserDisabled        string = "Disabled"
+	DefaultUserPasswordChanged string = "PasswordChanged"
+
+	//CredentialsUsernameKey is the key of the username in the credentials secrets
+	CredentialsUsernameKey = "username"
+	//CredentialsPasswordKey is the key of the password in the credentials secrets
+	CredentialsPasswordKey = "password"
+)
+
+//Values of CassandraClusterSpec.SeedMode
+const (
+	SeedModePodList string = "PodList"
+	SeedModeService string = "Service"
+)
+
+//Values of TLSSpec.InternodeEncryption
+const (
+	InternodeEncryptionNone string = "none"
+	InternodeEncryptionAll  string = "all"
+	InternodeEncryptionDC   string = "dc"
+	InternodeEncryptionRack string = "rack"
 )
 
 const (
@@ -67,14 +104,16 @@ const (
 	StatusError       string = "Error"
 
 	//Available actions
-	ActionUpdateConfigMap   string = "UpdateConfigMap"
-	ActionUpdateDockerImage string = "UpdateDockerImage"
-	ActionUpdateSeedList    string = "UpdateSeedList"
-	ActionRollingRestart    string = "RollingRestart"
-	ActionUpdateResources   string = "UpdateResources"
-	ActionUpdateStatefulSet string = "UpdateStatefulSet"
-	ActionScaleUp           string = "ScaleUp"
-	ActionScaleDown         string = "ScaleDown"
+	ActionUpdateConfigMap    string = "UpdateConfigMap"
+	ActionUpdateDockerImage  string = "UpdateDockerImage"
+	ActionUpdateSeedList     string = "UpdateSeedList"
+	ActionRollingRestart     string = "RollingRestart"
+	ActionUpdateResources    string = "UpdateResources"
+	ActionUpdateStatefulSet  string = "UpdateStatefulSet"
+	ActionUpdateDataCapacity string = "UpdateDataCapacity"
+	ActionScaleUp            string = "ScaleUp"
+	ActionScaleDown          string = "ScaleDown"
+	ActionAddRack            string = "AddRack"
 
 	ActionDeleteDC   string = "ActionDeleteDC"
 	ActionDeleteRack string = "ActionDeleteRack"
@@ -87,10 +126,29 @@ const (
 	OperationDecommission    string = "decommission"
 	OperationRebuild         string = "rebuild"
 	OperationRemove          string = "remove"
+	OperationRepair          string = "repair"
 )
 
-// SetDefaults sets the default values for the cassandra spec and returns true if the spec was changed
+// SetDefaults sets the default values for the cassandra spec and initializes the status of a new cluster
+// It returns true if the spec or the status was changed
 func (cc *CassandraCluster) SetDefaults() bool {
+	changed := cc.SetSpecDefaults()
+	if len(cc.Status.Phase) == 0 {
+		cc.Status.Phase = ClusterPhaseInitial
+		if cc.InitCassandraRackList() < 1 {
+			logrus.Errorf("[%s]: We should have at list One Rack, Please correct the Error", cc.Name)
+		}
+		if cc.Status.SeedList == nil {
+			cc.Status.SeedList = cc.InitSeedList()
+		}
+		changed = true
+	}
+	return changed
+}
+
+// SetSpecDefaults sets the default values for the cassandra spec and returns true if the spec was changed
+// It is used by the mutating webhook when a cluster is created
+func (cc *CassandraCluster) SetSpecDefaults() bool {
 	changed := false
 	ccs := &cc.Spec
 	if ccs.NodesPerRacks == 0 {
@@ -109,24 +167,33 @@ func (cc *CassandraCluster) SetDefaults() bool {
 		ccs.Version = defaultVersion
 		changed = true
 	}
+	if len(ccs.SeedMode) == 0 {
+		ccs.SeedMode = SeedModePodList
+		changed = true
+	}
 	if ccs.RunAsUser == nil {
 		ccs.RunAsUser = func(i int64) *int64 { return &i }(DefaultUserID)
-	}
-	if len(cc.Status.Phase) == 0 {
-		cc.Status.Phase = ClusterPhaseInitial
-		if cc.InitCassandraRackList() < 1 {
-			logrus.Errorf("[%s]: We should have at list One Rack, Please correct the Error", cc.Name)
-		}
-		if cc.Status.SeedList == nil {
-			cc.Status.SeedList = cc.InitSeedList()
-		}
 		changed = true
 	}
 	if ccs.MaxPodUnavailable == 0 {
 		ccs.MaxPodUnavailable = defaultMaxPodUnavailable
+		changed = true
+	}
+	if cc.Spec.Resources.setLimitsDefaults() {
+		changed = true
+	}
+	for dc := range cc.Spec.Topology.DC {
+		if cc.Spec.Topology.DC[dc].Resources.setLimitsDefaults() {
+			changed = true
+		}
+		for rack := range cc.Spec.Topology.DC[dc].Rack {
+			if cc.Spec.Topology.DC[dc].Rack[rack].Resources.setLimitsDefaults() {
+				changed = true
+			}
+		}
 	}
-	if cc.Spec.Resources.Limits == (CPUAndMem{}) {
-		cc.Spec.Resources.Limits = cc.Spec.Resources.Requests
+	if cc.GetDCSize() < 1 {
+		cc.initTopology(DefaultCassandraDC, DefaultCassandraRack)
 		changed = true
 	}
 
@@ -290,61 +357,104 @@ func (cc *CassandraCluster) InitCassandraRackinStatus(status *CassandraClusterSt
 }
 
 // Initialisation of the Cassandra SeedList
-// We want 3 seed nodes for each DC
+// Each DC has SeedsPerDC seeds (3 by default): the SeedOrdinals of its racks, then the first nodes of its racks taken
+// in turn
 func (cc *CassandraCluster) InitSeedList() []string {
-
-	var dcName, rackName string
-	var nbRack int = 0
-	var indice int32
 	var seedList []string
 
 	dcsize := cc.GetDCSize()
 
 	if dcsize < 1 {
-		dcName = DefaultCassandraDC
-		rackName = DefaultCassandraRack
-		nbRack++
-		for indice = 0; indice < cc.Spec.NodesPerRacks && indice < 3; indice++ {
-			cc.addNewSeed(&seedList, dcName, rackName, indice)
+		cc.addDCSeeds(&seedList, DefaultCassandraDC, []Rack{{Name: DefaultCassandraRack}},
+			[]int32{cc.Spec.NodesPerRacks})
+		return seedList
+	}
+	for dc := 0; dc < dcsize; dc++ {
+		dcName := cc.GetDCName(dc)
+		racks := cc.Spec.Topology.DC[dc].Rack
+		if len(racks) < 1 && cc.Spec.Topology.DC[dc].NodeTopologyKey != "" {
+			//The racks of the DC are not generated yet
+			continue
 		}
-	} else {
-		for dc := 0; dc < dcsize; dc++ {
-			dcName = cc.GetDCName(dc)
-			var nbSeedInDC int = 0
+		if len(racks) < 1 {
+			cc.addDCSeeds(&seedList, dcName, []Rack{{Name: DefaultCassandraRack}}, []int32{cc.Spec.NodesPerRacks})
+			continue
+		}
+		var nodesPerRacks []int32
+		for _, rack := range racks {
+			nodesPerRacks = append(nodesPerRacks, cc.GetNodesPerRacks(cc.GetDCRackName(dcName, rack.Name)))
+		}
+		cc.addDCSeeds(&seedList, dcName, racks, nodesPerRacks)
+	}
+	return seedList
+}
 
-			racksize := cc.GetRackSize(dc)
-			if racksize < 1 {
-				rackName = DefaultCassandraRack
-				nbRack++
-				for indice = 0; indice < cc.Spec.NodesPerRacks && indice < 3; indice++ {
-					cc.addNewSeed(&seedList, dcName, rackName, indice)
-				}
-			} else {
+//addDCSeeds adds the seeds of the DC dcName to seedList, nodesPerRacks are the numbers of nodes of its racks
+func (cc *CassandraCluster) addDCSeeds(seedList *[]string, dcName string, racks []Rack, nodesPerRacks []int32) {
+	seedsPerDC := cc.GetSeedsPerDC(dcName)
+	seeds := make([]map[int32]bool, len(racks))
+	var nbSeedInDC int32
+
+	for rack := range racks {
+		seeds[rack] = map[int32]bool{}
+		for _, ordinal := range racks[rack].SeedOrdinals {
+			if ordinal >= 0 && ordinal < nodesPerRacks[rack] && !seeds[rack][ordinal] {
+				seeds[rack][ordinal] = true
+				nbSeedInDC++
+			}
+		}
+	}
+	//The other seeds are spread over the racks
+	for indice := int32(0); nbSeedInDC < seedsPerDC; indice++ {
+		hasNodes := false
+		for rack := range racks {
+			if indice >= nodesPerRacks[rack] {
+				continue
+			}
+			hasNodes = true
+			if nbSeedInDC < seedsPerDC && !seeds[rack][indice] {
+				seeds[rack][indice] = true
+				nbSeedInDC++
+			}
+		}
+		if !hasNodes {
+			break
+		}
+	}
 
-				for rack := 0; rack < racksize; rack++ {
-					rackName = cc.GetRackName(dc, rack)
-					dcRackName := cc.GetDCRackName(dcName, rackName)
-					nbRack++
-					nodesPerRacks := cc.GetNodesPerRacks(dcRackName)
-
-					switch racksize {
-					case 1:
-						for indice = 0; indice < nodesPerRacks && indice < 3 && nbSeedInDC < 3; indice++ {
-							cc.addNewSeed(&seedList, dcName, rackName, indice)
-							nbSeedInDC++
-						}
-					case 2:
-						for indice = 0; indice < nodesPerRacks && indice < 2 && nbSeedInDC < 3; indice++ {
-							cc.addNewSeed(&seedList, dcName, rackName, indice)
-							nbSeedInDC++
-						}
-					default:
-						if nbSeedInDC < 3 {
-							cc.addNewSeed(&seedList, dcName, rackName, 0)
-							nbSeedInDC++
-						}
-					}
+	for rack := range racks {
+		for indice := int32(0); indice < nodesPerRacks[rack]; indice++ {
+			if seeds[rack][indice] {
+				cc.addNewSeed(seedList, dcName, racks[rack].Name, indice)
+			}
+		}
+	}
+}
 
+//GetSeedsPerDC returns the number of seeds of the DC dcName: the one of the DC if set, else the one of
+//CassandraClusterSpec if set, else DefaultSeedsPerDC
+func (cc *CassandraCluster) GetSeedsPerDC(dcName string) int32 {
+	for _, dc := range cc.Spec.Topology.DC {
+		if dc.Name == dcName && dc.SeedsPerDC != nil {
+			return *dc.SeedsPerDC
+		}
+	}
+	if cc.Spec.SeedsPerDC != nil {
+		return *cc.Spec.SeedsPerDC
+	}
+	return DefaultSeedsPerDC
+}
+
+//GetPinnedSeedList returns the seeds of the SeedOrdinals of the racks, whose pods exist
+func (cc *CassandraCluster) GetPinnedSeedList() []string {
+	var seedList []string
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		for _, rack := range cc.Spec.Topology.DC[dc].Rack {
+			nodesPerRacks := cc.GetNodesPerRacks(cc.GetDCRackName(dcName, rack.Name))
+			for _, ordinal := range rack.SeedOrdinals {
+				if ordinal >= 0 && ordinal < nodesPerRacks {
+					cc.addNewSeed(&seedList, dcName, rack.Name, ordinal)
 				}
 			}
 		}
@@ -352,11 +462,64 @@ func (cc *CassandraCluster) InitSeedList() []string {
 	return seedList
 }
 
+//GetSeedMode returns the SeedMode of the cluster, the clusters created before it was defaulted use PodList
+func (cc *CassandraCluster) GetSeedMode() string {
+	if len(cc.Spec.SeedMode) == 0 {
+		return SeedModePodList
+	}
+	return cc.Spec.SeedMode
+}
+
 func (cc *CassandraCluster) GetSeedList(seedListTab *[]string) string {
 	seedList := strings.Join(*seedListTab, ",")
 	return seedList
 }
 
+//GetDataDirectories returns the data directories of the nodes, the one of the data volume followed by the ones of the
+//AdditionalDataVolumes
+func (cc *CassandraCluster) GetDataDirectories() []string {
+	directories := []string{"/var/lib/cassandra/data"}
+	for i := range cc.Spec.AdditionalDataVolumes {
+		directories = append(directories, fmt.Sprintf("/var/lib/cassandra-data%d", i+1))
+	}
+	return directories
+}
+
+//GetSeedServiceName returns the name of the headless Service selecting the pod of the seed index of the DC dcName
+func (cc *CassandraCluster) GetSeedServiceName(dcName string, index int) string {
+	return fmt.Sprintf("%s-%s-seed-%d", cc.Name, dcName, index)
+}
+
+//GetDCSeedList returns the seeds of seedList which are pods of the DC dcName
+func (cc *CassandraCluster) GetDCSeedList(seedList []string, dcName string) []string {
+	var dcSeedList []string
+	for _, seed := range seedList {
+		if strings.HasPrefix(seed, cc.Name+"-"+dcName+"-") {
+			dcSeedList = append(dcSeedList, seed)
+		}
+	}
+	return dcSeedList
+}
+
+//GetSeedServiceList returns the names of the seed Services given to Cassandra as seeds in the seed mode Service,
+//SeedsPerDC names for each DC so that the list does not change when the seeds move or the DCs scale
+func (cc *CassandraCluster) GetSeedServiceList() string {
+	var seedServices []string
+	//Without topology, the cluster has the default DC
+	dcsize := cc.GetDCSize()
+	if dcsize < 1 {
+		dcsize = 1
+	}
+	for dc := 0; dc < dcsize; dc++ {
+		dcName := cc.GetDCName(dc)
+		for index := 0; index < int(cc.GetSeedsPerDC(dcName)); index++ {
+			seedServices = append(seedServices, fmt.Sprintf("%s.%s", cc.GetSeedServiceName(dcName, index),
+				cc.Namespace))
+		}
+	}
+	return strings.Join(seedServices, ",")
+}
+
 func (cc *CassandraCluster) addNewSeed(seedList *[]string, dcName string, rackName string, indice int32) {
 	dcRackName := cc.GetDCRackName(dcName, rackName)
 	seed := fmt.Sprintf("%s-%s-%d.%s.%s", cc.Name, dcRackName, indice, cc.Name, cc.Namespace)
@@ -422,6 +585,10 @@ func (cc *CassandraCluster) InitCassandraRackList() int {
 		for dc := 0; dc < dcsize; dc++ {
 			dcName = cc.GetDCName(dc)
 			racksize := cc.GetRackSize(dc)
+			if racksize < 1 && cc.Spec.Topology.DC[dc].NodeTopologyKey != "" {
+				//The racks of the DC are not generated yet
+				continue
+			}
 			if racksize < 1 {
 				rackName = DefaultCassandraRack
 				nbRack++
@@ -443,6 +610,10 @@ func (cc *CassandraCluster) InitCassandraRackList() int {
 
 // GetNodesPerRacks sends back the number of cassandra nodes to uses for this dc-rack
 func (cc *CassandraCluster) GetNodesPerRacks(dcRackName string) int32 {
+	//The nodes of a removed rack are decommissioned
+	if rackStatus, ok := cc.Status.CassandraRackStatus[dcRackName]; ok && rackStatus != nil && rackStatus.Removing {
+		return 0
+	}
 	nodesPerRacks := cc.GetDCNodesPerRacksFromDCRackName(dcRackName)
 	return nodesPerRacks
 }
@@ -458,8 +629,13 @@ func (cc *CassandraCluster) GetDCRackNames() []string {
 	for dc := 0; dc < dcsize; dc++ {
 		dcName := cc.GetDCName(dc)
 		racksize := cc.GetRackSize(dc)
+		if racksize < 1 && cc.Spec.Topology.DC[dc].NodeTopologyKey != "" {
+			//The racks of the DC are not generated yet
+			continue
+		}
 		if racksize < 1 {
-			return dcRackNames
+			dcRackNames = append(dcRackNames, cc.GetDCRackName(dcName, DefaultCassandraRack))
+			continue
 		}
 		for rack := 0; rack < racksize; rack++ {
 			rackName := cc.GetRackName(dc, rack)
@@ -538,6 +714,79 @@ func (cc *CassandraCluster) GetRollingPartitionPerRacks(dcRackName string) int32
 	return 0
 }
 
+//setLimitsDefaults sets the Limits to the Requests when only the Requests are set and returns true if it set them
+func (resources *CassandraResources) setLimitsDefaults() bool {
+	if resources == nil || resources.Limits != (CPUAndMem{}) || resources.Requests == (CPUAndMem{}) {
+		return false
+	}
+	resources.Limits = resources.Requests
+	return true
+}
+
+//GetResourcesFromDCRackName returns the resources applied to the cassandra containers of the rack dcRackName:
+//those of the rack if set, else those of its DC if set, else those of CassandraClusterSpec
+func (cc *CassandraCluster) GetResourcesFromDCRackName(dcRackName string) CassandraResources {
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			rackName := cc.GetRackName(dc, rack)
+			if dcRackName != cc.GetDCRackName(dcName, rackName) {
+				continue
+			}
+			if resources := cc.Spec.Topology.DC[dc].Rack[rack].Resources; resources != nil {
+				return *resources
+			}
+			if resources := cc.Spec.Topology.DC[dc].Resources; resources != nil {
+				return *resources
+			}
+			return cc.Spec.Resources
+		}
+	}
+	return cc.Spec.Resources
+}
+
+//GetPodSchedulingFromDCRackName returns the scheduling of the pods of the rack dcRackName: the tolerations of the
+//cluster, of its DC and of the rack are added together, the other fields are those of the rack if set, else those of
+//its DC if set, else those of CassandraClusterSpec
+func (cc *CassandraCluster) GetPodSchedulingFromDCRackName(dcRackName string) PodScheduling {
+	scheduling := *cc.Spec.PodScheduling.DeepCopy()
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			rackName := cc.GetRackName(dc, rack)
+			if dcRackName != cc.GetDCRackName(dcName, rackName) {
+				continue
+			}
+			scheduling.merge(cc.Spec.Topology.DC[dc].PodScheduling)
+			scheduling.merge(cc.Spec.Topology.DC[dc].Rack[rack].PodScheduling)
+			return scheduling
+		}
+	}
+	return scheduling
+}
+
+//GetDataCapacityForDC returns the capacity of the persistent volume claims of the DC dcName: the one of the DC if set,
+//else the one of CassandraClusterSpec
+func (cc *CassandraCluster) GetDataCapacityForDC(dcName string) string {
+	for _, dc := range cc.Spec.Topology.DC {
+		if dc.Name == dcName && dc.DataCapacity != "" {
+			return dc.DataCapacity
+		}
+	}
+	return cc.Spec.DataCapacity
+}
+
+//GetDataStorageClassForDC returns the storage class of the persistent volume claims of the DC dcName: the one of the
+//DC if set, else the one of CassandraClusterSpec
+func (cc *CassandraCluster) GetDataStorageClassForDC(dcName string) string {
+	for _, dc := range cc.Spec.Topology.DC {
+		if dc.Name == dcName && dc.DataStorageClass != "" {
+			return dc.DataStorageClass
+		}
+	}
+	return cc.Spec.DataStorageClass
+}
+
 //GetDCNodesPerRacksFromName send NodesPerRack which is applied for the specified dc name
 //return true if we found, and false if not
 func (cc *CassandraCluster) GetDCNodesPerRacksFromName(dctarget string) (bool, int32) {
@@ -595,6 +844,110 @@ func (rack *RackSlice) Remove(i int) {
 	*rack = append((*rack)[:i], (*rack)[i+1:]...)
 }
 
+//merge adds the tolerations of override missing in ps and replaces the other fields of ps by those set in override
+func (ps *PodScheduling) merge(override PodScheduling) {
+	for _, toleration := range override.Tolerations {
+		if !containsToleration(ps.Tolerations, toleration) {
+			ps.Tolerations = append(ps.Tolerations, toleration)
+		}
+	}
+	if override.PriorityClassName != "" {
+		ps.PriorityClassName = override.PriorityClassName
+	}
+	if override.SchedulerName != "" {
+		ps.SchedulerName = override.SchedulerName
+	}
+	if override.ServiceAccountName != "" {
+		ps.ServiceAccountName = override.ServiceAccountName
+	}
+}
+
+func containsToleration(tolerations []v1.Toleration, toleration v1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(&toleration) {
+			return true
+		}
+	}
+	return false
+}
+
+//GetInternodeEncryption returns the internode_encryption to use, all by default
+func (tls *TLSSpec) GetInternodeEncryption() string {
+	if tls.InternodeEncryption == "" {
+		return InternodeEncryptionAll
+	}
+	return tls.InternodeEncryption
+}
+
+//GetCertificateDuration returns the validity of the certificates of the nodes
+func (tls *TLSSpec) GetCertificateDuration() time.Duration {
+	if tls.CertificateDuration == nil {
+		return DefaultTLSCertificateDuration
+	}
+	return tls.CertificateDuration.Duration
+}
+
+//GetRenewBefore returns how long before their expiration the certificates of the nodes are renewed
+func (tls *TLSSpec) GetRenewBefore() time.Duration {
+	if tls.RenewBefore == nil {
+		return DefaultTLSRenewBefore
+	}
+	return tls.RenewBefore.Duration
+}
+
+//Validate returns an error if the TLS configuration can't be applied, a nil configuration is valid
+func (tls *TLSSpec) Validate() error {
+	if tls == nil {
+		return nil
+	}
+	switch tls.GetInternodeEncryption() {
+	case InternodeEncryptionNone, InternodeEncryptionAll, InternodeEncryptionDC, InternodeEncryptionRack:
+	default:
+		return fmt.Errorf("TLS internodeEncryption must be one of none, all, dc or rack, not [%s]",
+			tls.InternodeEncryption)
+	}
+	if tls.GetCertificateDuration() <= tls.GetRenewBefore() {
+		return fmt.Errorf("TLS certificateDuration [%v] must be greater than renewBefore [%v]",
+			tls.GetCertificateDuration(), tls.GetRenewBefore())
+	}
+	return nil
+}
+
+//Validate returns an error if the capacity of the volume is not a quantity, a nil volume is valid
+func (volume *StorageVolume) Validate() error {
+	if volume == nil {
+		return nil
+	}
+	if _, err := resource.ParseQuantity(volume.Capacity); err != nil {
+		return fmt.Errorf("capacity [%s] is not a valid quantity: %v", volume.Capacity, err)
+	}
+	return nil
+}
+
+//GetDefaultUser returns what is done with the default cassandra user, Disable by default
+func (auth *AuthenticationSpec) GetDefaultUser() string {
+	if auth.DefaultUser == "" {
+		return DefaultUserDisable
+	}
+	return auth.DefaultUser
+}
+
+//Validate returns an error if the authentication can't be configured, a nil configuration is valid
+func (auth *AuthenticationSpec) Validate() error {
+	if auth == nil {
+		return nil
+	}
+	if auth.SuperuserSecret.Name == "" {
+		return fmt.Errorf("Authentication superuserSecret must be set")
+	}
+	switch auth.GetDefaultUser() {
+	case DefaultUserDisable, DefaultUserChangePassword:
+	default:
+		return fmt.Errorf("Authentication defaultUser must be Disable or ChangePassword, not [%s]", auth.DefaultUser)
+	}
+	return nil
+}
+
 // CassandraClusterSpec defines the configuration of CassandraCluster
 type CassandraClusterSpec struct {
 	// Number of nodes to deploy for a Cassandra deployment in each Racks.
@@ -642,6 +995,14 @@ type CassandraClusterSpec struct {
 	//by default a boolean is false
 	AutoUpdateSeedList bool `json:"autoUpdateSeedList,omitempty"`
 
+	//SeedMode defines how the seeds are given to Cassandra. PodList (default) sets the names of the seed pods in
+	//CASSANDRA_SEEDS, a change of the seeds needs a rolling UpdateSeedList. Service sets the names of a headless Service
+	//per seed selecting its pod, the Operator replaces the seeds which are not ready by ready pods of their DC
+	SeedMode string `json:"seedMode,omitempty"`
+
+	//SeedsPerDC is the number of seeds of each DC, 3 by default
+	SeedsPerDC *int32 `json:"seedsPerDC,omitempty"`
+
 	MaxPodUnavailable int32 `json:"maxPodUnavailable"` //Number of MasPodUnavailable used in the PDB
 
 	//Very special Flag to hack CassKop reconcile loop - use with really good Care
@@ -653,6 +1014,14 @@ type CassandraClusterSpec struct {
 	//Define StorageClass for Persistent Volume Claims in the local storage.
 	DataStorageClass string `json:"dataStorageClass,omitempty"`
 
+	//CommitLogVolume puts the commitlog of the nodes on its own Persistent Volume Claim, for instance on a faster disk
+	//It can't be changed once the cluster is created
+	CommitLogVolume *StorageVolume `json:"commitLogVolume,omitempty"`
+
+	//AdditionalDataVolumes are Persistent Volume Claims used as data directories of the nodes (JBOD) in addition to
+	//the data volume. They can't be changed once the cluster is created
+	AdditionalDataVolumes []StorageVolume `json:"additionalDataVolumes,omitempty"`
+
 	// Deploy or Not Service that provide access to monitoring metrics
 	//Exporter bool `json:"exporter,omitempty"`
 
@@ -669,6 +1038,44 @@ type CassandraClusterSpec struct {
 	// JMX Secret if Set is used to set JMX_USER and JMX_PASSWORD
 	ImageJolokiaSecret v1.LocalObjectReference `json:"imageJolokiaSecret,omitempty"`
 
+	//SidecarContainers are added to the pods next to the cassandra container
+	SidecarContainers []v1.Container `json:"sidecarContainers,omitempty"`
+
+	//InitContainers are run in the pods before the cassandra container, after the restore init container
+	InitContainers []v1.Container `json:"initContainers,omitempty"`
+
+	//ExtraVolumes are added to the pods, they can be mounted by the cassandra container with ExtraVolumeMounts or by
+	//the sidecar and init containers
+	ExtraVolumes []v1.Volume `json:"extraVolumes,omitempty"`
+
+	//ExtraVolumeMounts are mounted in the cassandra container
+	ExtraVolumeMounts []v1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+
+	//ExtraEnv are environment variables added to the cassandra container after the ones set by the Operator
+	ExtraEnv []v1.EnvVar `json:"extraEnv,omitempty"`
+
+	//PodAnnotations are added to the pods
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	//PodLabels are added to the pods, they can't override the labels set by the Operator
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	//PodScheduling defines the tolerations, priority class, scheduler and service account of the pods
+	//They can be completed or overridden by the DCs and the racks of the topology
+	PodScheduling `json:",inline"`
+
+	//RestoreFrom seeds the data of the nodes with the files of a backup before Cassandra starts
+	//It is set by the CassandraRestore which creates the cluster
+	RestoreFrom *RestoreFrom `json:"restoreFrom,omitempty"`
+
+	//Authentication replaces the default cassandra superuser by the superuser of a Secret
+	//If it is not set, the default cassandra superuser is kept
+	Authentication *AuthenticationSpec `json:"authentication,omitempty"`
+
+	//TLS encrypts the internode and client communications with certificates issued by the Operator
+	//If it is not set, the communications are not encrypted
+	TLS *TLSSpec `json:"tls,omitempty"`
+
 	//Topology to create Cassandra DC and Racks and to target appropriate Kubernetes Nodes
 	Topology Topology `json:"topology,omitempty"`
 }
@@ -691,6 +1098,11 @@ type DC struct {
 	//List of Racks defined in the Cassandra DC
 	Rack RackSlice `json:"rack,omitempty"`
 
+	//NodeTopologyKey is a label of the kubernetes nodes, for example topology.kubernetes.io/zone. If it is set and the
+	//DC has no rack, the Operator creates one rack per value of this label found on the nodes matching the labels of
+	//the DC, the rack targets the nodes with this value
+	NodeTopologyKey string `json:"nodeTopologyKey,omitempty"`
+
 	// Number of nodes to deploy for a Cassandra deployment in each Racks.
 	// Default: 1.
 	// Optional, if not filled, used value define in CassandraClusterSpec
@@ -698,6 +1110,27 @@ type DC struct {
 
 	//NumTokens : configure the CASSANDRA_NUM_TOKENS parameter which can be different for each DD
 	NumTokens *int32 `json:"numTokens,omitempty"`
+
+	//SeedsPerDC is the number of seeds of the DC
+	//Optional, if not filled, used value define in CassandraClusterSpec
+	SeedsPerDC *int32 `json:"seedsPerDC,omitempty"`
+
+	//Resources of the cassandra containers of the DC
+	//Optional, if not filled, used value define in CassandraClusterSpec
+	Resources *CassandraResources `json:"resources,omitempty"`
+
+	//Define the Capacity for Persistent Volume Claims of the DC, once the DC is created it can only grow if its
+	//StorageClass allows volume expansion
+	//Optional, if not filled, used value define in CassandraClusterSpec
+	DataCapacity string `json:"dataCapacity,omitempty"`
+
+	//Define StorageClass for Persistent Volume Claims of the DC, it can't be changed once the DC is created
+	//Optional, if not filled, used value define in CassandraClusterSpec
+	DataStorageClass string `json:"dataStorageClass,omitempty"`
+
+	//PodScheduling of the pods of the DC, the tolerations are added to those of CassandraClusterSpec and the other
+	//fields override those of CassandraClusterSpec when they are set
+	PodScheduling `json:",inline"`
 }
 
 // Rack allow to configure Cassandra Rack according to kubernetes nodeselector labels
@@ -712,6 +1145,33 @@ type Rack struct {
 
 	//Labels used to target Kubernetes nodes
 	Labels map[string]string `json:"labels,omitempty"`
+
+	//Resources of the cassandra containers of the Rack
+	//Optional, if not filled, used value define in the DC or in CassandraClusterSpec
+	Resources *CassandraResources `json:"resources,omitempty"`
+
+	//SeedOrdinals are the ordinals of the pods of the Rack which are always seeds, they are counted in the seeds of
+	//the DC
+	SeedOrdinals []int32 `json:"seedOrdinals,omitempty"`
+
+	//PodScheduling of the pods of the Rack, the tolerations are added to those of the DC and the other fields
+	//override those of the DC when they are set
+	PodScheduling `json:",inline"`
+}
+
+// PodScheduling defines where and how the pods of a cluster, a DC or a rack are scheduled
+type PodScheduling struct {
+	//Tolerations of the pods, to run them on tainted nodes
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	//PriorityClassName is the name of the PriorityClass of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	//SchedulerName is the scheduler of the pods, the default scheduler if empty
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	//ServiceAccountName is the service account the pods run as, the default service account if empty
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
 // PodPolicy defines the policy for pods owned by vault operator.
@@ -726,12 +1186,55 @@ type CassandraResources struct {
 	Limits   CPUAndMem `json:"limits,omitempty"`
 }
 
+// StorageVolume defines a Persistent Volume Claim of each cassandra node
+type StorageVolume struct {
+	//Capacity of the Persistent Volume Claim, for example "100Gi"
+	Capacity string `json:"capacity"`
+	//StorageClass of the Persistent Volume Claim, the default storage class if empty
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
 // CPUAndMem defines how many cpu and ram the container will request/limit
 type CPUAndMem struct {
 	CPU    string `json:"cpu"`
 	Memory string `json:"memory"`
 }
 
+// AuthenticationSpec defines the superuser created by the Operator once the cluster is running
+type AuthenticationSpec struct {
+	//SuperuserSecret holds the username and password keys of the superuser created by the Operator
+	SuperuserSecret v1.LocalObjectReference `json:"superuserSecret"`
+
+	//DefaultUser defines what is done with the default cassandra superuser once the superuser is created:
+	//Disable removes its login and superuser rights, ChangePassword gives it a random password stored in the
+	//Secret <cluster>-default-user
+	//Default: Disable
+	DefaultUser string `json:"defaultUser,omitempty"`
+}
+
+// TLSSpec defines how the Operator issues the certificates of the Cassandra nodes
+type TLSSpec struct {
+	//CASecret is the name of a Secret of type kubernetes.io/tls holding the CA (tls.crt and tls.key) used to sign
+	//the certificates of the nodes. If it is empty, the Operator generates a CA in the Secret <cluster>-ca
+	CASecret string `json:"caSecret,omitempty"`
+
+	//InternodeEncryption sets server_encryption_options.internode_encryption: none, all, dc or rack
+	//Default: all
+	InternodeEncryption string `json:"internodeEncryption,omitempty"`
+
+	//ClientEncryption enables client_encryption_options for the CQL clients
+	ClientEncryption bool `json:"clientEncryption,omitempty"`
+
+	//CertificateDuration is the validity of the certificates of the nodes
+	//Default: 8760h (1 year)
+	CertificateDuration *metav1.Duration `json:"certificateDuration,omitempty"`
+
+	//RenewBefore is how long before their expiration the certificates are renewed, the nodes are then restarted
+	//rack by rack with a RollingRestart
+	//Default: 720h (30 days)
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
 //CassandraRackStatus defines states of Cassandra for 1 rack (1 statefulset)
 type CassandraRackStatus struct {
 	// Phase indicates the state this Cassandra cluster jumps in.
@@ -744,6 +1247,13 @@ type CassandraRackStatus struct {
 
 	// PodLastOperation manage status for Pod Operation (nodetool cleanup, upgradesstables..)
 	PodLastOperation PodLastOperation `json:"podLastOperation,omitempty"`
+
+	//TLSGeneration is the generation of the certificates loaded by the nodes of the rack
+	TLSGeneration string `json:"tlsGeneration,omitempty"`
+
+	//Removing is set when the rack has been removed from the topology, its nodes are decommissioned before the rack
+	//is deleted
+	Removing bool `json:"removing,omitempty"`
 }
 
 //CassandraClusterStatus defines Global state of CassandraCluster
@@ -765,6 +1275,109 @@ type CassandraClusterStatus struct {
 
 	//CassandraRackStatusList list les Status pour chaque Racks
 	CassandraRackStatus map[string]*CassandraRackStatus `json:"cassandraRackStatus,omitempty"`
+
+	//Conditions of the cluster, computed by the Operator from the status of the racks
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	//TLS is the state of the certificates issued by the Operator
+	TLS *TLSStatus `json:"tls,omitempty"`
+
+	//Authentication is the state of the superuser created by the Operator
+	Authentication *AuthenticationStatus `json:"authentication,omitempty"`
+}
+
+//AuthenticationStatus defines the state of the superuser created by the Operator
+type AuthenticationStatus struct {
+	//Superuser is the name of the superuser created
+	Superuser string `json:"superuser,omitempty"`
+
+	//DefaultUser is Disabled or PasswordChanged once the default cassandra superuser has been handled
+	DefaultUser string `json:"defaultUser,omitempty"`
+
+	//SuperuserSecretVersion is the resourceVersion of the superuser Secret when it was last applied
+	SuperuserSecretVersion string `json:"superuserSecretVersion,omitempty"`
+
+	//Status is Done when the superuser is created and the default superuser handled, Error otherwise
+	Status string `json:"status,omitempty"`
+
+	//Message is the error of the last attempt
+	Message string `json:"message,omitempty"`
+
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+//TLSStatus defines the state of the certificates of the nodes
+type TLSStatus struct {
+	//Generation of the certificates, it changes each time they are all issued again
+	Generation string `json:"generation,omitempty"`
+
+	//NotAfter is the expiration of the certificates of this generation
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+}
+
+//ClusterConditionType is the type of a condition of a CassandraCluster
+type ClusterConditionType string
+
+//List of the conditions of a CassandraCluster
+const (
+	//ClusterReady is true when all racks are running
+	ClusterReady ClusterConditionType = "Ready"
+	//ClusterProgressing is true when an action is ongoing on the cluster
+	ClusterProgressing ClusterConditionType = "Progressing"
+	//ClusterDegraded is true when pods of a rack are not ready while no action is ongoing
+	ClusterDegraded ClusterConditionType = "Degraded"
+	//ClusterScalingDown is true when nodes of a rack are decommissioned
+	ClusterScalingDown ClusterConditionType = "ScalingDown"
+	//ClusterOperationFailed is true when the last pod operation of a rack failed on some pods
+	ClusterOperationFailed ClusterConditionType = "OperationFailed"
+	//ClusterChangeRefused is true when the last change of the spec was refused and restored by the Operator
+	ClusterChangeRefused ClusterConditionType = "ChangeRefused"
+)
+
+//Reasons of the condition ChangeRefused
+const (
+	ReasonNonAllowedChange string = "NonAllowedChange"
+	ReasonTopologyChange   string = "TopologyChangeRefused"
+	ReasonScaleDownRefused string = "ScaleDownRefused"
+	ReasonChangeAccepted   string = "ChangeAccepted"
+)
+
+//ClusterCondition describes the state of a CassandraCluster
+type ClusterCondition struct {
+	Type   ClusterConditionType `json:"type"`
+	Status v1.ConditionStatus   `json:"status"`
+	//LastTransitionTime is the last time the status of the condition changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	//Reason is a CamelCase reason of the last transition
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+//GetCondition returns the condition of type conditionType or nil if it is not set
+func (status *CassandraClusterStatus) GetCondition(conditionType ClusterConditionType) *ClusterCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+//SetCondition sets the status, reason and message of the condition of type conditionType
+//The transition time only changes with the status of the condition
+func (status *CassandraClusterStatus) SetCondition(conditionType ClusterConditionType,
+	conditionStatus v1.ConditionStatus, reason, message string) {
+	condition := status.GetCondition(conditionType)
+	if condition == nil {
+		status.Conditions = append(status.Conditions, ClusterCondition{Type: conditionType})
+		condition = &status.Conditions[len(status.Conditions)-1]
+	}
+	if condition.Status != conditionStatus {
+		condition.Status = conditionStatus
+		condition.LastTransitionTime = metav1.Now()
+	}
+	condition.Reason = reason
+	condition.Message = message
 }
 
 // CassandraLastAction defines status of the CassandraStatefulset
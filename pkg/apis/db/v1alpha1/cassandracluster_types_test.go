@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
@@ -421,6 +422,42 @@ func TestInitSeedList_1DC1R1P(t *testing.T) {
 	assert.Equal("cassandra-demo-online-rack1-0.cassandra-demo.ns,cassandra-demo-online-rack2-0.cassandra-demo.ns", cc.GetSeedList(&cc.Status.SeedList))
 }
 
+func TestInitSeedList_SeedsPerDC(t *testing.T) {
+	assert := assert.New(t)
+
+	cc := helperInitCluster(t, "cassandracluster-1DC.yaml")
+
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(1)
+	assert.Equal([]string{"cassandra-demo-online-rack1-0.cassandra-demo.ns"}, cc.InitSeedList())
+
+	//The value of the DC overrides the one of the cluster
+	cc.Spec.Topology.DC[0].SeedsPerDC = func(i int32) *int32 { return &i }(5)
+	assert.Equal([]string{
+		"cassandra-demo-online-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-online-rack1-1.cassandra-demo.ns",
+		"cassandra-demo-online-rack1-2.cassandra-demo.ns",
+		"cassandra-demo-online-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-online-rack2-1.cassandra-demo.ns"}, cc.InitSeedList())
+}
+
+func TestInitSeedList_SeedOrdinals(t *testing.T) {
+	assert := assert.New(t)
+
+	cc := helperInitCluster(t, "cassandracluster-1DC.yaml")
+
+	//The ordinal 9 is ignored as the rack has 7 nodes
+	cc.Spec.Topology.DC[0].Rack[1].SeedOrdinals = []int32{4, 9}
+	assert.Equal([]string{
+		"cassandra-demo-online-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-online-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-online-rack2-4.cassandra-demo.ns"}, cc.InitSeedList())
+	assert.Equal([]string{"cassandra-demo-online-rack2-4.cassandra-demo.ns"}, cc.GetPinnedSeedList())
+
+	//The pinned seeds are kept when there are more than SeedsPerDC
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(1)
+	assert.Equal([]string{"cassandra-demo-online-rack2-4.cassandra-demo.ns"}, cc.InitSeedList())
+}
+
 func TestIsPodInSeedList(t *testing.T) {
 	assert := assert.New(t)
 
@@ -474,7 +511,7 @@ func TestSetDefaults(t *testing.T) {
 		Spec: CassandraClusterSpec{
 			Resources: CassandraResources{
 				Requests: CPUAndMem{
-					CPU: "500m",
+					CPU:    "500m",
 					Memory: "1Gi",
 				},
 			},
@@ -491,7 +528,56 @@ func TestSetDefaults(t *testing.T) {
 	assert.Equal(DefaultUserID, *cluster.Spec.RunAsUser)
 	assert.Equal(ClusterPhaseInitial, cluster.Status.Phase)
 	assert.Equal(int32(defaultMaxPodUnavailable), cluster.Spec.MaxPodUnavailable)
+	assert.Equal(SeedModePodList, cluster.Spec.SeedMode)
 	assert.Equal([]string{"defaults-test-dc1-rack1-0.defaults-test.default"}, cluster.Status.SeedList)
 
 }
 
+func TestSetSpecDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	cluster := CassandraCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "defaults-test",
+			Namespace: "default",
+		},
+	}
+
+	assert.True(cluster.SetSpecDefaults())
+
+	assert.Equal(int32(1), cluster.Spec.NodesPerRacks)
+	assert.Equal(defaultBaseImage, cluster.Spec.BaseImage)
+	assert.Equal(DefaultUserID, *cluster.Spec.RunAsUser)
+	assert.Equal(int32(defaultMaxPodUnavailable), cluster.Spec.MaxPodUnavailable)
+	assert.Equal(SeedModePodList, cluster.Spec.SeedMode)
+	assert.Equal(CPUAndMem{}, cluster.Spec.Resources.Limits)
+	assert.Equal(DefaultCassandraDC, cluster.GetDCName(0))
+	assert.Equal(DefaultCassandraRack, cluster.GetRackName(0, 0))
+	//The status is initialized by the operator
+	assert.Equal("", cluster.Status.Phase)
+
+	//Defaults are set only once
+	assert.False(cluster.SetSpecDefaults())
+}
+
+func TestTLSSpecValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	var tls *TLSSpec
+	assert.Nil(tls.Validate())
+
+	tls = &TLSSpec{}
+	assert.Nil(tls.Validate())
+	assert.Equal(InternodeEncryptionAll, tls.GetInternodeEncryption())
+	assert.Equal(DefaultTLSCertificateDuration, tls.GetCertificateDuration())
+	assert.Equal(DefaultTLSRenewBefore, tls.GetRenewBefore())
+
+	tls.InternodeEncryption = "everything"
+	assert.NotNil(tls.Validate())
+
+	tls.InternodeEncryption = InternodeEncryptionRack
+	tls.CertificateDuration = &metav1.Duration{Duration: 24 * time.Hour}
+	assert.NotNil(tls.Validate())
+	tls.RenewBefore = &metav1.Duration{Duration: time.Hour}
+	assert.Nil(tls.Validate())
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//NetworkTopologyStrategy replicates a keyspace with a replication factor per DC
+	NetworkTopologyStrategy string = "NetworkTopologyStrategy"
+	//SimpleStrategy replicates a keyspace without taking the DCs into account, it needs a cluster with one DC
+	SimpleStrategy string = "SimpleStrategy"
+
+	//KeyspacePhasePending is the phase of a keyspace waiting for its cluster to be running
+	KeyspacePhasePending string = "Pending"
+)
+
+var keyspaceNameRegexp = regexp.MustCompile(`^\w{1,48}$`)
+
+// CassandraKeyspaceSpec defines the desired state of CassandraKeyspace
+type CassandraKeyspaceSpec struct {
+	//Name of the CassandraCluster where the keyspace is created, it must live in the same namespace
+	Cluster string `json:"cluster"`
+	//Name of the keyspace, it can't be changed once the keyspace is created
+	Name string `json:"name"`
+	//Strategy is the replication strategy of the keyspace: NetworkTopologyStrategy (default) or SimpleStrategy
+	Strategy string `json:"strategy,omitempty"`
+	//ReplicationFactor is the number of replicas of the keyspace in each DC of the cluster
+	//It can't be larger than the number of nodes of the DC
+	ReplicationFactor map[string]int32 `json:"replicationFactor"`
+	//DurableWrites enables the commit log for the updates of the keyspace, default to true
+	DurableWrites *bool `json:"durableWrites,omitempty"`
+}
+
+// CassandraKeyspaceStatus defines the observed state of CassandraKeyspace
+type CassandraKeyspaceStatus struct {
+	//Phase of the keyspace: Pending until the cluster is running, Done once the spec is applied or Error
+	Phase string `json:"phase,omitempty"`
+	//Message is the error of the last attempt
+	Message string `json:"message,omitempty"`
+	//Warnings about the cluster, like a DC replicating the keyspace being scaled down to 0
+	Warnings []string `json:"warnings,omitempty"`
+
+	//Name, Strategy, ReplicationFactor and DurableWrites applied to the keyspace
+	Name              string           `json:"name,omitempty"`
+	Strategy          string           `json:"strategy,omitempty"`
+	ReplicationFactor map[string]int32 `json:"replicationFactor,omitempty"`
+	DurableWrites     *bool            `json:"durableWrites,omitempty"`
+
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+//GetStrategy returns the replication strategy of the keyspace
+func (ck *CassandraKeyspace) GetStrategy() string {
+	if ck.Spec.Strategy == "" {
+		return NetworkTopologyStrategy
+	}
+	return ck.Spec.Strategy
+}
+
+//GetDurableWrites returns true if the durable writes are enabled for the keyspace
+func (ck *CassandraKeyspace) GetDurableWrites() bool {
+	return ck.Spec.DurableWrites == nil || *ck.Spec.DurableWrites
+}
+
+//IsApplied returns true if the spec of the keyspace is the state reported in its status
+func (ck *CassandraKeyspace) IsApplied() bool {
+	status := ck.Status
+	if status.Phase != StatusDone || status.Name != ck.Spec.Name || status.Strategy != ck.GetStrategy() ||
+		status.DurableWrites == nil || *status.DurableWrites != ck.GetDurableWrites() ||
+		len(status.ReplicationFactor) != len(ck.Spec.ReplicationFactor) {
+		return false
+	}
+	for dcName, rf := range ck.Spec.ReplicationFactor {
+		if appliedRF, ok := status.ReplicationFactor[dcName]; !ok || appliedRF != rf {
+			return false
+		}
+	}
+	return true
+}
+
+//ReplicatedDCs returns the sorted names of the DCs where the keyspace has replicas
+func (ck *CassandraKeyspace) ReplicatedDCs() []string {
+	var dcNames []string
+	for dcName, rf := range ck.Spec.ReplicationFactor {
+		if rf > 0 {
+			dcNames = append(dcNames, dcName)
+		}
+	}
+	sort.Strings(dcNames)
+	return dcNames
+}
+
+//getDCNodes returns the number of nodes of the DC dcName of cc, false if cc has no such DC
+func getDCNodes(cc *CassandraCluster, dcName string) (bool, int32) {
+	if cc.GetDCSize() < 1 {
+		return dcName == DefaultCassandraDC, cc.Spec.NodesPerRacks
+	}
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		if cc.GetDCName(dc) == dcName {
+			_, nodesPerRacks := cc.GetDCNodesPerRacksFromName(dcName)
+			return true, nodesPerRacks * int32(cc.GetRackSize(dc))
+		}
+	}
+	return false, 0
+}
+
+//DCsScaledDownTo0 returns the DCs of cc replicating the keyspace which are scaled down to 0
+func (ck *CassandraKeyspace) DCsScaledDownTo0(cc *CassandraCluster) []string {
+	var dcNames []string
+	for _, dcName := range ck.ReplicatedDCs() {
+		if found, nodesPerRacks := cc.GetDCNodesPerRacksFromName(dcName); found && nodesPerRacks == 0 {
+			dcNames = append(dcNames, dcName)
+		}
+	}
+	return dcNames
+}
+
+//Validate returns an error if the keyspace can't be replicated as requested in cc
+//A replication factor can't be larger than the number of nodes of its DC
+func (ck *CassandraKeyspace) Validate(cc *CassandraCluster) error {
+	if !keyspaceNameRegexp.MatchString(ck.Spec.Name) {
+		return fmt.Errorf("Keyspace name %q must have 1 to 48 alphanumeric or underscore characters", ck.Spec.Name)
+	}
+	if ck.Status.Name != "" && ck.Status.Name != ck.Spec.Name {
+		return fmt.Errorf("Keyspace name can't be changed from %s to %s", ck.Status.Name, ck.Spec.Name)
+	}
+	if len(ck.Spec.ReplicationFactor) == 0 {
+		return fmt.Errorf("Keyspace %s must have a replication factor", ck.Spec.Name)
+	}
+	for dcName, rf := range ck.Spec.ReplicationFactor {
+		if rf < 0 {
+			return fmt.Errorf("Replication factor of DC %s can't be negative", dcName)
+		}
+	}
+	switch ck.GetStrategy() {
+	case NetworkTopologyStrategy:
+	case SimpleStrategy:
+		if len(ck.Spec.ReplicationFactor) != 1 || cc.GetDCSize() > 1 {
+			return fmt.Errorf("%s can only be used with a single DC", SimpleStrategy)
+		}
+	default:
+		return fmt.Errorf("Unknown replication strategy %s, it must be %s or %s", ck.Spec.Strategy,
+			NetworkTopologyStrategy, SimpleStrategy)
+	}
+	for _, dcName := range ck.ReplicatedDCs() {
+		found, nodes := getDCNodes(cc, dcName)
+		if !found {
+			return fmt.Errorf("DC %s does not exist in CassandraCluster %s", dcName, cc.Name)
+		}
+		if rf := ck.Spec.ReplicationFactor[dcName]; rf > nodes {
+			return fmt.Errorf("Replication factor %d of DC %s is larger than its %d nodes", rf, dcName, nodes)
+		}
+	}
+	return nil
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraKeyspace is the Schema for the cassandrakeyspaces API
+// +k8s:openapi-gen=true
+type CassandraKeyspace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraKeyspaceSpec   `json:"spec,omitempty"`
+	Status CassandraKeyspaceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraKeyspaceList contains a list of CassandraKeyspace
+type CassandraKeyspaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraKeyspace `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraKeyspace{}, &CassandraKeyspaceList{})
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//OperationRestore is the name of the operation stored in the status of a CassandraRestore for each dc-rack
+	OperationRestore string = "restore"
+
+	//DefaultRestoreImage is the image of the init container downloading the files of a backup
+	DefaultRestoreImage = "minio/mc:RELEASE.2019-08-07T23-14-43Z"
+)
+
+//RestoreFrom defines the backup used to seed the data of the nodes of a new cluster before Cassandra starts
+//It is set by the CassandraRestore which creates the cluster
+type RestoreFrom struct {
+	//Name of the CassandraBackup to restore
+	Backup string `json:"backup"`
+	//Storage where the files of the backup are stored
+	Storage BackupStorage `json:"storage"`
+	//Image of the init container downloading the files, default to DefaultRestoreImage
+	Image string `json:"image,omitempty"`
+}
+
+//GetImage returns the image of the init container downloading the files of the backup
+func (rf *RestoreFrom) GetImage() string {
+	if rf.Image != "" {
+		return rf.Image
+	}
+	return DefaultRestoreImage
+}
+
+// CassandraRestoreSpec defines the desired state of CassandraRestore
+type CassandraRestoreSpec struct {
+	//Name of the CassandraBackup to restore, it must live in the same namespace
+	Backup string `json:"backup"`
+	//Name of the CassandraCluster to restore into, it must live in the same namespace
+	Cluster string `json:"cluster"`
+	//List of keyspaces to restore, all the keyspaces of the backup are restored if empty
+	//Only used when restoring into an existing cluster
+	Keyspaces []string `json:"keyspaces,omitempty"`
+	//ClusterSpec is the spec of the cluster to create
+	//If set, a new CassandraCluster named Cluster is created and its nodes are seeded with the files of the
+	//backup before Cassandra starts. Otherwise Cluster must exist, its tables are truncated then the files
+	//of the backup are loaded on each node, rack by rack
+	ClusterSpec *CassandraClusterSpec `json:"clusterSpec,omitempty"`
+	//Image of the init container downloading the files in a new cluster, default to DefaultRestoreImage
+	Image string `json:"image,omitempty"`
+}
+
+// CassandraRestoreStatus defines the observed state of CassandraRestore
+type CassandraRestoreStatus struct {
+	//Phase of the restore: Ongoing, Done or Error
+	Phase string `json:"phase,omitempty"`
+	//Error which stopped the restore
+	Error string `json:"error,omitempty"`
+
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+
+	//Tables restored in an existing cluster, as <keyspace>.<table>
+	Tables []string `json:"tables,omitempty"`
+
+	//Progress of the restore for each dc-rack
+	CassandraRackStatus map[string]*PodLastOperation `json:"cassandraRackStatus,omitempty"`
+}
+
+//IsNewCluster returns true if the restore creates a new cluster
+func (cr *CassandraRestore) IsNewCluster() bool {
+	return cr.Spec.ClusterSpec != nil
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestore is the Schema for the cassandrarestores API
+// +k8s:openapi-gen=true
+type CassandraRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRestoreSpec   `json:"spec,omitempty"`
+	Status CassandraRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestoreList contains a list of CassandraRestore
+type CassandraRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraRestore{}, &CassandraRestoreList{})
+}
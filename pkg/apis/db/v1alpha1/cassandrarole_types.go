@@ -0,0 +1,182 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//RolePhasePending is the phase of a role waiting for its cluster to be running
+	RolePhasePending string = "Pending"
+)
+
+//rolePermissions are the permissions which can be granted on keyspaces and tables
+var rolePermissions = []string{"ALL", "ALTER", "AUTHORIZE", "CREATE", "DROP", "MODIFY", "SELECT"}
+
+//RoleGrant defines a permission granted to a role on all the keyspaces, a keyspace or a table
+type RoleGrant struct {
+	//Permission granted: ALL, ALTER, AUTHORIZE, CREATE, DROP, MODIFY or SELECT
+	Permission string `json:"permission"`
+	//Keyspace on which the permission is granted, all the keyspaces if empty
+	Keyspace string `json:"keyspace,omitempty"`
+	//Table of the keyspace on which the permission is granted, the whole keyspace if empty
+	Table string `json:"table,omitempty"`
+}
+
+//Resource returns the CQL resource of the grant
+func (grant RoleGrant) Resource() string {
+	if grant.Keyspace == "" {
+		return "ALL KEYSPACES"
+	}
+	if grant.Table == "" {
+		return fmt.Sprintf("KEYSPACE %q", grant.Keyspace)
+	}
+	return fmt.Sprintf("TABLE %q.%q", grant.Keyspace, grant.Table)
+}
+
+//Validate returns an error if the grant has an unknown permission or an invalid resource
+func (grant RoleGrant) Validate() error {
+	permission := strings.ToUpper(grant.Permission)
+	valid := false
+	for _, rolePermission := range rolePermissions {
+		if permission == rolePermission {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("Unknown permission %s, it must be one of %v", grant.Permission, rolePermissions)
+	}
+	if grant.Keyspace == "" && grant.Table != "" {
+		return fmt.Errorf("Table %s must have a keyspace", grant.Table)
+	}
+	if grant.Keyspace != "" && !keyspaceNameRegexp.MatchString(grant.Keyspace) {
+		return fmt.Errorf("Invalid keyspace name %q", grant.Keyspace)
+	}
+	if grant.Table != "" && !keyspaceNameRegexp.MatchString(grant.Table) {
+		return fmt.Errorf("Invalid table name %q", grant.Table)
+	}
+	return nil
+}
+
+// CassandraRoleSpec defines the desired state of CassandraRole
+type CassandraRoleSpec struct {
+	//Name of the CassandraCluster where the role is created, it must live in the same namespace
+	Cluster string `json:"cluster"`
+	//Name of the role, it can't be changed once the role is created
+	Name string `json:"name"`
+	//Login allows the role to log in, a role which logs in must have a password
+	Login bool `json:"login,omitempty"`
+	//Superuser gives all the permissions to the role
+	Superuser bool `json:"superuser,omitempty"`
+	//PasswordSecret is the Secret holding the password of the role in its password key
+	//The password of the role is changed each time the Secret is updated
+	PasswordSecret *v1.LocalObjectReference `json:"passwordSecret,omitempty"`
+	//Grants are the permissions granted to the role, the permissions removed from the list are revoked
+	Grants []RoleGrant `json:"grants,omitempty"`
+}
+
+// CassandraRoleStatus defines the observed state of CassandraRole
+type CassandraRoleStatus struct {
+	//Phase of the role: Pending until the cluster is running, Done once the spec is applied or Error
+	Phase string `json:"phase,omitempty"`
+	//Message is the error of the last attempt
+	Message string `json:"message,omitempty"`
+
+	//Name, Login, Superuser and Grants applied to the role
+	Name      string      `json:"name,omitempty"`
+	Login     bool        `json:"login,omitempty"`
+	Superuser bool        `json:"superuser,omitempty"`
+	Grants    []RoleGrant `json:"grants,omitempty"`
+	//PasswordSecretVersion is the resource version of the Secret whose password is set
+	PasswordSecretVersion string `json:"passwordSecretVersion,omitempty"`
+
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+//Validate returns an error if the role can't be created as requested
+func (cr *CassandraRole) Validate() error {
+	if cr.Spec.Name == "" {
+		return fmt.Errorf("Role name must be set")
+	}
+	if cr.Status.Name != "" && cr.Status.Name != cr.Spec.Name {
+		return fmt.Errorf("Role name can't be changed from %s to %s", cr.Status.Name, cr.Spec.Name)
+	}
+	if cr.Spec.Login && (cr.Spec.PasswordSecret == nil || cr.Spec.PasswordSecret.Name == "") {
+		return fmt.Errorf("Role %s must have a passwordSecret to log in", cr.Spec.Name)
+	}
+	for _, grant := range cr.Spec.Grants {
+		if err := grant.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//HasGrant returns true if the role has a grant with the same permission on the same resource
+func HasGrant(grants []RoleGrant, grant RoleGrant) bool {
+	for _, g := range grants {
+		if strings.EqualFold(g.Permission, grant.Permission) && g.Resource() == grant.Resource() {
+			return true
+		}
+	}
+	return false
+}
+
+//IsApplied returns true if the spec of the role and the resource version of its password Secret are the state
+//reported in its status
+func (cr *CassandraRole) IsApplied(passwordSecretVersion string) bool {
+	status := cr.Status
+	if status.Phase != StatusDone || status.Name != cr.Spec.Name || status.Login != cr.Spec.Login ||
+		status.Superuser != cr.Spec.Superuser || status.PasswordSecretVersion != passwordSecretVersion ||
+		len(status.Grants) != len(cr.Spec.Grants) {
+		return false
+	}
+	for _, grant := range cr.Spec.Grants {
+		if !HasGrant(status.Grants, grant) {
+			return false
+		}
+	}
+	return true
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRole is the Schema for the cassandraroles API
+// +k8s:openapi-gen=true
+type CassandraRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRoleSpec   `json:"spec,omitempty"`
+	Status CassandraRoleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRoleList contains a list of CassandraRole
+type CassandraRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraRole{}, &CassandraRoleList{})
+}
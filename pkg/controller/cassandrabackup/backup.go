@@ -0,0 +1,275 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrabackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var reEndingNumber = regexp.MustCompile("[0-9]+$")
+
+//uploadMaxAttempts is the number of times the upload of the snapshot of a pod is tried before the pod is in error
+const uploadMaxAttempts = 3
+
+//podOrdinal returns the ordinal of a pod of a statefulset
+func podOrdinal(podName string) (int, error) {
+	return strconv.Atoi(reEndingNumber.FindString(podName))
+}
+
+func (r *ReconcileCassandraBackup) listPods(cc *api.CassandraCluster) (*v1.PodList, error) {
+	opt := &client.ListOptions{
+		Namespace:     cc.Namespace,
+		LabelSelector: labels.SelectorFromSet(k8s.LabelsForCassandra(cc)),
+	}
+	pl := &v1.PodList{}
+	return pl, r.client.List(context.TODO(), opt, pl)
+}
+
+func (r *ReconcileCassandraBackup) getPod(namespace, name string) (*v1.Pod, error) {
+	pod := &v1.Pod{}
+	return pod, r.client.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, pod)
+}
+
+func (r *ReconcileCassandraBackup) jolokiaClient(cc *api.CassandraCluster,
+	pod *v1.Pod) (*cassandracluster.JolokiaClient, error) {
+	hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+	return cassandracluster.NewJolokiaClientWithK8sClient(hostName, cassandracluster.JolokiaPort, r.client,
+		cc.Spec.ImageJolokiaSecret, cc.Namespace)
+}
+
+//updateCassandraBackupStatus updates the CassandraBackup if its status has changed
+func (r *ReconcileCassandraBackup) updateCassandraBackupStatus(cb *api.CassandraBackup,
+	status *api.CassandraBackupStatus) error {
+	if reflect.DeepEqual(cb.Status, *status) {
+		return nil
+	}
+	cb.Status = *status
+	err := r.client.Update(context.TODO(), cb)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"backup": cb.Name}).Errorf("Issue when updating CassandraBackup: %v", err)
+	}
+	return err
+}
+
+//initBackup stores in the status the list of pods to backup
+func (r *ReconcileCassandraBackup) initBackup(cb *api.CassandraBackup, cc *api.CassandraCluster,
+	status *api.CassandraBackupStatus) error {
+	podsList, err := r.listPods(cc)
+	if err != nil {
+		return err
+	}
+	if len(podsList.Items) == 0 {
+		return fmt.Errorf("There is no pod in cluster %s", cc.Name)
+	}
+
+	now := metav1.Now()
+	status.Phase = api.StatusOngoing
+	status.SnapshotName = cb.GetSnapshotName()
+	status.StartTime = &now
+	status.Pods = map[string]*api.BackupPodStatus{}
+	for _, pod := range podsList.Items {
+		status.Pods[pod.Name] = &api.BackupPodStatus{
+			DCRackName: pod.Labels["dc-rack"],
+			Status:     api.StatusToDo,
+		}
+	}
+	logrus.WithFields(logrus.Fields{"backup": cb.Name, "cluster": cc.Name,
+		"snapshot": status.SnapshotName}).Infof("Start backup of %d pods", len(status.Pods))
+	return nil
+}
+
+//takeSnapshots takes the snapshot on each pod which has not been snapshotted yet
+//All the snapshots are taken in the same call so that they are as close as possible in time
+func (r *ReconcileCassandraBackup) takeSnapshots(cb *api.CassandraBackup, cc *api.CassandraCluster,
+	status *api.CassandraBackupStatus) {
+	for podName, podStatus := range status.Pods {
+		if podStatus.Status != api.StatusToDo {
+			continue
+		}
+		now := metav1.Now()
+		podStatus.StartTime = &now
+		if err := r.snapshotPod(cc, podName, status.SnapshotName, cb.Spec.Keyspaces); err != nil {
+			logrus.WithFields(logrus.Fields{"backup": cb.Name, "cluster": cc.Name,
+				"pod": podName}).Errorf("Snapshot failed: %v", err)
+			setPodError(podStatus, err)
+			continue
+		}
+		podStatus.Status = api.StatusOngoing
+	}
+}
+
+func (r *ReconcileCassandraBackup) snapshotPod(cc *api.CassandraCluster, podName, snapshotName string,
+	keyspaces []string) error {
+	pod, err := r.getPod(cc.Namespace, podName)
+	if err != nil {
+		return err
+	}
+	jolokiaClient, err := r.jolokiaClient(cc, pod)
+	if err != nil {
+		return err
+	}
+	return jolokiaClient.NodeSnapshot(snapshotName, keyspaces)
+}
+
+//nextPodToUpload returns the first pod, in alphabetical order, whose snapshot has to be uploaded
+func nextPodToUpload(status *api.CassandraBackupStatus) string {
+	podNames := []string{}
+	for podName, podStatus := range status.Pods {
+		if podStatus.Status == api.StatusOngoing {
+			podNames = append(podNames, podName)
+		}
+	}
+	if len(podNames) == 0 {
+		return ""
+	}
+	sort.Strings(podNames)
+	return podNames[0]
+}
+
+//uploadPod uploads the snapshot files of a pod and clears the snapshot once they are all uploaded. It returns false
+//if the upload failed and will be tried again, after uploadMaxAttempts the pod is in error and its snapshot is cleared
+func (r *ReconcileCassandraBackup) uploadPod(cb *api.CassandraBackup, cc *api.CassandraCluster, podName string,
+	status *api.CassandraBackupStatus) bool {
+	podStatus := status.Pods[podName]
+	logger := logrus.WithFields(logrus.Fields{"backup": cb.Name, "cluster": cc.Name, "pod": podName})
+
+	err := r.uploadPodFiles(cb, cc, podName, status.SnapshotName, podStatus)
+	if err != nil {
+		podStatus.Attempts++
+		podStatus.Error = err.Error()
+		if podStatus.Attempts < uploadMaxAttempts {
+			logger.Warnf("Upload failed (attempt %d/%d), it will be tried again: %v", podStatus.Attempts,
+				uploadMaxAttempts, err)
+			return false
+		}
+		logger.Errorf("Upload failed: %v", err)
+		setPodError(podStatus, err)
+		if err = r.clearSnapshot(cb, cc, podName, status.SnapshotName); err != nil {
+			logger.Errorf("Cannot clear snapshot: %v", err)
+			podStatus.Error = fmt.Sprintf("%s. The snapshot could not be cleared (%v), clear it with "+
+				"'nodetool clearsnapshot -t %s'", podStatus.Error, err, status.SnapshotName)
+		}
+		return true
+	}
+
+	now := metav1.Now()
+	podStatus.Status = api.StatusDone
+	podStatus.Error = ""
+	podStatus.EndTime = &now
+	logger.Infof("Uploaded %d files (%d bytes)", podStatus.Files, podStatus.Bytes)
+	return true
+}
+
+func (r *ReconcileCassandraBackup) clearSnapshot(cb *api.CassandraBackup, cc *api.CassandraCluster,
+	podName, snapshotName string) error {
+	pod, err := r.getPod(cc.Namespace, podName)
+	if err != nil {
+		return err
+	}
+	jolokiaClient, err := r.jolokiaClient(cc, pod)
+	if err != nil {
+		return err
+	}
+	return jolokiaClient.NodeClearSnapshot(snapshotName, cb.Spec.Keyspaces)
+}
+
+func (r *ReconcileCassandraBackup) uploadPodFiles(cb *api.CassandraBackup, cc *api.CassandraCluster,
+	podName, snapshotName string, podStatus *api.BackupPodStatus) error {
+	pod, err := r.getPod(cc.Namespace, podName)
+	if err != nil {
+		return err
+	}
+	ordinal, err := podOrdinal(podName)
+	if err != nil {
+		return fmt.Errorf("Cannot get ordinal of pod %s: %v", podName, err)
+	}
+	storage, err := r.newStorage(r.client, cb.Namespace, cb.Spec.Storage)
+	if err != nil {
+		return err
+	}
+	//The SSTables of a JBOD cluster are spread over its data directories, their keys don't depend on the directory so
+	//they are all restored in CassandraDataDir
+	files, err := r.files.ListSnapshotFiles(pod, cc.GetDataDirectories(), snapshotName)
+	if err != nil {
+		return err
+	}
+
+	prefix := cb.Spec.Storage.PodKeyPrefix(cb.Name, podStatus.DCRackName, ordinal)
+	podStatus.Files = 0
+	podStatus.Bytes = 0
+	for _, file := range files {
+		if err = r.uploadFile(pod, storage, file, prefix+SnapshotFileKey(file.Path, snapshotName)); err != nil {
+			return err
+		}
+		podStatus.Files++
+		podStatus.Bytes += file.Size
+	}
+
+	return r.clearSnapshot(cb, cc, podName, snapshotName)
+}
+
+//uploadFile streams a file from a pod to the object store
+func (r *ReconcileCassandraBackup) uploadFile(pod *v1.Pod, storage ObjectStorage, file SnapshotFile,
+	key string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(r.files.ReadFile(pod, path.Join(file.Dir, file.Path), writer))
+	}()
+	err := storage.PutObject(key, reader, file.Size)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("Cannot upload %s: %v", key, err)
+	}
+	return nil
+}
+
+func setPodError(podStatus *api.BackupPodStatus, err error) {
+	now := metav1.Now()
+	podStatus.Status = api.StatusError
+	podStatus.Error = err.Error()
+	podStatus.EndTime = &now
+}
+
+//finalizeBackup sets the phase of the backup once all pods are either Done or in Error
+func finalizeBackup(cb *api.CassandraBackup, status *api.CassandraBackupStatus) {
+	now := metav1.Now()
+	status.Phase = api.StatusDone
+	status.EndTime = &now
+	for podName, podStatus := range status.Pods {
+		if podStatus.Status == api.StatusError {
+			logrus.WithFields(logrus.Fields{"backup": cb.Name,
+				"pod": podName}).Errorf("Backup failed: %s", podStatus.Error)
+			status.Phase = api.StatusError
+		}
+	}
+	logrus.WithFields(logrus.Fields{"backup": cb.Name}).Infof("Backup ended with status %s", status.Phase)
+}
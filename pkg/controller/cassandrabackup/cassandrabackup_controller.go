@@ -0,0 +1,136 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrabackup
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_cassandrabackup")
+
+// Add creates a new CassandraBackup Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCassandraBackup{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		files: execPodFileReader{}, newStorage: NewObjectStorage}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("cassandrabackup-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CassandraBackup
+	return c.Watch(&source.Kind{Type: &api.CassandraBackup{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileCassandraBackup{}
+
+// ReconcileCassandraBackup reconciles a CassandraBackup object
+type ReconcileCassandraBackup struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+
+	//files gives access to the snapshot files of the pods
+	files PodFileReader
+	//newStorage returns the object store where the files are uploaded
+	newStorage func(client.Client, string, api.BackupStorage) (ObjectStorage, error)
+}
+
+// Reconcile takes a snapshot on every node of the cluster targeted by a CassandraBackup then uploads the files
+// of each node, one node per call, to the object store. Progress is stored in CassandraBackup.Status
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCassandraBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraBackup")
+
+	requeue30 := reconcile.Result{RequeueAfter: 30 * time.Second}
+	requeue := reconcile.Result{Requeue: true}
+	forget := reconcile.Result{}
+
+	// Fetch the CassandraBackup instance
+	cb := &api.CassandraBackup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, cb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return forget, nil
+		}
+		return forget, err
+	}
+
+	//A backup is never done twice
+	if cb.Status.Phase == api.StatusDone || cb.Status.Phase == api.StatusError {
+		return forget, nil
+	}
+
+	cc := &api.CassandraCluster{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cb.Spec.Cluster, Namespace: cb.Namespace}, cc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logrus.WithFields(logrus.Fields{"backup": cb.Name,
+				"cluster": cb.Spec.Cluster}).Warn("CassandraCluster to backup does not exist, waiting..")
+			return requeue30, nil
+		}
+		return forget, err
+	}
+
+	status := cb.Status.DeepCopy()
+
+	//We Update Status at the end
+	defer r.updateCassandraBackupStatus(cb, status)
+
+	if status.Phase == "" {
+		return requeue, r.initBackup(cb, cc, status)
+	}
+
+	r.takeSnapshots(cb, cc, status)
+
+	if podName := nextPodToUpload(status); podName != "" {
+		if !r.uploadPod(cb, cc, podName, status) {
+			return requeue30, nil
+		}
+		return requeue, nil
+	}
+
+	finalizeBackup(cb, status)
+	return forget, nil
+}
@@ -0,0 +1,404 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrabackup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/ghodss/yaml"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	path := filepath.Join("testdata", name) // relative path
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+//fakeS3 is a minimal MinIO-style object store keeping uploaded objects in memory
+type fakeS3 struct {
+	sync.Mutex
+	server  *httptest.Server
+	objects map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	s3 := &fakeS3{objects: map[string]string{}}
+	s3.server = httptest.NewServer(http.HandlerFunc(s3.handle))
+	return s3
+}
+
+func (s3 *fakeS3) endpoint() string {
+	return strings.TrimPrefix(s3.server.URL, "http://")
+}
+
+func (s3 *fakeS3) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	var body []byte
+	var err error
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		body, err = decodeAwsChunked(r.Body)
+	} else {
+		body, err = ioutil.ReadAll(r.Body)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s3.Lock()
+	s3.objects[strings.TrimPrefix(r.URL.Path, "/")] = string(body)
+	s3.Unlock()
+	w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+//decodeAwsChunked decodes a body sent with a streaming signature: <hex size>;chunk-signature=<sig>\r\n<data>\r\n
+func decodeAwsChunked(reader io.Reader) ([]byte, error) {
+	body := []byte{}
+	buf := bufio.NewReader(reader)
+	for {
+		header, err := buf.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(strings.SplitN(strings.TrimSpace(header), ";", 2)[0], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		chunk := make([]byte, size+2)
+		if _, err = io.ReadFull(buf, chunk); err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
+
+//fakePodFileReader serves snapshot files from memory, files are indexed by pod name then by path, the paths relative to
+//CassandraDataDir are in CassandraDataDir
+type fakePodFileReader map[string]map[string]string
+
+func (f fakePodFileReader) ListSnapshotFiles(pod *v1.Pod, dirs []string, tag string) ([]SnapshotFile, error) {
+	files := []SnapshotFile{}
+	for path, content := range f[pod.Name] {
+		if !strings.HasPrefix(path, "/") {
+			path = CassandraDataDir + "/" + path
+		}
+		for _, dir := range dirs {
+			if strings.HasPrefix(path, dir+"/") && strings.Contains(path, "/snapshots/"+tag+"/") {
+				files = append(files, SnapshotFile{Dir: dir, Path: strings.TrimPrefix(path, dir+"/"),
+					Size: int64(len(content))})
+			}
+		}
+	}
+	return files, nil
+}
+
+//unreadableFile is the content of the files which can't be read
+const unreadableFile = "<unreadable>"
+
+func (f fakePodFileReader) ReadFile(pod *v1.Pod, path string, writer io.Writer) error {
+	content, ok := f[pod.Name][path]
+	if !ok {
+		content, ok = f[pod.Name][strings.TrimPrefix(path, CassandraDataDir+"/")]
+	}
+	if !ok || content == unreadableFile {
+		return fmt.Errorf("file %s not found", path)
+	}
+	_, err := io.WriteString(writer, content)
+	return err
+}
+
+func helperPod(cc *api.CassandraCluster, dcName, rackName string, ordinal int) *v1.Pod {
+	dcRackName := cc.GetDCRackName(dcName, rackName)
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, ordinal),
+			Namespace: cc.Namespace,
+			Labels:    k8s.LabelsForCassandraDCRack(cc, dcName, rackName),
+		},
+		Spec: v1.PodSpec{
+			Hostname:  fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, ordinal),
+			Subdomain: cc.Name,
+		},
+	}
+}
+
+func helperInitBackup(t *testing.T, s3 *fakeS3, files fakePodFileReader) (*ReconcileCassandraBackup,
+	*api.CassandraBackup, *api.CassandraCluster) {
+	var cc api.CassandraCluster
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandracluster.yaml"), &cc); err != nil {
+		t.Fatal(err)
+	}
+	var cb api.CassandraBackup
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandrabackup.yaml"), &cb); err != nil {
+		t.Fatal(err)
+	}
+	cb.Spec.Storage.Endpoint = s3.endpoint()
+
+	secret := &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-credentials", Namespace: "ns"},
+		Data: map[string][]byte{api.StorageAccessKeyIDKey: []byte("minio"),
+			api.StorageSecretAccessKeyKey: []byte("minio123")},
+	}
+	objs := []runtime.Object{&cc, &cb, secret,
+		helperPod(&cc, "dc1", "rack1", 0), helperPod(&cc, "dc1", "rack2", 0)}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraCluster{}, &api.CassandraClusterList{},
+		&api.CassandraBackup{}, &api.CassandraBackupList{})
+	cl := fake.NewFakeClient(objs...)
+	r := &ReconcileCassandraBackup{client: cl, scheme: s, files: files, newStorage: NewObjectStorage}
+	return r, &cb, &cc
+}
+
+//helperJolokia mocks the jolokia endpoint of the pods and records the operations received by each host
+func helperJolokia(cc *api.CassandraCluster, failingHost string) map[string][]string {
+	operations := map[string][]string{}
+	for _, podName := range []string{"cassandra-demo-dc1-rack1-0", "cassandra-demo-dc1-rack2-0"} {
+		hostName := podName + "." + cc.Name
+		httpmock.RegisterResponder("POST", cassandracluster.JolokiaURL(hostName, cassandracluster.JolokiaPort),
+			func(req *http.Request) (*http.Response, error) {
+				var request struct {
+					Operation string `json:"operation"`
+				}
+				json.NewDecoder(req.Body).Decode(&request)
+				operations[hostName] = append(operations[hostName], strings.Split(request.Operation, "(")[0])
+				if hostName == failingHost {
+					return httpmock.NewStringResponse(200, `{"error": "java.io.IOException", "status": 500}`), nil
+				}
+				return httpmock.NewStringResponse(200, `{"value": null, "status": 200}`), nil
+			})
+	}
+	return operations
+}
+
+func helperReconcileUntilDone(t *testing.T, r *ReconcileCassandraBackup, cb *api.CassandraBackup) *api.CassandraBackup {
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: cb.Name, Namespace: cb.Namespace}}
+	for i := 0; i < 10; i++ {
+		res, err := r.Reconcile(request)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if !res.Requeue {
+			break
+		}
+	}
+	backup := &api.CassandraBackup{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	return backup
+}
+
+func TestCassandraBackup(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	s3 := newFakeS3()
+	defer s3.server.Close()
+	files := fakePodFileReader{
+		"cassandra-demo-dc1-rack1-0": {
+			"demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db":  "data-rack1",
+			"demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Index.db": "index",
+			"demo/users-1c42e8b0/snapshots/other/mc-1-big-Data.db":    "other",
+		},
+		"cassandra-demo-dc1-rack2-0": {
+			"demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db": "data-rack2",
+		},
+	}
+	r, cb, cc := helperInitBackup(t, s3, files)
+	operations := helperJolokia(cc, "")
+
+	backup := helperReconcileUntilDone(t, r, cb)
+
+	assert.Equal(api.StatusDone, backup.Status.Phase)
+	assert.Equal("backup1", backup.Status.SnapshotName)
+	assert.NotNil(backup.Status.EndTime)
+	assert.Equal(2, len(backup.Status.Pods))
+	rack1 := backup.Status.Pods["cassandra-demo-dc1-rack1-0"]
+	assert.Equal(api.StatusDone, rack1.Status)
+	assert.Equal("dc1-rack1", rack1.DCRackName)
+	assert.Equal(int32(2), rack1.Files)
+	assert.Equal(int64(15), rack1.Bytes)
+
+	assert.Equal(map[string]string{
+		"cassandra-backups/k8s/backup1/dc1-rack1/0/demo/users-1c42e8b0/mc-1-big-Data.db":  "data-rack1",
+		"cassandra-backups/k8s/backup1/dc1-rack1/0/demo/users-1c42e8b0/mc-1-big-Index.db": "index",
+		"cassandra-backups/k8s/backup1/dc1-rack2/0/demo/users-1c42e8b0/mc-2-big-Data.db":  "data-rack2",
+	}, s3.objects)
+
+	//Snapshot is taken then cleared once uploaded
+	assert.Equal([]string{"takeSnapshot", "clearSnapshot"}, operations["cassandra-demo-dc1-rack1-0.cassandra-demo"])
+	assert.Equal([]string{"takeSnapshot", "clearSnapshot"}, operations["cassandra-demo-dc1-rack2-0.cassandra-demo"])
+
+	//A backup which is done is not run again
+	helperReconcileUntilDone(t, r, backup)
+	assert.Equal(2, len(operations["cassandra-demo-dc1-rack1-0.cassandra-demo"]))
+}
+
+func TestCassandraBackupAdditionalDataVolumes(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	s3 := newFakeS3()
+	defer s3.server.Close()
+	files := fakePodFileReader{
+		"cassandra-demo-dc1-rack1-0": {
+			"demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db":                          "data",
+			"/var/lib/cassandra-data1/demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db": "data1",
+			"/var/lib/cassandra-data2/demo/users-1c42e8b0/snapshots/backup1/mc-3-big-Data.db": "data2",
+		},
+	}
+	r, cb, cc := helperInitBackup(t, s3, files)
+	cc.Spec.AdditionalDataVolumes = []api.StorageVolume{{Capacity: "1Gi"}, {Capacity: "1Gi"}}
+	if err := r.client.Update(context.TODO(), cc); err != nil {
+		t.Fatal(err)
+	}
+	helperJolokia(cc, "")
+
+	backup := helperReconcileUntilDone(t, r, cb)
+
+	//The files of every data directory are uploaded
+	assert.Equal(api.StatusDone, backup.Status.Phase)
+	assert.Equal(int32(3), backup.Status.Pods["cassandra-demo-dc1-rack1-0"].Files)
+	assert.Equal(map[string]string{
+		"cassandra-backups/k8s/backup1/dc1-rack1/0/demo/users-1c42e8b0/mc-1-big-Data.db": "data",
+		"cassandra-backups/k8s/backup1/dc1-rack1/0/demo/users-1c42e8b0/mc-2-big-Data.db": "data1",
+		"cassandra-backups/k8s/backup1/dc1-rack1/0/demo/users-1c42e8b0/mc-3-big-Data.db": "data2",
+	}, s3.objects)
+}
+
+func TestCassandraBackupSnapshotError(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	s3 := newFakeS3()
+	defer s3.server.Close()
+	files := fakePodFileReader{
+		"cassandra-demo-dc1-rack1-0": {"demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db": "data-rack1"},
+		"cassandra-demo-dc1-rack2-0": {"demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db": "data-rack2"},
+	}
+	r, cb, cc := helperInitBackup(t, s3, files)
+	operations := helperJolokia(cc, "cassandra-demo-dc1-rack2-0.cassandra-demo")
+
+	backup := helperReconcileUntilDone(t, r, cb)
+
+	assert.Equal(api.StatusError, backup.Status.Phase)
+	assert.Equal(api.StatusDone, backup.Status.Pods["cassandra-demo-dc1-rack1-0"].Status)
+	rack2 := backup.Status.Pods["cassandra-demo-dc1-rack2-0"]
+	assert.Equal(api.StatusError, rack2.Status)
+	assert.Contains(rack2.Error, "Cannot take snapshot backup1")
+	//Nothing is uploaded for a pod whose snapshot failed
+	assert.Equal([]string{"takeSnapshot"}, operations["cassandra-demo-dc1-rack2-0.cassandra-demo"])
+	assert.Equal(1, len(s3.objects))
+}
+
+func TestCassandraBackupUploadRetry(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	s3 := newFakeS3()
+	defer s3.server.Close()
+	files := fakePodFileReader{
+		"cassandra-demo-dc1-rack1-0": {"demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db": unreadableFile},
+		"cassandra-demo-dc1-rack2-0": {"demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db": unreadableFile},
+	}
+	r, cb, cc := helperInitBackup(t, s3, files)
+	operations := helperJolokia(cc, "")
+
+	//A failed upload is tried again
+	backup := helperReconcileUntilDone(t, r, cb)
+	assert.Equal(api.StatusOngoing, backup.Status.Phase)
+	rack1 := backup.Status.Pods["cassandra-demo-dc1-rack1-0"]
+	assert.Equal(api.StatusOngoing, rack1.Status)
+	assert.Equal(int32(1), rack1.Attempts)
+	assert.Contains(rack1.Error, "Cannot upload")
+
+	files["cassandra-demo-dc1-rack1-0"]["demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db"] = "data-rack1"
+	backup = helperReconcileUntilDone(t, r, backup)
+	rack1 = backup.Status.Pods["cassandra-demo-dc1-rack1-0"]
+	assert.Equal(api.StatusDone, rack1.Status)
+	assert.Empty(rack1.Error)
+	assert.Equal([]string{"takeSnapshot", "clearSnapshot"}, operations["cassandra-demo-dc1-rack1-0.cassandra-demo"])
+
+	//The snapshot is cleared once the pod is in error
+	for i := 1; i < uploadMaxAttempts; i++ {
+		assert.Equal(api.StatusOngoing, backup.Status.Phase)
+		backup = helperReconcileUntilDone(t, r, backup)
+	}
+	assert.Equal(api.StatusError, backup.Status.Phase)
+	rack2 := backup.Status.Pods["cassandra-demo-dc1-rack2-0"]
+	assert.Equal(api.StatusError, rack2.Status)
+	assert.Equal(int32(uploadMaxAttempts), rack2.Attempts)
+	assert.Equal([]string{"takeSnapshot", "clearSnapshot"}, operations["cassandra-demo-dc1-rack2-0.cassandra-demo"])
+	assert.Equal(1, len(s3.objects))
+}
+
+func TestParseSnapshotFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files, err := parseSnapshotFiles(`/var/lib/cassandra/data 12 demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db
+/var/lib/cassandra-data1 4 demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db
+`)
+	assert.Nil(err)
+	assert.Equal([]SnapshotFile{
+		{Dir: "/var/lib/cassandra/data", Path: "demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db", Size: 12},
+		{Dir: "/var/lib/cassandra-data1", Path: "demo/users-1c42e8b0/snapshots/backup1/mc-2-big-Data.db",
+			Size: 4}}, files)
+
+	_, err = parseSnapshotFiles("/var/lib/cassandra/data twelve demo/users/snapshots/backup1/mc-1-big-Data.db")
+	assert.NotNil(err)
+
+	assert.Equal("demo/users-1c42e8b0/mc-1-big-Data.db",
+		SnapshotFileKey("demo/users-1c42e8b0/snapshots/backup1/mc-1-big-Data.db", "backup1"))
+}
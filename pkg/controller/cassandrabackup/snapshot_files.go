@@ -0,0 +1,98 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrabackup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+)
+
+//CassandraDataDir is the first directory holding the keyspaces in the cassandra container, the files restored are
+//written there
+const CassandraDataDir = "/var/lib/cassandra/data"
+
+//SnapshotFile is a file of a snapshot found in the data directory Dir, Path is relative to Dir
+//and looks like <keyspace>/<table>/snapshots/<snapshot>/<file>
+type SnapshotFile struct {
+	Dir  string
+	Path string
+	Size int64
+}
+
+//PodFileReader gives access to the files of the cassandra container of a pod
+type PodFileReader interface {
+	//ListSnapshotFiles returns the files of the snapshot named tag found in the data directories dirs
+	ListSnapshotFiles(pod *v1.Pod, dirs []string, tag string) ([]SnapshotFile, error)
+	//ReadFile copies the content of the file at the absolute path to writer
+	ReadFile(pod *v1.Pod, path string, writer io.Writer) error
+}
+
+//execPodFileReader reads the files by executing commands in the cassandra container
+type execPodFileReader struct{}
+
+func (execPodFileReader) ListSnapshotFiles(pod *v1.Pod, dirs []string, tag string) ([]SnapshotFile, error) {
+	k8s.InitClient()
+	command := append(append([]string{"find"}, dirs...), "-type", "f",
+		"-path", fmt.Sprintf("*/snapshots/%s/*", tag), "-printf", "%H %s %P\\n")
+	stdout, stderr, err := k8s.ExecPod(pod.Namespace, pod, command)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot list files of snapshot %s: %v %s", tag, err, stderr)
+	}
+	return parseSnapshotFiles(stdout)
+}
+
+func (execPodFileReader) ReadFile(pod *v1.Pod, path string, writer io.Writer) error {
+	k8s.InitClient()
+	stderr, err := k8s.ExecPodStream(pod.Namespace, pod, []string{"cat", path}, nil, writer)
+	if err != nil {
+		return fmt.Errorf("Cannot read file %s: %v %s", path, err, stderr)
+	}
+	return nil
+}
+
+//parseSnapshotFiles parses the "<dir> <size> <path>" lines returned by find
+func parseSnapshotFiles(output string) ([]SnapshotFile, error) {
+	files := []SnapshotFile{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("Malformed line in file list: %s", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed size in file list: %s", line)
+		}
+		files = append(files, SnapshotFile{Dir: fields[0], Path: fields[2], Size: size})
+	}
+	return files, scanner.Err()
+}
+
+//SnapshotFileKey returns the object key of a snapshot file, the snapshots/<tag> part of the path is removed
+//so that the key is <keyspace>/<table>/<file>
+func SnapshotFileKey(filePath, tag string) string {
+	return strings.Replace(filePath, path.Join("snapshots", tag)+"/", "", 1)
+}
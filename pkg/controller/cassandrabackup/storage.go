@@ -0,0 +1,83 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrabackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	minio "github.com/minio/minio-go"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//ObjectStorage is the subset of an S3-compatible object store used by backups
+type ObjectStorage interface {
+	//PutObject uploads size bytes read from reader under key
+	PutObject(key string, reader io.Reader, size int64) error
+	//GetObject returns a reader on the object stored under key
+	GetObject(key string) (io.ReadCloser, error)
+	//ListObjects returns the keys of all objects starting with prefix
+	ListObjects(prefix string) ([]string, error)
+}
+
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+//NewObjectStorage returns an ObjectStorage for the storage section of a backup, the credentials are read in the
+//secret referenced by the storage section
+func NewObjectStorage(k8sClient client.Client, namespace string, storage api.BackupStorage) (ObjectStorage, error) {
+	secret := &v1.Secret{}
+	err := k8sClient.Get(context.TODO(),
+		types.NamespacedName{Name: storage.CredentialsSecret.Name, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get storage credentials secret %s: %v", storage.CredentialsSecret.Name, err)
+	}
+	minioClient, err := minio.NewWithRegion(storage.Endpoint, string(secret.Data[api.StorageAccessKeyIDKey]),
+		string(secret.Data[api.StorageSecretAccessKeyKey]), !storage.Insecure, storage.Region)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create client for storage %s: %v", storage.Endpoint, err)
+	}
+	return &s3Storage{client: minioClient, bucket: storage.Bucket}, nil
+}
+
+func (s *s3Storage) PutObject(key string, reader io.Reader, size int64) error {
+	_, err := s.client.PutObject(s.bucket, key, reader, size,
+		minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (s *s3Storage) GetObject(key string) (io.ReadCloser, error) {
+	return s.client.GetObject(s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *s3Storage) ListObjects(prefix string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	keys := []string{}
+	for object := range s.client.ListObjects(s.bucket, prefix, true, doneCh) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
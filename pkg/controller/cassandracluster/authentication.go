@@ -0,0 +1,300 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//NewCQLSession opens a CQL session on the nodes of cc through the headless service of the cluster
+//TLS is used with the CA of the cluster when the client encryption is enabled
+func NewCQLSession(cqlClient cql.Client, k8sClient client.Client, cc *api.CassandraCluster, username,
+	password string) (cql.Session, error) {
+	var tlsConfig *tls.Config
+	if cc.Spec.TLS != nil && cc.Spec.TLS.ClientEncryption {
+		secret := &v1.Secret{}
+		err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: cc.Namespace,
+			Name: tlsCASecretName(cc)}, secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS CA secret %s: %v", tlsCASecretName(cc), err)
+		}
+		caCert, _, err := parseTLSCA(secret)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = cql.TLSConfig(caCert)
+	}
+	return cqlClient.NewSession([]string{cc.Name + "." + cc.Namespace}, cassandraPort, username, password, tlsConfig)
+}
+
+//CQLSuperuserCredentials returns the credentials of the superuser of cc, the default ones until the Operator has
+//created the superuser of spec.authentication, then the ones it has last applied
+func CQLSuperuserCredentials(k8sClient client.Client, cc *api.CassandraCluster) (string, string, error) {
+	if cc.Spec.Authentication == nil || cc.Status.Authentication == nil ||
+		cc.Status.Authentication.Status != api.StatusDone {
+		return cql.DefaultUsername, cql.DefaultPassword, nil
+	}
+	if username, password, err := getCredentials(k8sClient, cc.Namespace,
+		appliedSuperuserSecretName(cc)); err == nil {
+		return username, password, nil
+	}
+	return getCredentials(k8sClient, cc.Namespace, cc.Spec.Authentication.SuperuserSecret.Name)
+}
+
+//getCredentials returns the username and the password stored in a Secret
+func getCredentials(k8sClient client.Client, namespace, name string) (string, string, error) {
+	secret, err := getCredentialsSecret(k8sClient, namespace, name)
+	if err != nil {
+		return "", "", err
+	}
+	return string(secret.Data[api.CredentialsUsernameKey]), string(secret.Data[api.CredentialsPasswordKey]), nil
+}
+
+//getCredentialsSecret returns a Secret which must hold a username and a password
+func getCredentialsSecret(k8sClient client.Client, namespace, name string) (*v1.Secret, error) {
+	secret := &v1.Secret{}
+	if err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name},
+		secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s: %v", name, err)
+	}
+	if len(secret.Data[api.CredentialsUsernameKey]) == 0 || len(secret.Data[api.CredentialsPasswordKey]) == 0 {
+		return nil, fmt.Errorf("Secret %s must have %s and %s keys", name, api.CredentialsUsernameKey,
+			api.CredentialsPasswordKey)
+	}
+	return secret, nil
+}
+
+//defaultUserSecretName returns the name of the Secret holding the password given to the default superuser
+func defaultUserSecretName(cc *api.CassandraCluster) string {
+	return cc.Name + "-default-user"
+}
+
+//appliedSuperuserSecretName returns the name of the Secret holding the credentials of the superuser as they were last
+//applied in Cassandra, they are used to log in when the superuser Secret changes
+func appliedSuperuserSecretName(cc *api.CassandraCluster) string {
+	return cc.Name + "-applied-superuser"
+}
+
+//ensureAuthentication creates the superuser of spec.authentication with the default superuser once the cluster is
+//running, then disables the default superuser or changes its password. The result is stored in status.authentication
+//It is done again when the superuser Secret or defaultUser changes, the password of the superuser is then changed by
+//logging in with the credentials last applied
+func (rcc *ReconcileCassandraCluster) ensureAuthentication(cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus) error {
+	auth := cc.Spec.Authentication
+	if auth == nil || status.Phase != api.ClusterPhaseRunning {
+		return nil
+	}
+	if err := auth.Validate(); err != nil {
+		return rcc.setAuthenticationError(cc, status, err)
+	}
+	secret, err := getCredentialsSecret(rcc.client, cc.Namespace, auth.SuperuserSecret.Name)
+	if err != nil {
+		return rcc.setAuthenticationError(cc, status, err)
+	}
+	username := string(secret.Data[api.CredentialsUsernameKey])
+	password := string(secret.Data[api.CredentialsPasswordKey])
+	if username == cql.DefaultUsername {
+		return rcc.setAuthenticationError(cc, status,
+			fmt.Errorf("The superuser can't be the default superuser %s", cql.DefaultUsername))
+	}
+
+	defaultUser := api.DefaultUserDisabled
+	if auth.GetDefaultUser() == api.DefaultUserChangePassword {
+		defaultUser = api.DefaultUserPasswordChanged
+	}
+	if status.Authentication != nil && status.Authentication.Status == api.StatusDone &&
+		status.Authentication.Superuser == username && status.Authentication.DefaultUser == defaultUser &&
+		status.Authentication.SuperuserSecretVersion == secret.ResourceVersion {
+		return nil
+	}
+
+	cqlClient := rcc.cqlClient
+	if cqlClient == nil {
+		cqlClient = cql.NewClient()
+	}
+
+	//The default superuser can't log in anymore if a previous attempt has already handled it, the superuser is then
+	//updated with the credentials last applied
+	session, err := NewCQLSession(cqlClient, rcc.client, cc, cql.DefaultUsername, cql.DefaultPassword)
+	if err != nil {
+		appliedUsername, appliedPassword, appliedErr := getCredentials(rcc.client, cc.Namespace,
+			appliedSuperuserSecretName(cc))
+		if appliedErr == nil {
+			session, err = NewCQLSession(cqlClient, rcc.client, cc, appliedUsername, appliedPassword)
+		}
+	}
+	if err == nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "superuser": username}).Info("Create superuser")
+		err = session.Exec(fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s WITH PASSWORD = %s AND SUPERUSER = true "+
+			"AND LOGIN = true", cql.Literal(username), cql.Literal(password)))
+		if err == nil {
+			err = session.Exec(fmt.Sprintf("ALTER ROLE %s WITH PASSWORD = %s AND SUPERUSER = true AND LOGIN = true",
+				cql.Literal(username), cql.Literal(password)))
+		}
+		session.Close()
+		if err != nil {
+			return rcc.setAuthenticationError(cc, status,
+				fmt.Errorf("failed to create superuser %s: %v", username, err))
+		}
+	}
+
+	session, err = NewCQLSession(cqlClient, rcc.client, cc, username, password)
+	if err != nil {
+		return rcc.setAuthenticationError(cc, status,
+			fmt.Errorf("failed to connect with superuser %s: %v", username, err))
+	}
+	defer session.Close()
+
+	statement := fmt.Sprintf("ALTER ROLE %s WITH SUPERUSER = false AND LOGIN = false",
+		cql.Literal(cql.DefaultUsername))
+	if defaultUser == api.DefaultUserPasswordChanged {
+		defaultPassword, err := rcc.getOrCreateDefaultUserPassword(cc)
+		if err != nil {
+			return rcc.setAuthenticationError(cc, status, err)
+		}
+		statement = fmt.Sprintf("ALTER ROLE %s WITH PASSWORD = %s AND SUPERUSER = true AND LOGIN = true",
+			cql.Literal(cql.DefaultUsername), cql.Literal(defaultPassword))
+	}
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name, "defaultUser": defaultUser}).Info("Update default superuser")
+	if err = session.Exec(statement); err != nil {
+		return rcc.setAuthenticationError(cc, status,
+			fmt.Errorf("failed to update default superuser %s: %v", cql.DefaultUsername, err))
+	}
+
+	if err = rcc.saveAppliedSuperuser(cc, username, password); err != nil {
+		return rcc.setAuthenticationError(cc, status, err)
+	}
+
+	now := metav1.Now()
+	status.Authentication = &api.AuthenticationStatus{
+		Superuser:              username,
+		DefaultUser:            defaultUser,
+		SuperuserSecretVersion: secret.ResourceVersion,
+		Status:                 api.StatusDone,
+		LastUpdateTime:         &now,
+	}
+	rcc.recordEvent(cc, v1.EventTypeNormal, reasonAuthenticationDone, "Superuser %s created, default superuser %s",
+		username, defaultUser)
+	return nil
+}
+
+//setAuthenticationError stores err in status.authentication and returns it, an event is recorded when the error
+//changes
+func (rcc *ReconcileCassandraCluster) setAuthenticationError(cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus, err error) error {
+	if status.Authentication == nil {
+		status.Authentication = &api.AuthenticationStatus{}
+	}
+	if status.Authentication.Status != api.StatusError || status.Authentication.Message != err.Error() {
+		rcc.recordEvent(cc, v1.EventTypeWarning, reasonAuthenticationFailed, "Authentication not configured: %v", err)
+	}
+	now := metav1.Now()
+	status.Authentication.Status = api.StatusError
+	status.Authentication.Message = err.Error()
+	status.Authentication.LastUpdateTime = &now
+	return err
+}
+
+//saveAppliedSuperuser stores the credentials of the superuser applied in Cassandra
+func (rcc *ReconcileCassandraCluster) saveAppliedSuperuser(cc *api.CassandraCluster, username, password string) error {
+	data := map[string][]byte{
+		api.CredentialsUsernameKey: []byte(username),
+		api.CredentialsPasswordKey: []byte(password),
+	}
+	secret := &v1.Secret{}
+	err := rcc.client.Get(context.TODO(), types.NamespacedName{Namespace: cc.Namespace,
+		Name: appliedSuperuserSecretName(cc)}, secret)
+	if err == nil {
+		secret.Data = data
+		if err = rcc.client.Update(context.TODO(), secret); err != nil {
+			return fmt.Errorf("failed to update secret %s: %v", appliedSuperuserSecretName(cc), err)
+		}
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get secret %s: %v", appliedSuperuserSecretName(cc), err)
+	}
+	secret = &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appliedSuperuserSecretName(cc),
+			Namespace: cc.Namespace,
+			Labels:    k8s.LabelsForCassandra(cc),
+		},
+		Data: data,
+	}
+	k8s.AddOwnerRefToObject(secret, k8s.AsOwner(cc))
+	if err = rcc.client.Create(context.TODO(), secret); err != nil {
+		return fmt.Errorf("failed to create secret %s: %v", appliedSuperuserSecretName(cc), err)
+	}
+	return nil
+}
+
+//getOrCreateDefaultUserPassword returns the password given to the default superuser, it is generated the first time
+func (rcc *ReconcileCassandraCluster) getOrCreateDefaultUserPassword(cc *api.CassandraCluster) (string, error) {
+	secret := &v1.Secret{}
+	err := rcc.client.Get(context.TODO(), types.NamespacedName{Namespace: cc.Namespace,
+		Name: defaultUserSecretName(cc)}, secret)
+	if err == nil {
+		if len(secret.Data[api.CredentialsPasswordKey]) == 0 {
+			return "", fmt.Errorf("Secret %s must have a %s key", defaultUserSecretName(cc), api.CredentialsPasswordKey)
+		}
+		return string(secret.Data[api.CredentialsPasswordKey]), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get secret %s: %v", defaultUserSecretName(cc), err)
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		return "", err
+	}
+	secret = &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultUserSecretName(cc),
+			Namespace: cc.Namespace,
+			Labels:    k8s.LabelsForCassandra(cc),
+		},
+		Data: map[string][]byte{
+			api.CredentialsUsernameKey: []byte(cql.DefaultUsername),
+			api.CredentialsPasswordKey: []byte(password),
+		},
+	}
+	k8s.AddOwnerRefToObject(secret, k8s.AsOwner(cc))
+	if err = rcc.client.Create(context.TODO(), secret); err != nil {
+		return "", fmt.Errorf("failed to create secret %s: %v", defaultUserSecretName(cc), err)
+	}
+	return password, nil
+}
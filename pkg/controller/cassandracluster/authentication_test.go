@@ -0,0 +1,169 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql/fake"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func helperInitAuthentication(t *testing.T, defaultUser string) (*ReconcileCassandraCluster, *api.CassandraCluster,
+	*fake.Client) {
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	cqlClient := fake.NewClient()
+	rcc.cqlClient = cqlClient
+	cc.Spec.Authentication = &api.AuthenticationSpec{
+		SuperuserSecret: v1.LocalObjectReference{Name: "admin"},
+		DefaultUser:     defaultUser,
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: cc.Namespace},
+		Data: map[string][]byte{
+			api.CredentialsUsernameKey: []byte("admin"),
+			api.CredentialsPasswordKey: []byte("it's secret"),
+		},
+	}
+	if err := rcc.client.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("Can't create secret: %v", err)
+	}
+	return rcc, cc, cqlClient
+}
+
+func TestEnsureAuthenticationDisable(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc, cqlClient := helperInitAuthentication(t, "")
+	status := cc.Status.DeepCopy()
+
+	//Nothing is done before the cluster is running
+	status.Phase = api.ClusterPhaseInitial
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Empty(cqlClient.Statements)
+	assert.Nil(status.Authentication)
+
+	status.Phase = api.ClusterPhaseRunning
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(&fake.Role{Password: "it's secret", Login: true, Superuser: true}, cqlClient.Roles["admin"])
+	assert.Equal(&fake.Role{Password: cql.DefaultPassword}, cqlClient.Roles[cql.DefaultUsername])
+	assert.Equal("admin", status.Authentication.Superuser)
+	assert.Equal(api.DefaultUserDisabled, status.Authentication.DefaultUser)
+	assert.Equal(api.StatusDone, status.Authentication.Status)
+
+	//It is done only once
+	statements := len(cqlClient.Statements)
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(statements, len(cqlClient.Statements))
+
+	//The superuser is used when the default superuser is already disabled
+	status.Authentication = nil
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(2, len(cqlClient.Executed("CREATE ROLE")))
+	assert.Equal(2, len(cqlClient.Executed("ALTER ROLE 'cassandra'")))
+	assert.Equal(api.StatusDone, status.Authentication.Status)
+
+	cc.Status = *status
+	username, password, err := CQLSuperuserCredentials(rcc.client, cc)
+	assert.Nil(err)
+	assert.Equal("admin", username)
+	assert.Equal("it's secret", password)
+}
+
+func TestEnsureAuthenticationChangePassword(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc, cqlClient := helperInitAuthentication(t, api.DefaultUserChangePassword)
+	status := cc.Status.DeepCopy()
+	status.Phase = api.ClusterPhaseRunning
+
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(api.DefaultUserPasswordChanged, status.Authentication.DefaultUser)
+
+	username, password, err := getCredentials(rcc.client, cc.Namespace, "cassandra-demo-default-user")
+	assert.Nil(err)
+	assert.Equal(cql.DefaultUsername, username)
+	assert.Equal(&fake.Role{Password: password, Login: true, Superuser: true}, cqlClient.Roles[cql.DefaultUsername])
+}
+
+func TestEnsureAuthenticationError(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc, cqlClient := helperInitAuthentication(t, "")
+	status := cc.Status.DeepCopy()
+	status.Phase = api.ClusterPhaseRunning
+
+	cqlClient.Err = fmt.Errorf("no host available")
+	assert.NotNil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(api.StatusError, status.Authentication.Status)
+	assert.Contains(status.Authentication.Message, "no host available")
+
+	username, _, err := CQLSuperuserCredentials(rcc.client, cc)
+	assert.Nil(err)
+	assert.Equal(cql.DefaultUsername, username)
+
+	cqlClient.Err = nil
+	cc.Spec.Authentication.SuperuserSecret.Name = "unknown"
+	assert.NotNil(rcc.ensureAuthentication(cc, status))
+	assert.Contains(status.Authentication.Message, "unknown")
+
+	cqlClient.Err = nil
+	cc.Spec.Authentication.SuperuserSecret.Name = "admin"
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(api.StatusDone, status.Authentication.Status)
+	assert.Empty(status.Authentication.Message)
+}
+
+func TestEnsureAuthenticationPasswordChange(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc, cqlClient := helperInitAuthentication(t, "")
+	status := cc.Status.DeepCopy()
+	status.Phase = api.ClusterPhaseRunning
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+
+	secret, err := getCredentialsSecret(rcc.client, cc.Namespace, "admin")
+	assert.Nil(err)
+	secret.ResourceVersion = "2"
+	secret.Data[api.CredentialsPasswordKey] = []byte("new secret")
+	assert.Nil(rcc.client.Update(context.TODO(), secret))
+
+	//The credentials last applied are used until the new password is applied
+	cc.Status = *status
+	_, password, err := CQLSuperuserCredentials(rcc.client, cc)
+	assert.Nil(err)
+	assert.Equal("it's secret", password)
+
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	assert.Equal(&fake.Role{Password: "new secret", Login: true, Superuser: true}, cqlClient.Roles["admin"])
+	assert.Equal("2", status.Authentication.SuperuserSecretVersion)
+	cc.Status = *status
+	_, password, err = CQLSuperuserCredentials(rcc.client, cc)
+	assert.Nil(err)
+	assert.Equal("new secret", password)
+
+	//The default superuser can log in again when its password is changed instead of disabling it
+	cc.Spec.Authentication.DefaultUser = api.DefaultUserChangePassword
+	assert.Nil(rcc.ensureAuthentication(cc, status))
+	_, password, err = getCredentials(rcc.client, cc.Namespace, "cassandra-demo-default-user")
+	assert.Nil(err)
+	assert.Equal(&fake.Role{Password: password, Login: true, Superuser: true}, cqlClient.Roles[cql.DefaultUsername])
+}
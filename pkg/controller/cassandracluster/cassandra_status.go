@@ -16,22 +16,23 @@ package cassandracluster
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
 	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-//Global var used to know if we need to update the CRD
-var needUpdate bool
-
 //updateCassandraStatus updates the CRD if the status has changed
-//if needUpdate is set that mean that we have updated some fields in the CRD
+//if rcc.needUpdate is set that mean that we have updated some fields in the CRD
 //This method also stored the annotation cassandraclusters.db.orange.com/last-applied-configuration with last-applied-configuration
 func (rcc *ReconcileCassandraCluster) updateCassandraStatus(cc *api.CassandraCluster,
 	status *api.CassandraClusterStatus) error {
@@ -42,13 +43,16 @@ func (rcc *ReconcileCassandraCluster) updateCassandraStatus(cc *api.CassandraClu
 
 	lastApplied, _ := cc.ComputeLastAppliedConfiguration()
 
-	if !needUpdate &&
+	updateRackMetrics(cc, status)
+
+	if !rcc.needUpdate &&
 		reflect.DeepEqual(cc.Status, *status) && //Do We need to update Status ?
 		reflect.DeepEqual(cc.Annotations[api.AnnotationLastApplied], string(lastApplied)) && //Do We need to update Annotation ?
 		cc.Annotations[api.AnnotationLastApplied] != "" {
 		return nil
 	}
-	needUpdate = false
+	rcc.needUpdate = false
+	oldStatus := cc.Status.DeepCopy()
 	//make also deepcopy to avoid pointer conflict
 	cc.Status = *status.DeepCopy()
 	cc.Annotations[api.AnnotationLastApplied] = string(lastApplied)
@@ -56,8 +60,10 @@ func (rcc *ReconcileCassandraCluster) updateCassandraStatus(cc *api.CassandraClu
 	err := rcc.client.Update(context.TODO(), cc)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "err": err}).Errorf("Issue when updating CassandraCluster")
+		return err
 	}
-	return err
+	rcc.recordActionEvents(cc, oldStatus, status)
+	return nil
 }
 
 // getNextCassandraClusterStatus goal is to detect some changes in the status between cassandracluster and its statefulset
@@ -130,6 +136,14 @@ func (rcc *ReconcileCassandraCluster) getNextCassandraClusterStatus(cc *api.Cass
 			return nil
 		}
 
+		if UpdateStatusIfTLSCertificatesRenewed(cc, dcRackName, storedStatefulSet, status) {
+			return nil
+		}
+
+		if UpdateStatusIfDataCapacityHasChanged(cc, dcRackName, storedStatefulSet, status) {
+			return nil
+		}
+
 		if UpdateStatusIfStatefulSetChanged(cc, dcRackName, storedStatefulSet, status) {
 			return nil
 		}
@@ -187,13 +201,18 @@ func UpdateStatusIfUpdateResources(cc *api.CassandraCluster, dcRackName string,
 // - or the add or remoove of the configmap in the CRD
 func UpdateStatusIfconfigMapHasChanged(cc *api.CassandraCluster, dcRackName string, storedStatefulSet *appsv1.StatefulSet, status *api.CassandraClusterStatus) bool {
 
-	//Detect a change if there is a difference between the ConfigMapName and mounted volumes
-	//TODO: this needs to be refactor if there can be several volumes/configmap mounted
-	if (storedStatefulSet.Spec.Template.Spec.Volumes != nil && cc.Spec.ConfigMapName != storedStatefulSet.Spec.Template.Spec.Volumes[0].ConfigMap.Name) || (storedStatefulSet.Spec.Template.Spec.Volumes == nil && cc.Spec.ConfigMapName != "") {
+	//Detect a change if there is a difference between the ConfigMapName and the mounted configmap volume
+	storedConfigMapName := ""
+	for _, volume := range storedStatefulSet.Spec.Template.Spec.Volumes {
+		if volume.Name == "cassandra-config" && volume.ConfigMap != nil {
+			storedConfigMapName = volume.ConfigMap.Name
+		}
+	}
+	if cc.Spec.ConfigMapName != storedConfigMapName {
 
-		if storedStatefulSet.Spec.Template.Spec.Volumes != nil {
+		if storedConfigMapName != "" {
 			logrus.Infof("[%s][%s]: We ask to change ConfigMap New-CRD:%s -> Old-StatefulSet:%s", cc.Name, dcRackName,
-				cc.Spec.ConfigMapName, storedStatefulSet.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+				cc.Spec.ConfigMapName, storedConfigMapName)
 		} else {
 			logrus.Infof("[%s][%s]: We ask to change ConfigMap New-CRD:%s -> Old-StatefulSet:%s", cc.Name, dcRackName,
 				cc.Spec.ConfigMapName, "-")
@@ -213,10 +232,11 @@ func UpdateStatusIfDockerImageHasChanged(cc *api.CassandraCluster, dcRackName st
 
 	desiredDockerImage := cc.Spec.BaseImage + ":" + cc.Spec.Version
 
-	//This needs to be refactor if we load more than 1 container
-	if storedStatefulSet.Spec.Template.Spec.Containers != nil &&
-		desiredDockerImage != storedStatefulSet.Spec.Template.Spec.Containers[0].Image {
-		logrus.Infof("[%s][%s]: We ask to change DockerImage CRD:%s -> StatefulSet:%s", cc.Name, dcRackName, desiredDockerImage, storedStatefulSet.Spec.Template.Spec.Containers[0].Image)
+	//The pods may have sidecar containers, so we look for the cassandra container
+	container := cassandraContainer(&storedStatefulSet.Spec.Template.Spec)
+	if container != nil &&
+		desiredDockerImage != container.Image {
+		logrus.Infof("[%s][%s]: We ask to change DockerImage CRD:%s -> StatefulSet:%s", cc.Name, dcRackName, desiredDockerImage, container.Image)
 		lastAction := &status.CassandraRackStatus[dcRackName].CassandraLastAction
 		lastAction.Status = api.StatusToDo
 		lastAction.Name = api.ActionUpdateDockerImage
@@ -244,14 +264,46 @@ func UpdateStatusIfRollingRestart(cc *api.CassandraCluster, dc,
 	return false
 }
 
+//UpdateStatusIfTLSCertificatesRenewed starts a RollingRestart of the rack if its nodes have not loaded the last
+//generation of certificates issued by the Operator
+func UpdateStatusIfTLSCertificatesRenewed(cc *api.CassandraCluster, dcRackName string,
+	storedStatefulSet *appsv1.StatefulSet, status *api.CassandraClusterStatus) bool {
+	dcRackStatus := status.CassandraRackStatus[dcRackName]
+	if status.TLS == nil || dcRackStatus.TLSGeneration == status.TLS.Generation {
+		return false
+	}
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName,
+		"generation": status.TLS.Generation}).Info("Certificates renewed, scoping RollingRestart of the Rack")
+	lastAction := &dcRackStatus.CassandraLastAction
+	lastAction.Status = api.StatusToDo
+	lastAction.Name = api.ActionRollingRestart
+	lastAction.StartTime = nil
+	lastAction.EndTime = nil
+	dcRackStatus.TLSGeneration = status.TLS.Generation
+	return true
+}
+
 //UpdateStatusIfSeedListHasChanged updates CassandraCluster Action Status if it detect a changes
 func UpdateStatusIfSeedListHasChanged(cc *api.CassandraCluster, dcRackName string, storedStatefulSet *appsv1.StatefulSet, status *api.CassandraClusterStatus) bool {
-
 	storedSeedListTab := getStoredSeedListTab(storedStatefulSet)
 
+	//The seeds given by Services are moved by ensureSeedPods without changing the statefulsets, they are only updated
+	//when the list of the seed Services changes with SeedsPerDC or the DCs
+	if cc.Spec.SeedMode == api.SeedModeService {
+		if !cc.Spec.AutoUpdateSeedList || racksAreChanging(status) ||
+			reflect.DeepEqual(strings.Split(cc.GetSeedServiceList(), ","), storedSeedListTab) {
+			return false
+		}
+		logrus.Infof("[%s][%s]: We ask to Change the Cassandra seed Services", cc.Name, dcRackName)
+		setUpdateSeedListConfiguring(&status.CassandraRackStatus[dcRackName].CassandraLastAction)
+		return true
+	}
+
 	//If Automatic Update of SeedList is enabled in the CRD
-	if cc.Spec.AutoUpdateSeedList {
-		//We compute what would be the best SeedList according to CRD Topology
+	//The nodes of a rack added to a DC must bootstrap, they can't be seeds so the SeedList is updated once they run,
+	//and the SeedList is updated once the nodes of a removed rack have been decommissioned
+	if cc.Spec.AutoUpdateSeedList && !racksAreChanging(status) {
+		//We compute what would be the best SeedList according to CRD Topology, SeedsPerDC and SeedOrdinals
 		newSeedListTab := cc.InitSeedList()
 		//We check if some nodes of the newSeedList are missing from Actual one
 		if !k8s.ContainSlice(storedSeedListTab, newSeedListTab) {
@@ -272,17 +324,33 @@ func UpdateStatusIfSeedListHasChanged(cc *api.CassandraCluster, dcRackName strin
 	//This is to ensure that we won't do 2 different kind of operations in different racks at the same time (ex:scaling + updateseedlist)
 	if !reflect.DeepEqual(status.SeedList, storedSeedListTab) {
 		logrus.Infof("[%s][%s]: We ask to Change the Cassandra SeedList", cc.Name, dcRackName)
-		lastAction := &status.CassandraRackStatus[dcRackName].CassandraLastAction
-		lastAction.Status = api.StatusConfiguring
-		lastAction.Name = api.ActionUpdateSeedList
-		lastAction.StartTime = nil
-		lastAction.EndTime = nil
+		setUpdateSeedListConfiguring(&status.CassandraRackStatus[dcRackName].CassandraLastAction)
 		return true
 	}
 
 	return false
 }
 
+//setUpdateSeedListConfiguring flags the rack with UpdateSeedList, it starts once all the racks are flagged
+func setUpdateSeedListConfiguring(lastAction *api.CassandraLastAction) {
+	lastAction.Status = api.StatusConfiguring
+	lastAction.Name = api.ActionUpdateSeedList
+	lastAction.StartTime = nil
+	lastAction.EndTime = nil
+}
+
+//racksAreChanging returns true while the nodes of a rack added to an existing DC are bootstrapping or the nodes of
+//a removed rack are decommissioned
+func racksAreChanging(status *api.CassandraClusterStatus) bool {
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		if dcRackStatus.Removing || (dcRackStatus.Phase == api.ClusterPhaseInitial &&
+			dcRackStatus.CassandraLastAction.Name == api.ActionAddRack) {
+			return true
+		}
+	}
+	return false
+}
+
 //UpdateStatusIfScaling will detect any change of replicas
 //For Scale Down the operator will need to first Decommission the last node from Cassandra before remooving it from kubernetes.
 //For Scale Up some PodOperations may be scheduled if Auto-pilot is activeted.
@@ -305,6 +373,31 @@ func UpdateStatusIfScaling(cc *api.CassandraCluster, dcRackName string, storedSt
 	return false
 }
 
+//UpdateStatusIfDataCapacityHasChanged sets the action UpdateDataCapacity if the DataCapacity of the DC is greater than
+//the one of the volumeClaimTemplates of the statefulset, the PVCs of the rack are then expanded by expandDataPVCs
+func UpdateStatusIfDataCapacityHasChanged(cc *api.CassandraCluster, dcRackName string,
+	storedStatefulSet *appsv1.StatefulSet, status *api.CassandraClusterStatus) bool {
+	dataCapacity, err := resource.ParseQuantity(cc.GetDataCapacityForDC(cc.GetDCFromDCRackName(dcRackName)))
+	if err != nil {
+		return false
+	}
+	for _, volumeClaimTemplate := range storedStatefulSet.Spec.VolumeClaimTemplates {
+		request := volumeClaimTemplate.Spec.Resources.Requests[v1.ResourceStorage]
+		if volumeClaimTemplate.Name != "data" || request.Cmp(dataCapacity) >= 0 {
+			continue
+		}
+		logrus.Infof("[%s][%s]: Update Rack Status UpdateDataCapacity=ToDo to expand its PVCs from %s to %s",
+			cc.Name, dcRackName, request.String(), dataCapacity.String())
+		lastAction := &status.CassandraRackStatus[dcRackName].CassandraLastAction
+		lastAction.Name = api.ActionUpdateDataCapacity
+		lastAction.Status = api.StatusToDo
+		lastAction.StartTime = nil
+		lastAction.EndTime = nil
+		return true
+	}
+	return false
+}
+
 // UpdateStatusIfStatefulSetChanged detects if there is a change in the statefulset which was not already caught
 // If we detect a Statefulset change with this method, then the operator won't catch it before the statefulset tells the operator
 // that a change is ongoing.
@@ -354,13 +447,7 @@ func (rcc *ReconcileCassandraCluster) UpdateStatusIfActionEnded(cc *api.Cassandr
 					lastAction.Status = api.StatusDone
 					lastAction.EndTime = &now
 
-					labels := map[string]string{"operation-name": api.OperationCleanup}
-					if cc.Spec.AutoPilot {
-						labels["operation-status"] = api.StatusToDo
-					} else {
-						labels["operation-status"] = api.StatusManual
-					}
-					rcc.addPodOperationLabels(cc, dcName, rackName, labels)
+					rcc.addPodOperationLabels(cc, dcName, rackName, podOperationLabels(cc, api.OperationCleanup))
 
 					return true
 				}
@@ -380,8 +467,12 @@ func (rcc *ReconcileCassandraCluster) UpdateStatusIfActionEnded(cc *api.Cassandr
 				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName}).Info("ScaleDown not yet Completed: Waiting for Pod operation to be Done")
 			}
 
-		case api.ClusterPhaseInitial:
-			//nothing particular here
+		case api.ClusterPhaseInitial, api.ActionAddRack:
+			//ended by UpdateCassandraRackStatusPhase once the nodes of the rack are running
+			return false
+
+		case api.ActionUpdateDataCapacity:
+			//ended by expandDataPVCs once the statefulset has been recreated
 			return false
 
 		default:
@@ -443,6 +534,10 @@ func (rcc *ReconcileCassandraCluster) UpdateCassandraRackStatusPhase(cc *api.Cas
 				lastAction.EndTime = &now
 				lastAction.Status = api.StatusDone
 				logrus.Infof("[%s][%s]: StatefulSet(%s): Replicas Number OK: ready[%d]", cc.Name, dcRackName, lastAction.Name, storedStatefulSet.Status.ReadyReplicas)
+				//The data of the other racks of the DC are now also owned by the nodes of the new rack
+				if lastAction.Name == api.ActionAddRack {
+					rcc.addCleanupToOtherRacks(cc, dcName, rackName)
+				}
 				return nil
 			}
 			return nil
@@ -481,4 +576,79 @@ func setDecommissionStatus(status *api.CassandraClusterStatus, dcRackName string
 	status.CassandraRackStatus[dcRackName].PodLastOperation.Pods = []string{}
 	status.CassandraRackStatus[dcRackName].PodLastOperation.PodsOK = []string{}
 	status.CassandraRackStatus[dcRackName].PodLastOperation.PodsKO = []string{}
-}
\ No newline at end of file
+}
+//UpdateClusterConditions computes the conditions Ready, Progressing, Degraded, ScalingDown and OperationFailed
+//from the status of the cluster and of its racks
+func UpdateClusterConditions(cc *api.CassandraCluster, status *api.CassandraClusterStatus) {
+	var notRunning, degraded, scalingDown, operationFailed []string
+
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			dcRackName := cc.GetDCRackName(dcName, cc.GetRackName(dc, rack))
+			dcRackStatus, ok := status.CassandraRackStatus[dcRackName]
+			if !ok {
+				continue
+			}
+			lastAction := dcRackStatus.CassandraLastAction
+			if dcRackStatus.Phase != api.ClusterPhaseRunning {
+				notRunning = append(notRunning, dcRackName)
+				//Pods not ready while no action is ongoing
+				if dcRackStatus.Phase == api.ClusterPhasePending && lastAction.Status == api.StatusDone {
+					degraded = append(degraded, dcRackName)
+				}
+			}
+			if lastAction.Name == api.ActionScaleDown && lastAction.Status != api.StatusDone {
+				scalingDown = append(scalingDown, dcRackName)
+			}
+			if len(dcRackStatus.PodLastOperation.PodsKO) > 0 {
+				operationFailed = append(operationFailed, fmt.Sprintf("%s on %s",
+					dcRackStatus.PodLastOperation.Name, strings.Join(dcRackStatus.PodLastOperation.PodsKO, ",")))
+			}
+		}
+	}
+
+	if len(notRunning) == 0 {
+		status.SetCondition(api.ClusterReady, v1.ConditionTrue, api.ClusterPhaseRunning, "All racks are running")
+	} else {
+		status.SetCondition(api.ClusterReady, v1.ConditionFalse, status.Phase,
+			"Racks not running: "+strings.Join(notRunning, ", "))
+	}
+
+	if status.LastClusterActionStatus != api.StatusDone && status.LastClusterAction != "" {
+		status.SetCondition(api.ClusterProgressing, v1.ConditionTrue, status.LastClusterAction,
+			fmt.Sprintf("Action %s is %s", status.LastClusterAction, status.LastClusterActionStatus))
+	} else {
+		status.SetCondition(api.ClusterProgressing, v1.ConditionFalse, api.StatusDone, "No action ongoing")
+	}
+
+	if len(degraded) > 0 {
+		status.SetCondition(api.ClusterDegraded, v1.ConditionTrue, api.ClusterPhasePending,
+			"Racks with pods not ready: "+strings.Join(degraded, ", "))
+	} else {
+		status.SetCondition(api.ClusterDegraded, v1.ConditionFalse, "", "")
+	}
+
+	if len(scalingDown) > 0 {
+		status.SetCondition(api.ClusterScalingDown, v1.ConditionTrue, api.ActionScaleDown,
+			"Racks scaling down: "+strings.Join(scalingDown, ", "))
+	} else {
+		status.SetCondition(api.ClusterScalingDown, v1.ConditionFalse, "", "")
+	}
+
+	if len(operationFailed) > 0 {
+		status.SetCondition(api.ClusterOperationFailed, v1.ConditionTrue, "PodOperationFailed",
+			"Failed pod operations: "+strings.Join(operationFailed, "; "))
+	} else {
+		status.SetCondition(api.ClusterOperationFailed, v1.ConditionFalse, "", "")
+	}
+}
+
+//setChangeRefused sets the condition ChangeRefused with the reason of the refusal of a change and the error
+//It also records the refusal as an event of the cluster and in the metrics
+func (rcc *ReconcileCassandraCluster) setChangeRefused(cc *api.CassandraCluster, status *api.CassandraClusterStatus,
+	reason string, err error) {
+	status.SetCondition(api.ClusterChangeRefused, v1.ConditionTrue, reason, err.Error())
+	refusedChanges.WithLabelValues(cc.Namespace, cc.Name, reason).Inc()
+	rcc.recordEvent(cc, v1.EventTypeWarning, reason, "Change refused: %v", err)
+}
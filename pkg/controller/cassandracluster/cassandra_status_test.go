@@ -30,6 +30,7 @@ import (
 	"strconv"
 	"testing"
 
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
@@ -116,6 +117,61 @@ func TestUpdateStatusIfSeedListHasChanged(t *testing.T) {
 
 }
 
+func TestUpdateStatusIfSeedListHasChangedSeedsPerDC(t *testing.T) {
+	assert := assert.New(t)
+
+	var cc api.CassandraCluster
+	err := yaml.Unmarshal([]byte(cc2Dcs), &cc)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+	}
+	cc.InitCassandraRackList()
+	cc.Spec.AutoUpdateSeedList = true
+	cc.Status.SeedList = cc.InitSeedList()
+	status := cc.Status.DeepCopy()
+
+	storedStatefulSet := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: v1.PodTemplateSpec{
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "cassandra", Env: []v1.EnvVar{
+			{Name: "CASSANDRA_SEEDS", Value: cc.GetSeedList(&cc.Status.SeedList)},
+		}}}}}}}
+	assert.False(UpdateStatusIfSeedListHasChanged(&cc, "dc1-rack1", storedStatefulSet, status))
+
+	//A test cluster wants a single seed per DC
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(1)
+	assert.True(UpdateStatusIfSeedListHasChanged(&cc, "dc1-rack1", storedStatefulSet, status))
+	assert.Equal([]string{"cassandra-demo-dc1-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-0.cassandra-demo.ns"}, status.SeedList)
+	assert.Equal(api.ActionUpdateSeedList, status.CassandraRackStatus["dc1-rack1"].CassandraLastAction.Name)
+}
+
+func TestUpdateStatusIfSeedListHasChangedSeedMode(t *testing.T) {
+	assert := assert.New(t)
+
+	var cc api.CassandraCluster
+	err := yaml.Unmarshal([]byte(cc2Dcs), &cc)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+	}
+	cc.InitCassandraRackList()
+	cc.Spec.AutoUpdateSeedList = true
+	cc.Spec.SeedMode = api.SeedModeService
+	cc.Status.SeedList = cc.InitSeedList()
+	status := cc.Status.DeepCopy()
+
+	storedStatefulSet := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: v1.PodTemplateSpec{
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "cassandra", Env: []v1.EnvVar{
+			{Name: "CASSANDRA_SEEDS", Value: cc.GetSeedServiceList()},
+		}}}}}}}
+	//The seeds moving to other pods don't change the seed Services
+	status.SeedList = status.SeedList[1:]
+	assert.False(UpdateStatusIfSeedListHasChanged(&cc, "dc1-rack1", storedStatefulSet, status))
+
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(1)
+	assert.True(UpdateStatusIfSeedListHasChanged(&cc, "dc1-rack1", storedStatefulSet, status))
+	assert.Equal(api.ActionUpdateSeedList, status.CassandraRackStatus["dc1-rack1"].CassandraLastAction.Name)
+	assert.Equal(api.StatusConfiguring, status.CassandraRackStatus["dc1-rack1"].CassandraLastAction.Status)
+}
+
 //helperCreateCassandraCluster fake create a cluster from the yaml specified
 func helperCreateCassandraCluster(t *testing.T, cassandraClusterFileName string) (*ReconcileCassandraCluster,
 	*reconcile.Request) {
@@ -254,3 +310,90 @@ func TestReconcileCassandraCluster(t *testing.T) {
 	}
 
 }
+
+func TestNextReconcile(t *testing.T) {
+	assert := assert.New(t)
+
+	var cc api.CassandraCluster
+	err := yaml.Unmarshal([]byte(cc2Dcs), &cc)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+	}
+	cc.InitCassandraRackList()
+	status := &cc.Status
+
+	//The cluster is initializing
+	assert.Equal(requeueDelayInProgress, nextReconcile(status).RequeueAfter)
+
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		dcRackStatus.Phase = api.ClusterPhaseRunning
+		dcRackStatus.CassandraLastAction.Status = api.StatusDone
+	}
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	assert.Equal(resyncDelay, nextReconcile(status).RequeueAfter)
+
+	status.CassandraRackStatus["dc1-rack2"].PodLastOperation.Name = api.OperationCleanup
+	status.CassandraRackStatus["dc1-rack2"].PodLastOperation.Status = api.StatusOngoing
+	assert.Equal(requeueDelayInProgress, nextReconcile(status).RequeueAfter)
+	status.CassandraRackStatus["dc1-rack2"].PodLastOperation.Status = api.StatusDone
+	assert.Equal(resyncDelay, nextReconcile(status).RequeueAfter)
+}
+
+func TestClusterOfPod(t *testing.T) {
+	assert := assert.New(t)
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cassandra-demo-dc1-rack1-0", Namespace: namespace,
+		Labels: map[string]string{"app": "cassandracluster", "cassandracluster": name}}}
+	assert.Equal([]reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}},
+		clusterOfPod(handler.MapObject{Meta: pod, Object: pod}))
+
+	pod.Labels = map[string]string{"app": "other"}
+	assert.Empty(clusterOfPod(handler.MapObject{Meta: pod, Object: pod}))
+}
+
+func TestUpdateClusterConditions(t *testing.T) {
+	assert := assert.New(t)
+
+	var cc api.CassandraCluster
+	err := yaml.Unmarshal([]byte(cc2Dcs), &cc)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+	}
+	cc.InitCassandraRackList()
+	status := &cc.Status
+
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	assert.Equal(v1.ConditionFalse, status.GetCondition(api.ClusterReady).Status)
+	assert.Equal(api.ClusterPhaseInitial, status.GetCondition(api.ClusterReady).Reason)
+	assert.Equal(v1.ConditionTrue, status.GetCondition(api.ClusterProgressing).Status)
+
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		dcRackStatus.Phase = api.ClusterPhaseRunning
+		dcRackStatus.CassandraLastAction.Status = api.StatusDone
+	}
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	ready := *status.GetCondition(api.ClusterReady)
+	assert.Equal(v1.ConditionTrue, ready.Status)
+	assert.Equal(v1.ConditionFalse, status.GetCondition(api.ClusterProgressing).Status)
+	assert.Equal(v1.ConditionFalse, status.GetCondition(api.ClusterDegraded).Status)
+
+	//The transition time does not change with the same status
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	assert.Equal(ready.LastTransitionTime, status.GetCondition(api.ClusterReady).LastTransitionTime)
+
+	//Pods not ready without action ongoing
+	status.CassandraRackStatus["dc1-rack2"].Phase = api.ClusterPhasePending
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	assert.Equal(v1.ConditionFalse, status.GetCondition(api.ClusterReady).Status)
+	assert.Equal(v1.ConditionTrue, status.GetCondition(api.ClusterDegraded).Status)
+	assert.Equal("Racks with pods not ready: dc1-rack2", status.GetCondition(api.ClusterDegraded).Message)
+
+	setDecommissionStatus(status, "dc2-rack1")
+	status.CassandraRackStatus["dc1-rack2"].PodLastOperation.Name = api.OperationCleanup
+	status.CassandraRackStatus["dc1-rack2"].PodLastOperation.PodsKO = []string{"cassandra-demo-dc1-rack2-0"}
+	UpdateCassandraClusterStatusPhase(&cc, status)
+	assert.Equal(v1.ConditionTrue, status.GetCondition(api.ClusterScalingDown).Status)
+	assert.Equal(v1.ConditionTrue, status.GetCondition(api.ClusterOperationFailed).Status)
+	assert.Equal("Failed pod operations: cleanup on cassandra-demo-dc1-rack2-0",
+		status.GetCondition(api.ClusterOperationFailed).Message)
+}
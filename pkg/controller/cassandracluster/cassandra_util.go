@@ -26,7 +26,19 @@ import (
 
 func isCassandraVersionMatch(ps v1.PodSpec, ccs api.CassandraClusterSpec) bool {
 	desiredImage := cassandraImage(ccs)
-	return ps.Containers[0].Image == desiredImage
+	container := cassandraContainer(&ps)
+	return container != nil && container.Image == desiredImage
+}
+
+//cassandraContainer returns the cassandra container of the pod spec, which may have sidecar containers, or nil if it
+//has none
+func cassandraContainer(ps *v1.PodSpec) *v1.Container {
+	for i := range ps.Containers {
+		if ps.Containers[i].Name == cassandraContainerName {
+			return &ps.Containers[i]
+		}
+	}
+	return nil
 }
 
 //thereIsNoPodDisruption return true if there is no Disruption in the Pods of the cassandra Cluster
@@ -21,10 +21,14 @@ import (
 	"github.com/sirupsen/logrus"
 
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -36,6 +40,9 @@ import (
 
 var log = logf.Log.WithName("controller_cassandracluster")
 
+//MaxConcurrentReconciles is the number of CassandraClusters which can be reconciled at the same time
+var MaxConcurrentReconciles = 1
+
 // Add creates a new CassandraCluster Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -44,13 +51,15 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileCassandraCluster{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileCassandraCluster{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		recorder: mgr.GetRecorder("cassandracluster-controller"), cqlClient: cql.NewClient()}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("cassandracluster-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("cassandracluster-controller", mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: MaxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
@@ -61,20 +70,63 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// TODO(user): Modify this to be the types you create that are owned by the primary resource
-	/* We currently don't have secondary resource to watch
-	// Modify this to be the types you create that are owned by the primary resource
-	// Watch for changes to secondary resource Pods and requeue the owner CassandraCluster
-	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
-		IsController: true,
-		OwnerType:    &dbv1alpha1.CassandraCluster{},
+	// Watch for changes to secondary resources StatefulSets, PodDisruptionBudgets and Services and requeue the owner
+	// CassandraCluster
+	for _, ownedType := range []runtime.Object{&appsv1.StatefulSet{}, &policyv1beta1.PodDisruptionBudget{},
+		&v1.Service{}} {
+		err = c.Watch(&source.Kind{Type: ownedType}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &api.CassandraCluster{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Pods are owned by the StatefulSets, they are mapped to their CassandraCluster with their labels so that a pod
+	// becoming ready or the labels of an operation trigger a reconcile
+	return c.Watch(&source.Kind{Type: &v1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(clusterOfPod),
 	})
-	if err != nil {
-		return err
+}
+
+//clusterOfPod returns the request of the CassandraCluster of a pod created by the operator
+func clusterOfPod(object handler.MapObject) []reconcile.Request {
+	labels := object.Meta.GetLabels()
+	if labels["app"] != "cassandracluster" || labels["cassandracluster"] == "" {
+		return nil
 	}
-	*/
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: object.Meta.GetNamespace(),
+		Name: labels["cassandracluster"]}}}
+}
+
+//Delays before the next reconcile of a cluster. The watches reconcile a cluster as soon as it or one of its objects
+//changes, the short delay is only used to follow the actions and the operations running in the cassandra nodes
+const (
+	requeueDelayInProgress = 5 * time.Second
+	resyncDelay            = 5 * time.Minute
+)
 
-	return nil
+//nextReconcile returns the result requeuing a cluster after requeueDelayInProgress while an action or a pod operation
+//is in progress and after resyncDelay otherwise
+func nextReconcile(status *api.CassandraClusterStatus) reconcile.Result {
+	inProgress := reconcile.Result{RequeueAfter: requeueDelayInProgress}
+	if status.Phase != api.ClusterPhaseRunning || status.LastClusterActionStatus != api.StatusDone {
+		return inProgress
+	}
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		if dcRackStatus == nil {
+			continue
+		}
+		if dcRackStatus.Phase != api.ClusterPhaseRunning || dcRackStatus.CassandraLastAction.Status != api.StatusDone {
+			return inProgress
+		}
+		switch dcRackStatus.PodLastOperation.Status {
+		case api.StatusToDo, api.StatusOngoing, api.StatusFinalizing:
+			return inProgress
+		}
+	}
+	return reconcile.Result{RequeueAfter: resyncDelay}
 }
 
 var _ reconcile.Reconciler = &ReconcileCassandraCluster{}
@@ -83,12 +135,26 @@ var _ reconcile.Reconciler = &ReconcileCassandraCluster{}
 type ReconcileCassandraCluster struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	cc     *api.CassandraCluster
 	client client.Client
 	scheme *runtime.Scheme
+	// recorder records the events of the clusters and of the pods running operations
+	recorder record.EventRecorder
+	// cqlClient opens the CQL sessions used to configure the authentication
+	cqlClient cql.Client
 
+	// The fields below are the state of a reconcile. Each reconcile works on its own copy of the reconciler so that
+	// several clusters can be reconciled at the same time
+	cc                *api.CassandraCluster
 	storedPdb         *policyv1beta1.PodDisruptionBudget
 	storedStatefulSet *appsv1.StatefulSet
+	// needUpdate is set when fields of the CassandraCluster have been changed and must be updated
+	needUpdate bool
+}
+
+//forRequest returns a copy of the reconciler sharing its clients, with the state of a new reconcile
+func (rcc *ReconcileCassandraCluster) forRequest() *ReconcileCassandraCluster {
+	return &ReconcileCassandraCluster{client: rcc.client, scheme: rcc.scheme, recorder: rcc.recorder,
+		cqlClient: rcc.cqlClient}
 }
 
 // Reconcile reads that state of the cluster for a CassandraCluster object and makes changes based on the state read
@@ -97,9 +163,13 @@ type ReconcileCassandraCluster struct {
 // Note:
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (rcc *ReconcileCassandraCluster) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (rcc *ReconcileCassandraCluster) Reconcile(request reconcile.Request) (result reconcile.Result, err error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling CassandraCluster")
+	rcc = rcc.forRequest()
+	defer func(start time.Time) {
+		observeReconcile(request.Namespace, request.Name, start, err)
+	}(time.Now())
 
 	requeue30 := reconcile.Result{RequeueAfter: 30 * time.Second}
 	requeue5 := reconcile.Result{RequeueAfter: 5 * time.Second}
@@ -109,27 +179,44 @@ func (rcc *ReconcileCassandraCluster) Reconcile(request reconcile.Request) (reco
 	// Fetch the CassandraCluster instance
 	rcc.cc = &api.CassandraCluster{}
 	cc := rcc.cc
-	err := rcc.client.Get(context.TODO(), request.NamespacedName, cc)
+	err = rcc.client.Get(context.TODO(), request.NamespacedName, cc)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			deleteClusterMetrics(request.Namespace, request.Name)
+			deleteFinalizedOperations(request.NamespacedName)
 			return forget, nil
 		}
 		// Error reading the object - requeue the request.
 		return forget, err
 	}
 
+	// The racks of the DCs placed on the zones of the nodes are written in the spec before they are initialized
+	generated, err := rcc.generateTopologyRacks(cc)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Errorf("generateTopologyRacks Error: %v", err)
+		return requeue30, err
+	}
+	if generated {
+		return requeue, rcc.client.Update(context.TODO(), cc)
+	}
+
 	// After first time reconcile, phase will switch to "Initializing".
 	if cc.Status.Phase == "" {
-		// Simulate initializer.
-		changed := cc.SetDefaults()
-		if changed {
+		// The defaults of the spec are set by the mutating webhook. As the webhooks are optional
+		// (WEBHOOK_ENABLED), they are also written here for the clusters created without them, which
+		// requires to update the spec and to reconcile again
+		if cc.SetSpecDefaults() {
 			updateDeletePvcStrategy(cc)
 			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Info("Initialization: Update CassandraCluster")
 			return requeue, rcc.client.Update(context.TODO(), cc)
 		}
+		// The status is initialized here and stored with the status update at the end of the reconcile
+		cc.SetDefaults()
+		updateDeletePvcStrategy(cc)
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Info("Initialization: Init CassandraCluster status")
 	}
 
 	err = rcc.CheckDeletePVC(cc)
@@ -147,6 +234,13 @@ func (rcc *ReconcileCassandraCluster) Reconcile(request reconcile.Request) (reco
 		return requeue30, nil
 	}
 
+	//The keystores must exist before the pods of the statefulsets are started
+	if err = rcc.ensureCassandraTLS(cc, status); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Errorf("ensureCassandraTLS Error: %v", err)
+		rcc.recordEvent(cc, v1.EventTypeWarning, reasonTLSFailed, "Certificates of the nodes not issued: %v", err)
+		return requeue30, err
+	}
+
 	if err = rcc.ensureCassandraPodDisruptionBudget(cc); err != nil {
 		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Errorf("ensureCassandraPodDisruptionBudget Error: %v", err)
 	}
@@ -160,9 +254,19 @@ func (rcc *ReconcileCassandraCluster) Reconcile(request reconcile.Request) (reco
 	//Do we need to UpdateSeedList
 	FlipCassandraClusterUpdateSeedListStatus(cc, status)
 
+	if cc.Spec.SeedMode == api.SeedModeService {
+		if err = rcc.ensureSeedPods(cc, status); err != nil {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Errorf("ensureSeedPods Error: %v", err)
+		}
+	}
+
 	UpdateCassandraClusterStatusPhase(cc, status)
 
-	//We could set different requeue based on current Operation
-	return requeue5, nil
+	if err = rcc.ensureAuthentication(cc, status); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Errorf("ensureAuthentication Error: %v", err)
+		return requeue30, nil
+	}
+
+	return nextReconcile(status), nil
 
 }
@@ -68,6 +68,42 @@ func (rcc *ReconcileCassandraCluster) ensureCassandraServiceMonitoring(cc *api.C
 	return nil
 }
 
+//ensureCassandraSeedServices creates or updates the headless Services selecting the pods of the seeds of the DC dcName
+//and deletes the Services of the seeds the DC no longer has
+func (rcc *ReconcileCassandraCluster) ensureCassandraSeedServices(cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus, dcName string) error {
+	labels := k8s.LabelsForCassandraDC(cc, dcName)
+	seedServices := map[string]bool{}
+	for index, seed := range cc.GetDCSeedList(status.SeedList, dcName) {
+		svc := generateCassandraSeedService(cc, dcName, index, seedPodName(seed), labels, nil)
+		k8s.AddOwnerRefToObject(svc, k8s.AsOwner(cc))
+		if err := rcc.CreateOrUpdateService(svc); err != nil {
+			return fmt.Errorf("failed to create or update cassandra seed service: %v", err)
+		}
+		seedServices[svc.Name] = true
+	}
+	return rcc.deleteCassandraSeedServices(cc, dcName, seedServices)
+}
+
+//deleteCassandraSeedServices deletes the seed Services of the DC dcName which are not in keep
+func (rcc *ReconcileCassandraCluster) deleteCassandraSeedServices(cc *api.CassandraCluster, dcName string,
+	keep map[string]bool) error {
+	svcList, err := rcc.ListServices(cc.Namespace, k8s.MergeLabels(k8s.LabelsForCassandraDC(cc, dcName),
+		map[string]string{seedLabel: "true"}))
+	if err != nil {
+		return err
+	}
+	for _, svc := range svcList.Items {
+		if keep[svc.Name] {
+			continue
+		}
+		if err = rcc.DeleteService(cc.Namespace, svc.Name); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete cassandra seed service: %v", err)
+		}
+	}
+	return nil
+}
+
 // ensureCassandraPodDisruptionBudget generate and apply the PodDisruptionBudget
 // take dcName to accordingly named the pdb, and target the pods
 func (rcc *ReconcileCassandraCluster) ensureCassandraPodDisruptionBudget(cc *api.CassandraCluster) error {
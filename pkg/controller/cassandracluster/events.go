@@ -0,0 +1,76 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"sort"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+//Reasons of the events recorded for pod operations, certificates, authentication and topology, the events of actions use the
+//name of the action as reason
+const (
+	reasonPodOperationStarted    = "PodOperationStarted"
+	reasonPodOperationSucceeded  = "PodOperationSucceeded"
+	reasonPodOperationFailed     = "PodOperationFailed"
+	reasonTLSCertificatesRenewed = "TLSCertificatesRenewed"
+	reasonTLSFailed              = "TLSFailed"
+	reasonAuthenticationDone     = "AuthenticationConfigured"
+	reasonAuthenticationFailed   = "AuthenticationFailed"
+	reasonTopologyRacksCreated   = "TopologyRacksCreated"
+	reasonTopologyFailed         = "TopologyFailed"
+	reasonRackRemoved            = "RackRemoved"
+	reasonSeedPromoted           = "SeedPromoted"
+)
+
+//recordEvent records an event on object if the reconciler has a recorder
+func (rcc *ReconcileCassandraCluster) recordEvent(object runtime.Object, eventType, reason, messageFmt string,
+	args ...interface{}) {
+	if rcc.recorder == nil {
+		return
+	}
+	rcc.recorder.Eventf(object, eventType, reason, messageFmt, args...)
+}
+
+//recordActionEvents records an event on the cluster for each rack whose CassandraLastAction has changed from
+//oldStatus to status
+func (rcc *ReconcileCassandraCluster) recordActionEvents(cc *api.CassandraCluster,
+	oldStatus *api.CassandraClusterStatus, status *api.CassandraClusterStatus) {
+	var dcRackNames []string
+	for dcRackName := range status.CassandraRackStatus {
+		dcRackNames = append(dcRackNames, dcRackName)
+	}
+	sort.Strings(dcRackNames)
+
+	for _, dcRackName := range dcRackNames {
+		lastAction := status.CassandraRackStatus[dcRackName].CassandraLastAction
+		var oldLastAction api.CassandraLastAction
+		if oldDCRackStatus, ok := oldStatus.CassandraRackStatus[dcRackName]; ok && oldDCRackStatus != nil {
+			oldLastAction = oldDCRackStatus.CassandraLastAction
+		}
+		if lastAction.Name == oldLastAction.Name && lastAction.Status == oldLastAction.Status {
+			continue
+		}
+		eventType := v1.EventTypeNormal
+		if lastAction.Status == api.StatusError {
+			eventType = v1.EventTypeWarning
+		}
+		rcc.recordEvent(cc, eventType, lastAction.Name, "Action %s is %s on rack %s",
+			lastAction.Name, lastAction.Status, dcRackName)
+	}
+}
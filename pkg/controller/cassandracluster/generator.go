@@ -42,8 +42,20 @@ type JvmMemory struct {
 /*Bunch of different constants*/
 const (
 	cassandraContainerName = "cassandra"
-	defaultJvmMaxHeap      = "2048M"
-	hostnameTopologyKey    = "kubernetes.io/hostname"
+	//RestoreContainerName is the name of the init container seeding the data of a node from a backup
+	RestoreContainerName = "restore"
+	//configContainerName is the name of the init container writing in cassandra.yaml the settings managed by CassKop
+	configContainerName = "config"
+	defaultJvmMaxHeap   = "2048M"
+	hostnameTopologyKey = "kubernetes.io/hostname"
+
+	commitLogVolumeName = "commitlog"
+	commitLogMountPath  = "/var/lib/cassandra-commitlog"
+
+	//configMapMountPath is the directory whose files are copied to /etc/cassandra by the cassandra image
+	configMapMountPath    = "/tmp/cassandra/configmap"
+	userConfigMountPath   = "/tmp/cassandra/user-configmap"
+	generatedConfigVolume = "cassandra-generated-config"
 
 	livenessInitialDelaySeconds int32 = 120
 	livenessHealthCheckTimeout  int32 = 20
@@ -100,6 +112,43 @@ func generateCassandraService(cc *api.CassandraCluster, labels map[string]string
 	}
 }
 
+//generateCassandraSeedService returns the headless Service of the seed index of the DC dcName, it selects the pod podName
+//by the name label set by its statefulset when the pod is created. The address of the pod is published before it is
+//ready so that the first seeds of a new cluster can start
+func generateCassandraSeedService(cc *api.CassandraCluster, dcName string, index int, podName string,
+	labels map[string]string, ownerRefs []metav1.OwnerReference) *v1.Service {
+	return &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cc.GetSeedServiceName(dcName, index),
+			Namespace:       cc.Namespace,
+			Labels:          k8s.MergeLabels(labels, map[string]string{seedLabel: "true"}),
+			OwnerReferences: ownerRefs,
+		},
+		Spec: v1.ServiceSpec{
+			Type:      v1.ServiceTypeClusterIP,
+			ClusterIP: v1.ClusterIPNone,
+			Ports: []v1.ServicePort{
+				v1.ServicePort{
+					Port:     cassandraIntraNodePort,
+					Protocol: v1.ProtocolTCP,
+					Name:     cassandraIntraNodeName,
+				},
+				v1.ServicePort{
+					Port:     cassandraIntraNodeTLSPort,
+					Protocol: v1.ProtocolTCP,
+					Name:     cassandraIntraNodeTLSName,
+				},
+			},
+			Selector:                 k8s.MergeLabels(labels, map[string]string{appsv1.StatefulSetPodNameLabel: podName}),
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
 func generateCassandraExporterService(cc *api.CassandraCluster, labels map[string]string, ownerRefs []metav1.OwnerReference) *v1.Service {
 	name := cc.GetName()
 	namespace := cc.Namespace
@@ -149,59 +198,132 @@ func generateCassandraVolumes(cc *api.CassandraCluster) []v1.Volume {
 		})
 	}
 
-	return v
+	if needsConfigContainer(cc) {
+		v = append(v, v1.Volume{
+			Name: generatedConfigVolume,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	if cc.Spec.TLS != nil {
+		v = append(v, v1.Volume{
+			Name: tlsVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: tlsKeystoreSecretName(cc),
+				},
+			},
+		})
+	}
+
+	return append(v, cc.Spec.ExtraVolumes...)
 }
 
-func generateCassandraVolumeMount(cc *api.CassandraCluster) []v1.VolumeMount {
+func generateCassandraVolumeMount(cc *api.CassandraCluster, dcName string) []v1.VolumeMount {
 	var vm []v1.VolumeMount
 
-	if cc.Spec.DataCapacity != "" {
+	if cc.GetDataCapacityForDC(dcName) != "" {
 		vm = append(vm, v1.VolumeMount{
 			Name:      "data",
 			MountPath: "/var/lib/cassandra",
 		})
 	}
 
-	if cc.Spec.ConfigMapName != "" {
+	if cc.Spec.CommitLogVolume != nil {
+		vm = append(vm, v1.VolumeMount{
+			Name:      commitLogVolumeName,
+			MountPath: commitLogMountPath,
+		})
+	}
+
+	for i, directory := range cc.GetDataDirectories()[1:] {
+		vm = append(vm, v1.VolumeMount{
+			Name:      additionalDataVolumeName(i),
+			MountPath: directory,
+		})
+	}
+
+	//The files of the ConfigMap are given to the cassandra image by the config init container when it is used
+	if needsConfigContainer(cc) {
+		vm = append(vm, v1.VolumeMount{
+			Name:      generatedConfigVolume,
+			MountPath: configMapMountPath,
+		})
+	} else if cc.Spec.ConfigMapName != "" {
 		vm = append(vm, v1.VolumeMount{
 			Name:      "cassandra-config",
-			MountPath: "/tmp/cassandra/configmap",
+			MountPath: configMapMountPath,
 		})
 	}
-	return vm
+
+	if cc.Spec.TLS != nil {
+		vm = append(vm, v1.VolumeMount{
+			Name:      tlsVolumeName,
+			MountPath: tlsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return append(vm, cc.Spec.ExtraVolumeMounts...)
 }
 
-func generateVolumeClaimTemplate(cc *api.CassandraCluster, labels map[string]string) []v1.PersistentVolumeClaim {
+//additionalDataVolumeName returns the name of the i-th additional data volume
+func additionalDataVolumeName(i int) string {
+	return fmt.Sprintf("data%d", i+1)
+}
+
+func generateVolumeClaimTemplate(cc *api.CassandraCluster, labels map[string]string,
+	dcName string) []v1.PersistentVolumeClaim {
 
 	var pvc []v1.PersistentVolumeClaim
 
-	if cc.Spec.DataCapacity == "" {
-		logrus.Warnf("[%s]: No Spec.DataCapacity was specified -> You Cluster WILL NOT HAVE PERSISTENT DATA!!!!!", cc.Name)
-		return pvc
+	dataCapacity := cc.GetDataCapacityForDC(dcName)
+	if dataCapacity == "" {
+		logrus.Warnf("[%s][%s]: No DataCapacity was specified -> You DC WILL NOT HAVE PERSISTENT DATA!!!!!",
+			cc.Name, dcName)
+	} else {
+		pvc = append(pvc, generatePersistentVolumeClaim("data", labels, dataCapacity,
+			cc.GetDataStorageClassForDC(dcName)))
+	}
+
+	if volume := cc.Spec.CommitLogVolume; volume != nil {
+		pvc = append(pvc, generatePersistentVolumeClaim(commitLogVolumeName, labels, volume.Capacity,
+			volume.StorageClass))
 	}
 
-	pvc = []v1.PersistentVolumeClaim{
-		v1.PersistentVolumeClaim{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:   "data",
-				Labels: labels,
+	for i, volume := range cc.Spec.AdditionalDataVolumes {
+		pvc = append(pvc, generatePersistentVolumeClaim(additionalDataVolumeName(i), labels, volume.Capacity,
+			volume.StorageClass))
+	}
+
+	return pvc
+}
+
+//generatePersistentVolumeClaim returns a claim template of capacity, using the default storage class if storageClass
+//is empty
+func generatePersistentVolumeClaim(name string, labels map[string]string, capacity,
+	storageClass string) v1.PersistentVolumeClaim {
+	pvc := v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				v1.ReadWriteOnce,
 			},
-			Spec: v1.PersistentVolumeClaimSpec{
-				AccessModes: []v1.PersistentVolumeAccessMode{
-					v1.ReadWriteOnce,
-				},
 
-				Resources: v1.ResourceRequirements{
-					Requests: v1.ResourceList{
-						"storage": generateResourceQuantity(cc.Spec.DataCapacity),
-					},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					"storage": generateResourceQuantity(capacity),
 				},
 			},
 		},
 	}
 
-	if cc.Spec.DataStorageClass != "" {
-		pvc[0].Spec.StorageClassName = &cc.Spec.DataStorageClass
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
 	}
 
 	return pvc
@@ -213,20 +335,20 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 	name := cc.GetName()
 	namespace := cc.Namespace
 
-	spec := cc.Spec
 	cassandraImage := k8s.GetCassandraImage(cc)
-	resources := getCassandraResources(spec)
+	resources := getCassandraResources(cc.GetResourcesFromDCRackName(dcRackName))
 
 	volumes := generateCassandraVolumes(cc)
-	volumemounts := generateCassandraVolumeMount(cc)
+	volumemounts := generateCassandraVolumeMount(cc, dcName)
 
-	volumeClaimTemplate := generateVolumeClaimTemplate(cc, labels)
+	volumeClaimTemplate := generateVolumeClaimTemplate(cc, labels, dcName)
 
 	for _, pvc := range volumeClaimTemplate {
 		k8s.AddOwnerRefToObject(&pvc, k8s.AsOwner(cc))
 	}
 
 	nodeAffinity := createNodeAffinity(nodeSelector)
+	scheduling := cc.GetPodSchedulingFromDCRackName(dcRackName)
 
 	nodesPerRacks := cc.GetNodesPerRacks(dcRackName)
 	numTokensPerRacks := cc.GetNumTokensPerRacks(dcRackName)
@@ -235,6 +357,9 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 
 	//in statefulset.go we surcharge this value with conditions
 	seedList := cc.GetSeedList(&status.SeedList)
+	if cc.Spec.SeedMode == api.SeedModeService {
+		seedList = cc.GetSeedServiceList()
+	}
 
 	terminationPeriod := int64(api.DefaultTerminationGracePeriodSeconds)
 
@@ -263,7 +388,8 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      k8s.MergeLabels(cc.Spec.PodLabels, labels),
+					Annotations: cc.Spec.PodAnnotations,
 				},
 				Spec: v1.PodSpec{
 					Affinity: &v1.Affinity{
@@ -456,6 +582,10 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 							Resources: resources,
 						},
 					},
+					Tolerations:                   scheduling.Tolerations,
+					PriorityClassName:             scheduling.PriorityClassName,
+					SchedulerName:                 scheduling.SchedulerName,
+					ServiceAccountName:            scheduling.ServiceAccountName,
 					Volumes:                       volumes,
 					RestartPolicy:                 v1.RestartPolicyAlways,
 					TerminationGracePeriodSeconds: &terminationPeriod,
@@ -465,6 +595,16 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 		},
 	}
 
+	if cc.Spec.RestoreFrom != nil {
+		ss.Spec.Template.Spec.InitContainers = []v1.Container{generateRestoreContainer(cc, dcRackName)}
+	}
+	if needsConfigContainer(cc) {
+		ss.Spec.Template.Spec.InitContainers = append(ss.Spec.Template.Spec.InitContainers,
+			generateConfigContainer(cc))
+	}
+	ss.Spec.Template.Spec.InitContainers = append(ss.Spec.Template.Spec.InitContainers, cc.Spec.InitContainers...)
+	ss.Spec.Template.Spec.Containers = append(ss.Spec.Template.Spec.Containers, cc.Spec.SidecarContainers...)
+
 	//Add secrets
 
 	if (cc.Spec.ImagePullSecret != v1.LocalObjectReference{}) {
@@ -500,9 +640,88 @@ func generateCassandraStatefulSet(cc *api.CassandraCluster, status *api.Cassandr
 		}
 	}
 
+	if cc.Spec.TLS != nil {
+		for idx, container := range ss.Spec.Template.Spec.Containers {
+			if container.Name == cassandraContainerName {
+				ss.Spec.Template.Spec.Containers[idx].Env = append(container.Env, generateTLSEnv(cc)...)
+			}
+		}
+	}
+
+	if storageEnv := generateStorageEnv(cc); len(storageEnv) > 0 {
+		for idx, container := range ss.Spec.Template.Spec.Containers {
+			if container.Name == cassandraContainerName {
+				ss.Spec.Template.Spec.Containers[idx].Env = append(container.Env, storageEnv...)
+			}
+		}
+	}
+
+	if len(cc.Spec.ExtraEnv) > 0 {
+		for idx, container := range ss.Spec.Template.Spec.Containers {
+			if container.Name == cassandraContainerName {
+				ss.Spec.Template.Spec.Containers[idx].Env = append(container.Env, cc.Spec.ExtraEnv...)
+			}
+		}
+	}
+
 	return ss
 }
 
+//generateStorageEnv returns the variables used by the config init container to set commitlog_directory and
+//data_file_directories when the cluster has a commitlog volume or additional data volumes
+func generateStorageEnv(cc *api.CassandraCluster) []v1.EnvVar {
+	var env []v1.EnvVar
+	if cc.Spec.CommitLogVolume != nil {
+		env = append(env, v1.EnvVar{Name: "CASSANDRA_COMMITLOG_DIRECTORY", Value: commitLogMountPath})
+	}
+	if len(cc.Spec.AdditionalDataVolumes) > 0 {
+		env = append(env, v1.EnvVar{Name: "CASSANDRA_DATA_FILE_DIRECTORIES",
+			Value: strings.Join(cc.GetDataDirectories(), ",")})
+	}
+	return env
+}
+
+//generateTLSEnv returns the variables used by the config init container to set server_encryption_options and
+//client_encryption_options, the keystore of each pod is found with POD_NAME
+func generateTLSEnv(cc *api.CassandraCluster) []v1.EnvVar {
+	password := &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{Name: tlsKeystoreSecretName(cc)},
+			Key:                  tlsKeystorePasswordKey,
+		},
+	}
+	return []v1.EnvVar{
+		v1.EnvVar{
+			Name:  "CASSANDRA_INTERNODE_ENCRYPTION",
+			Value: cc.Spec.TLS.GetInternodeEncryption(),
+		},
+		v1.EnvVar{
+			Name:  "CASSANDRA_CLIENT_ENCRYPTION",
+			Value: strconv.FormatBool(cc.Spec.TLS.ClientEncryption),
+		},
+		v1.EnvVar{
+			Name:  "CASSANDRA_KEYSTORE",
+			Value: tlsMountPath + "/$(POD_NAME)" + tlsKeystoreSuffix,
+		},
+		v1.EnvVar{
+			Name:      "CASSANDRA_KEYSTORE_PASSWORD",
+			ValueFrom: password,
+		},
+		v1.EnvVar{
+			Name:  "CASSANDRA_TRUSTSTORE",
+			Value: tlsMountPath + "/" + tlsTruststoreKey,
+		},
+		v1.EnvVar{
+			Name:      "CASSANDRA_TRUSTSTORE_PASSWORD",
+			ValueFrom: password,
+		},
+		v1.EnvVar{
+			Name:  "CASSANDRA_STORE_TYPE",
+			Value: tlsStoreType,
+		},
+	}
+}
+
 func generateResourceQuantity(qs string) resource.Quantity {
 	q, _ := resource.ParseQuantity(qs)
 	return q
@@ -547,10 +766,10 @@ func generatePodDisruptionBudget(name string, namespace string, labels map[strin
 	}
 }
 
-func getCassandraResources(spec api.CassandraClusterSpec) v1.ResourceRequirements {
+func getCassandraResources(resources api.CassandraResources) v1.ResourceRequirements {
 	return v1.ResourceRequirements{
-		Requests: getRequests(spec.Resources),
-		Limits:   getLimits(spec.Resources),
+		Requests: getRequests(resources),
+		Limits:   getLimits(resources),
 	}
 }
 
@@ -639,3 +858,142 @@ func createPodAntiAffinity(hard bool, labels map[string]string) *v1.PodAntiAffin
 		},
 	}
 }
+
+/*restoreScript downloads in the data directory the files stored for the ordinal of the pod, it does it only once
+so that a restarted pod does not override the data written since the restore. The credentials are given to mc as
+arguments as they may contain characters which are not allowed in a URL*/
+const restoreScript = `set -e
+if [ -f /var/lib/cassandra/.restored ]; then
+  echo "Data already restored"
+  exit 0
+fi
+mc --config-dir /tmp/.mc config host add backup "$S3_SCHEME://$S3_ENDPOINT" "$S3_ACCESS_KEY_ID" \
+  "$S3_SECRET_ACCESS_KEY" > /dev/null || mc --config-dir /tmp/.mc alias set backup "$S3_SCHEME://$S3_ENDPOINT" \
+  "$S3_ACCESS_KEY_ID" "$S3_SECRET_ACCESS_KEY" > /dev/null
+SOURCE="backup/$S3_BUCKET/$S3_PREFIX${HOSTNAME##*-}/"
+if [ -n "$(mc --config-dir /tmp/.mc ls "$SOURCE")" ]; then
+  mc --config-dir /tmp/.mc cp --recursive "$SOURCE" /var/lib/cassandra/data/
+fi
+touch /var/lib/cassandra/.restored
+`
+
+//generateRestoreContainer returns the init container seeding the data of the nodes of a rack from a backup
+func generateRestoreContainer(cc *api.CassandraCluster, dcRackName string) v1.Container {
+	restoreFrom := cc.Spec.RestoreFrom
+	scheme := "https"
+	if restoreFrom.Storage.Insecure {
+		scheme = "http"
+	}
+	return v1.Container{
+		Name:            RestoreContainerName,
+		Image:           restoreFrom.GetImage(),
+		ImagePullPolicy: cc.Spec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", restoreScript},
+		Env: []v1.EnvVar{
+			v1.EnvVar{Name: "S3_SCHEME", Value: scheme},
+			v1.EnvVar{Name: "S3_ENDPOINT", Value: restoreFrom.Storage.Endpoint},
+			v1.EnvVar{Name: "S3_BUCKET", Value: restoreFrom.Storage.Bucket},
+			v1.EnvVar{Name: "S3_PREFIX", Value: restoreFrom.Storage.RackKeyPrefix(restoreFrom.Backup, dcRackName)},
+			v1.EnvVar{
+				Name: "S3_ACCESS_KEY_ID",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: restoreFrom.Storage.CredentialsSecret,
+						Key:                  api.StorageAccessKeyIDKey,
+					},
+				},
+			},
+			v1.EnvVar{
+				Name: "S3_SECRET_ACCESS_KEY",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: restoreFrom.Storage.CredentialsSecret,
+						Key:                  api.StorageSecretAccessKeyKey,
+					},
+				},
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			v1.VolumeMount{
+				Name:      "data",
+				MountPath: "/var/lib/cassandra",
+			},
+		},
+	}
+}
+
+/*configScript copies the cassandra.yaml of the image and the files of the ConfigMap of the cluster in the directory
+the cassandra image copies to /etc/cassandra, then writes in cassandra.yaml the settings managed by CassKop*/
+const configScript = `set -e
+cp /etc/cassandra/cassandra.yaml /tmp/cassandra/configmap/
+if [ -d /tmp/cassandra/user-configmap ]; then
+  cp -L /tmp/cassandra/user-configmap/* /tmp/cassandra/configmap/
+fi
+CONF=/tmp/cassandra/configmap/cassandra.yaml
+if [ -n "$CASSANDRA_KEYSTORE" ]; then
+  echo "Configure encryption options"
+  sed -ri "s|^(\s*)#?\s*internode_encryption:.*|\1internode_encryption: ${CASSANDRA_INTERNODE_ENCRYPTION}|" $CONF
+  sed -ri "s|^(\s*)#?\s*keystore:.*|\1keystore: ${CASSANDRA_KEYSTORE}|" $CONF
+  sed -ri "s|^(\s*)#?\s*keystore_password:.*|\1keystore_password: ${CASSANDRA_KEYSTORE_PASSWORD}\n\1store_type: ${CASSANDRA_STORE_TYPE}|" $CONF
+  sed -ri "s|^(\s*)#?\s*truststore:.*|\1truststore: ${CASSANDRA_TRUSTSTORE}|" $CONF
+  sed -ri "s|^(\s*)#?\s*truststore_password:.*|\1truststore_password: ${CASSANDRA_TRUSTSTORE_PASSWORD}|" $CONF
+  sed -ri "/^client_encryption_options:/,/^\S/ s|^(\s*)enabled:.*|\1enabled: ${CASSANDRA_CLIENT_ENCRYPTION}|" $CONF
+fi
+if [ -n "$CASSANDRA_COMMITLOG_DIRECTORY" ]; then
+  echo "Configure commitlog_directory"
+  sed -ri "s|^#?\s*commitlog_directory:.*|commitlog_directory: ${CASSANDRA_COMMITLOG_DIRECTORY}|" $CONF
+fi
+if [ -n "$CASSANDRA_DATA_FILE_DIRECTORIES" ]; then
+  echo "Configure data_file_directories"
+  sed -ri "/^#?\s*data_file_directories:/,/^\S/ { /^#?\s*-/d }" $CONF
+  DIRECTORIES=$(echo "$CASSANDRA_DATA_FILE_DIRECTORIES" | sed "s|,|\\\\n    - |g")
+  sed -ri "s|^#?\s*data_file_directories:.*|data_file_directories:\n    - ${DIRECTORIES}|" $CONF
+fi
+`
+
+//needsConfigContainer returns true if cassandra.yaml has settings managed by CassKop
+func needsConfigContainer(cc *api.CassandraCluster) bool {
+	return cc.Spec.TLS != nil || len(generateStorageEnv(cc)) > 0
+}
+
+//generateConfigContainer returns the init container writing in cassandra.yaml the settings managed by CassKop, on top
+//of the files of the ConfigMap of the cluster. It uses the cassandra image to start from its cassandra.yaml
+func generateConfigContainer(cc *api.CassandraCluster) v1.Container {
+	env := []v1.EnvVar{
+		v1.EnvVar{
+			Name: "POD_NAME",
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.name",
+				},
+			},
+		},
+	}
+	if cc.Spec.TLS != nil {
+		env = append(env, generateTLSEnv(cc)...)
+	}
+	env = append(env, generateStorageEnv(cc)...)
+
+	volumeMounts := []v1.VolumeMount{
+		v1.VolumeMount{
+			Name:      generatedConfigVolume,
+			MountPath: configMapMountPath,
+		},
+	}
+	if cc.Spec.ConfigMapName != "" {
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      "cassandra-config",
+			MountPath: userConfigMountPath,
+		})
+	}
+
+	return v1.Container{
+		Name:            configContainerName,
+		Image:           k8s.GetCassandraImage(cc),
+		ImagePullPolicy: cc.Spec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", configScript},
+		Env:             env,
+		VolumeMounts:    volumeMounts,
+	}
+}
@@ -17,7 +17,12 @@ package cassandracluster
 import (
 	"testing"
 
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCreateNodeAffinity(t *testing.T) {
@@ -88,3 +93,370 @@ func TestCreatePodAntiAffinityHard(t *testing.T) {
 	assert.Equal(podAntiAffinityHard.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey, hostnameTopologyKey)
 	assert.Equal(podAntiAffinityHard.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels, labels)
 }
+
+func TestGenerateCassandraStatefulSetRestoreFrom(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Empty(ss.Spec.Template.Spec.InitContainers)
+
+	cc.Spec.RestoreFrom = &api.RestoreFrom{
+		Backup: "backup1",
+		Storage: api.BackupStorage{Endpoint: "minio:9000", Bucket: "backups", Prefix: "k8s", Insecure: true,
+			CredentialsSecret: v1.LocalObjectReference{Name: "backup-credentials"}},
+	}
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+
+	assert.Equal(1, len(ss.Spec.Template.Spec.InitContainers))
+	restore := ss.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(RestoreContainerName, restore.Name)
+	assert.Equal(api.DefaultRestoreImage, restore.Image)
+	env := map[string]string{}
+	for _, envVar := range restore.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal("http", env["S3_SCHEME"])
+	assert.Equal("minio:9000", env["S3_ENDPOINT"])
+	assert.Equal("backups", env["S3_BUCKET"])
+	assert.Equal("k8s/backup1/dc1-rack1/", env["S3_PREFIX"])
+	assert.Equal("data", restore.VolumeMounts[0].Name)
+	//The credentials are not put in a URL
+	assert.Contains(restore.Command[2],
+		`config host add backup "$S3_SCHEME://$S3_ENDPOINT" "$S3_ACCESS_KEY_ID" \
+  "$S3_SECRET_ACCESS_KEY"`)
+	assert.NotContains(restore.Command[2], "MC_HOST")
+}
+
+func TestGenerateCassandraStatefulSetTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	cc.Spec.TLS = &api.TLSSpec{InternodeEncryption: api.InternodeEncryptionDC}
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+
+	volumes := ss.Spec.Template.Spec.Volumes
+	assert.Equal(tlsVolumeName, volumes[len(volumes)-1].Name)
+	assert.Equal("cassandra-demo-tls", volumes[len(volumes)-1].Secret.SecretName)
+	container := ss.Spec.Template.Spec.Containers[0]
+	mounts := container.VolumeMounts
+	assert.Equal(tlsMountPath, mounts[len(mounts)-1].MountPath)
+
+	env := map[string]v1.EnvVar{}
+	for _, envVar := range container.Env {
+		env[envVar.Name] = envVar
+	}
+	assert.Equal("dc", env["CASSANDRA_INTERNODE_ENCRYPTION"].Value)
+	assert.Equal("false", env["CASSANDRA_CLIENT_ENCRYPTION"].Value)
+	assert.Equal("/etc/cassandra-tls/$(POD_NAME).keystore.p12", env["CASSANDRA_KEYSTORE"].Value)
+	assert.Equal("keystore-password", env["CASSANDRA_KEYSTORE_PASSWORD"].ValueFrom.SecretKeyRef.Key)
+	assert.Equal("/etc/cassandra-tls/truststore.p12", env["CASSANDRA_TRUSTSTORE"].Value)
+	assert.Equal("PKCS12", env["CASSANDRA_STORE_TYPE"].Value)
+
+	//The secret volume is not taken for the configmap
+	assert.False(UpdateStatusIfconfigMapHasChanged(cc, "dc1-rack1", ss, status))
+
+	//The encryption options are written in cassandra.yaml by the config init container, on top of the ConfigMap
+	cc.Spec.ConfigMapName = "cassandra-configmap-v1"
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Equal(1, len(ss.Spec.Template.Spec.InitContainers))
+	config := ss.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(configContainerName, config.Name)
+	assert.Equal(ss.Spec.Template.Spec.Containers[0].Image, config.Image)
+	configEnv := map[string]v1.EnvVar{}
+	for _, envVar := range config.Env {
+		configEnv[envVar.Name] = envVar
+	}
+	assert.Equal("metadata.name", configEnv["POD_NAME"].ValueFrom.FieldRef.FieldPath)
+	assert.Equal(env["CASSANDRA_KEYSTORE"], configEnv["CASSANDRA_KEYSTORE"])
+	assert.Equal(env["CASSANDRA_INTERNODE_ENCRYPTION"], configEnv["CASSANDRA_INTERNODE_ENCRYPTION"])
+	assert.Equal([]v1.VolumeMount{{Name: generatedConfigVolume, MountPath: "/tmp/cassandra/configmap"},
+		{Name: "cassandra-config", MountPath: "/tmp/cassandra/user-configmap"}}, config.VolumeMounts)
+	configMounts := map[string]string{}
+	for _, mount := range ss.Spec.Template.Spec.Containers[0].VolumeMounts {
+		configMounts[mount.MountPath] = mount.Name
+	}
+	assert.Equal(generatedConfigVolume, configMounts["/tmp/cassandra/configmap"])
+	assert.False(UpdateStatusIfconfigMapHasChanged(cc, "dc1-rack1", ss, status))
+}
+
+func TestGenerateCassandraStatefulSetResources(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	cc.Spec.Topology.DC[0].Resources = &api.CassandraResources{Requests: api.CPUAndMem{CPU: "2", Memory: "4Gi"}}
+	cc.Spec.Topology.DC[0].Rack[1].Resources = &api.CassandraResources{Requests: api.CPUAndMem{CPU: "4"}}
+	//The limits of the DCs and racks default to their requests like those of the cluster
+	assert.True(cc.SetSpecDefaults())
+
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	resources := ss.Spec.Template.Spec.Containers[0].Resources
+	assert.Equal("2", resources.Requests.Cpu().String())
+	assert.Equal("4Gi", resources.Requests.Memory().String())
+	assert.Equal(resources.Requests, resources.Limits)
+
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack2", labels, nodeSelector, []metav1.OwnerReference{})
+	resources = ss.Spec.Template.Spec.Containers[0].Resources
+	assert.Equal("4", resources.Requests.Cpu().String())
+	assert.Equal(1, len(resources.Requests))
+	assert.Equal(resources.Requests, resources.Limits)
+
+	ss = generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	resources = ss.Spec.Template.Spec.Containers[0].Resources
+	assert.Equal("1", resources.Requests.Cpu().String())
+	assert.Equal("2Gi", resources.Limits.Memory().String())
+}
+
+func TestGenerateCassandraStatefulSetDCDataStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 1, 0)
+
+	cc.Spec.Topology.DC[1].DataCapacity = "10Gi"
+	cc.Spec.Topology.DC[1].DataStorageClass = "fast"
+
+	ss := generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	pvc := ss.Spec.VolumeClaimTemplates[0]
+	request := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("10Gi", request.String())
+	assert.Equal("fast", *pvc.Spec.StorageClassName)
+
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	pvc = ss.Spec.VolumeClaimTemplates[0]
+	request = pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("3Gi", request.String())
+	assert.Equal("local-storage", *pvc.Spec.StorageClassName)
+
+	//A DC without capacity has no persistent data
+	cc.Spec.DataCapacity = ""
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Empty(ss.Spec.VolumeClaimTemplates)
+	for _, mount := range ss.Spec.Template.Spec.Containers[0].VolumeMounts {
+		assert.NotEqual("data", mount.Name)
+	}
+}
+
+func TestGenerateCassandraStatefulSetVolumes(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	cc.Spec.CommitLogVolume = &api.StorageVolume{Capacity: "1Gi", StorageClass: "fast"}
+	cc.Spec.AdditionalDataVolumes = []api.StorageVolume{{Capacity: "5Gi"}, {Capacity: "6Gi"}}
+
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	pvcs := ss.Spec.VolumeClaimTemplates
+	assert.Equal(4, len(pvcs))
+	assert.Equal("data", pvcs[0].Name)
+	assert.Equal("commitlog", pvcs[1].Name)
+	request := pvcs[1].Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("1Gi", request.String())
+	assert.Equal("fast", *pvcs[1].Spec.StorageClassName)
+	assert.Equal("data1", pvcs[2].Name)
+	assert.Nil(pvcs[2].Spec.StorageClassName)
+	assert.Equal("data2", pvcs[3].Name)
+	request = pvcs[3].Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("6Gi", request.String())
+
+	mounts := map[string]string{}
+	for _, mount := range ss.Spec.Template.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal("/var/lib/cassandra", mounts["data"])
+	assert.Equal("/var/lib/cassandra-commitlog", mounts["commitlog"])
+	assert.Equal("/var/lib/cassandra-data1", mounts["data1"])
+	assert.Equal("/var/lib/cassandra-data2", mounts["data2"])
+
+	env := map[string]string{}
+	for _, envVar := range ss.Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal("/var/lib/cassandra-commitlog", env["CASSANDRA_COMMITLOG_DIRECTORY"])
+	assert.Equal("/var/lib/cassandra/data,/var/lib/cassandra-data1,/var/lib/cassandra-data2",
+		env["CASSANDRA_DATA_FILE_DIRECTORIES"])
+
+	//The directories are written in cassandra.yaml by the config init container
+	assert.Equal(1, len(ss.Spec.Template.Spec.InitContainers))
+	config := ss.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(configContainerName, config.Name)
+	configEnv := map[string]string{}
+	for _, envVar := range config.Env {
+		configEnv[envVar.Name] = envVar.Value
+	}
+	assert.Equal(env["CASSANDRA_COMMITLOG_DIRECTORY"], configEnv["CASSANDRA_COMMITLOG_DIRECTORY"])
+	assert.Equal(env["CASSANDRA_DATA_FILE_DIRECTORIES"], configEnv["CASSANDRA_DATA_FILE_DIRECTORIES"])
+	assert.Equal("/tmp/cassandra/configmap", mounts[generatedConfigVolume])
+
+	//The directories are not set without the volumes
+	cc.Spec.CommitLogVolume = nil
+	cc.Spec.AdditionalDataVolumes = nil
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Equal(1, len(ss.Spec.VolumeClaimTemplates))
+	assert.Empty(ss.Spec.Template.Spec.InitContainers)
+	for _, envVar := range ss.Spec.Template.Spec.Containers[0].Env {
+		assert.NotEqual("CASSANDRA_COMMITLOG_DIRECTORY", envVar.Name)
+		assert.NotEqual("CASSANDRA_DATA_FILE_DIRECTORIES", envVar.Name)
+	}
+}
+
+func TestGenerateCassandraStatefulSetPodCustomisation(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	cc.Spec.SidecarContainers = []v1.Container{{Name: "logs", Image: "busybox"}}
+	cc.Spec.InitContainers = []v1.Container{{Name: "sysctl", Image: "busybox"}}
+	cc.Spec.ExtraVolumes = []v1.Volume{{Name: "tools", VolumeSource: v1.VolumeSource{
+		EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+	cc.Spec.ExtraVolumeMounts = []v1.VolumeMount{{Name: "tools", MountPath: "/opt/tools"}}
+	cc.Spec.ExtraEnv = []v1.EnvVar{{Name: "JVM_EXTRA_OPTS", Value: "-Dcassandra.ring_delay_ms=30000"}}
+	cc.Spec.PodAnnotations = map[string]string{"prometheus.io/scrape": "true"}
+	cc.Spec.PodLabels = map[string]string{"team": "storage", "app": "other"}
+
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	podSpec := ss.Spec.Template.Spec
+	assert.Equal(2, len(podSpec.Containers))
+	assert.Equal("cassandra", podSpec.Containers[0].Name)
+	assert.Equal("logs", podSpec.Containers[1].Name)
+	assert.Equal([]v1.Container{{Name: "sysctl", Image: "busybox"}}, podSpec.InitContainers)
+	assert.Equal(cc.Spec.ExtraVolumes[0], podSpec.Volumes[len(podSpec.Volumes)-1])
+	mounts := podSpec.Containers[0].VolumeMounts
+	assert.Equal(cc.Spec.ExtraVolumeMounts[0], mounts[len(mounts)-1])
+	env := podSpec.Containers[0].Env
+	assert.Equal(cc.Spec.ExtraEnv[0], env[len(env)-1])
+	assert.Equal("true", ss.Spec.Template.Annotations["prometheus.io/scrape"])
+	assert.Equal("storage", ss.Spec.Template.Labels["team"])
+	assert.Equal(labels["app"], ss.Spec.Template.Labels["app"])
+	assert.Equal(labels, ss.Spec.Selector.MatchLabels)
+
+	//The restore init container stays first
+	cc.Spec.RestoreFrom = &api.RestoreFrom{Backup: "backup"}
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Equal(RestoreContainerName, ss.Spec.Template.Spec.InitContainers[0].Name)
+	assert.Equal("sysctl", ss.Spec.Template.Spec.InitContainers[1].Name)
+}
+
+func TestStatefulSetsAreEqualWithSidecars(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	cc.Spec.SidecarContainers = []v1.Container{{Name: "logs", Image: "busybox"}}
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+
+	//The cassandra container is found by name even if it is not the first one
+	swapped := ss.DeepCopy()
+	containers := swapped.Spec.Template.Spec.Containers
+	containers[0], containers[1] = containers[1], containers[0]
+	assert.Equal("cassandra", cassandraContainer(&swapped.Spec.Template.Spec).Name)
+	assert.True(isCassandraVersionMatch(swapped.Spec.Template.Spec, cc.Spec))
+	assert.False(UpdateStatusIfDockerImageHasChanged(cc, "dc1-rack1", swapped, status))
+	cc.Spec.Version = "3.11.5"
+	assert.True(UpdateStatusIfDockerImageHasChanged(cc, "dc1-rack1", swapped, status))
+
+	stored := ss.DeepCopy()
+	stored.Spec.Template.Spec.Containers[0].LivenessProbe.FailureThreshold = 10
+	assert.True(statefulSetsAreEqual(stored, ss.DeepCopy()))
+
+	stored.Spec.Template.Spec.Containers[1].Image = "busybox:1.31"
+	assert.False(statefulSetsAreEqual(stored, ss.DeepCopy()))
+}
+
+func TestGenerateCassandraStatefulSetPodScheduling(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	dedicated := v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "cassandra",
+		Effect: v1.TaintEffectNoSchedule}
+	ssd := v1.Toleration{Key: "disk", Operator: v1.TolerationOpEqual, Value: "ssd", Effect: v1.TaintEffectNoSchedule}
+	cc.Spec.PodScheduling = api.PodScheduling{
+		Tolerations:        []v1.Toleration{dedicated},
+		PriorityClassName:  "cassandra",
+		ServiceAccountName: "cassandra",
+	}
+	cc.Spec.Topology.DC[0].PodScheduling = api.PodScheduling{
+		Tolerations:       []v1.Toleration{dedicated, ssd},
+		PriorityClassName: "cassandra-high",
+	}
+	cc.Spec.Topology.DC[0].Rack[0].SchedulerName = "custom-scheduler"
+
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	podSpec := ss.Spec.Template.Spec
+	assert.Equal([]v1.Toleration{dedicated, ssd}, podSpec.Tolerations)
+	assert.Equal("cassandra-high", podSpec.PriorityClassName)
+	assert.Equal("custom-scheduler", podSpec.SchedulerName)
+	assert.Equal("cassandra", podSpec.ServiceAccountName)
+
+	ss = generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack2", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Equal("", ss.Spec.Template.Spec.SchedulerName)
+
+	//Only the scheduling of the cluster applies to dc2
+	ss = generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.Equal([]v1.Toleration{dedicated}, ss.Spec.Template.Spec.Tolerations)
+	assert.Equal("cassandra", ss.Spec.Template.Spec.PriorityClassName)
+
+	//The default scheduler and the serviceAccount set by kubernetes are not differences
+	stored := ss.DeepCopy()
+	stored.Spec.Template.Spec.SchedulerName = v1.DefaultSchedulerName
+	stored.Spec.Template.Spec.DeprecatedServiceAccount = "cassandra"
+	assert.True(statefulSetsAreEqual(stored, ss.DeepCopy()))
+
+	cc.Spec.SchedulerName = "custom-scheduler"
+	ss = generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.False(statefulSetsAreEqual(stored, ss.DeepCopy()))
+
+	//Going back to the default scheduler is a difference
+	stored = ss.DeepCopy()
+	cc.Spec.SchedulerName = ""
+	ss = generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	assert.False(statefulSetsAreEqual(stored, ss.DeepCopy()))
+}
+
+func TestGenerateCassandraSeedService(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+
+	svc := generateCassandraSeedService(cc, "dc1", 1, "cassandra-demo-dc1-rack2-0", k8s.LabelsForCassandraDC(cc, "dc1"),
+		nil)
+	assert.Equal("cassandra-demo-dc1-seed-1", svc.Name)
+	assert.Equal("true", svc.Labels[seedLabel])
+	assert.Equal(v1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.True(svc.Spec.PublishNotReadyAddresses)
+	assert.Equal("cassandra-demo-dc1-rack2-0", svc.Spec.Selector[appsv1.StatefulSetPodNameLabel])
+	assert.Equal("dc1", svc.Spec.Selector["cassandraclusters.db.orange.com.dc"])
+
+	//The seeds given to Cassandra don't depend on the seeds of the status but on SeedsPerDC
+	cc.Spec.SeedMode = api.SeedModeService
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(2)
+	status.SeedList = cc.InitSeedList()[:1]
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, []metav1.OwnerReference{})
+	for _, envVar := range ss.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name == "CASSANDRA_SEEDS" {
+			assert.Equal("cassandra-demo-dc1-seed-0.ns,cassandra-demo-dc1-seed-1.ns,"+
+				"cassandra-demo-dc2-seed-0.ns,cassandra-demo-dc2-seed-1.ns", envVar.Value)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "casskop_reconcile_duration_seconds",
+		Help:    "Duration of the reconciles of a CassandraCluster",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"namespace", "cluster"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casskop_reconcile_errors_total",
+		Help: "Number of reconciles of a CassandraCluster which returned an error",
+	}, []string{"namespace", "cluster"})
+
+	rackActionStartTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "casskop_rack_action_start_time_seconds",
+		Help: "Start time of the current action of a rack in seconds since epoch, labeled with the action and its status",
+	}, []string{"namespace", "cluster", "dc_rack", "action", "status"})
+
+	rackPodOperationStartTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "casskop_rack_pod_operation_start_time_seconds",
+		Help: "Start time of the last pod operation of a rack in seconds since epoch, labeled with the operation " +
+			"and its status",
+	}, []string{"namespace", "cluster", "dc_rack", "operation", "status"})
+
+	podOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casskop_pod_operations_total",
+		Help: "Number of pod operations run by the operator by operation and result",
+	}, []string{"namespace", "cluster", "operation", "result"})
+
+	podOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "casskop_pod_operation_duration_seconds",
+		Help:    "Duration of the pod operations run by the operator by operation and result",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 9),
+	}, []string{"operation", "result"})
+
+	jolokiaDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "casskop_jolokia_request_duration_seconds",
+		Help:    "Duration of the Jolokia requests sent to the cassandra pods",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 10),
+	}, []string{"namespace", "cluster", "pod", "request"})
+
+	jolokiaFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casskop_jolokia_request_failures_total",
+		Help: "Number of Jolokia requests sent to the cassandra pods which failed",
+	}, []string{"namespace", "cluster", "pod", "request"})
+
+	refusedChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casskop_refused_changes_total",
+		Help: "Number of changes of the spec of a CassandraCluster refused and restored by the operator",
+	}, []string{"namespace", "cluster", "reason"})
+)
+
+//rackMetricsLabels keeps the labels of the series exported for each rack, so that the series of the previous
+//action or operation of a rack are deleted when it changes
+var rackMetricsLabels = struct {
+	sync.Mutex
+	actions    map[string]prometheus.Labels
+	operations map[string]prometheus.Labels
+}{actions: map[string]prometheus.Labels{}, operations: map[string]prometheus.Labels{}}
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, reconcileErrors, rackActionStartTime,
+		rackPodOperationStartTime, podOperations, podOperationDuration, jolokiaDuration, jolokiaFailures,
+		refusedChanges)
+}
+
+//observeReconcile records the duration of a reconcile of a cluster started at start and its error
+func observeReconcile(namespace, name string, start time.Time, err error) {
+	reconcileDuration.WithLabelValues(namespace, name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(namespace, name).Inc()
+	}
+}
+
+//observePodOperation records a pod operation started at start and its result
+func observePodOperation(cc *api.CassandraCluster, operationName string, start time.Time, err error) {
+	result := api.StatusDone
+	if err != nil {
+		result = api.StatusError
+	}
+	podOperations.WithLabelValues(cc.Namespace, cc.Name, operationName, result).Inc()
+	podOperationDuration.WithLabelValues(operationName, result).Observe(time.Since(start).Seconds())
+}
+
+//observeJolokiaRequest records the duration of a Jolokia request sent to host of namespace and whether it failed,
+//host is <pod>.<cluster> as the subdomain of the pods is the headless Service of their cluster
+func observeJolokiaRequest(namespace, host, request string, start time.Time, failed bool) {
+	names := strings.SplitN(host, ".", 3)
+	pod, cluster := names[0], ""
+	if len(names) > 1 {
+		cluster = names[1]
+	}
+	jolokiaDuration.WithLabelValues(namespace, cluster, pod, request).Observe(time.Since(start).Seconds())
+	if failed {
+		jolokiaFailures.WithLabelValues(namespace, cluster, pod, request).Inc()
+	}
+}
+
+//updateRackMetrics exports the current action and the last pod operation of each rack of status
+func updateRackMetrics(cc *api.CassandraCluster, status *api.CassandraClusterStatus) {
+	rackMetricsLabels.Lock()
+	defer rackMetricsLabels.Unlock()
+
+	for dcRackName, dcRackStatus := range status.CassandraRackStatus {
+		if dcRackStatus == nil {
+			continue
+		}
+		key := cc.Namespace + "/" + cc.Name + "/" + dcRackName
+
+		lastAction := dcRackStatus.CassandraLastAction
+		setRackGauge(rackActionStartTime, rackMetricsLabels.actions, key, prometheus.Labels{
+			"namespace": cc.Namespace, "cluster": cc.Name, "dc_rack": dcRackName,
+			"action": lastAction.Name, "status": lastAction.Status}, lastAction.StartTime)
+
+		podLastOperation := dcRackStatus.PodLastOperation
+		if podLastOperation.Name == "" {
+			continue
+		}
+		setRackGauge(rackPodOperationStartTime, rackMetricsLabels.operations, key, prometheus.Labels{
+			"namespace": cc.Namespace, "cluster": cc.Name, "dc_rack": dcRackName,
+			"operation": podLastOperation.Name, "status": podLastOperation.Status}, podLastOperation.StartTime)
+	}
+}
+
+//setRackGauge sets the series of gauge with labels to startTime, deleting the series previously exported for the
+//rack identified by key
+func setRackGauge(gauge *prometheus.GaugeVec, exported map[string]prometheus.Labels, key string,
+	labels prometheus.Labels, startTime *metav1.Time) {
+	if previous, ok := exported[key]; ok && !labelsEqual(previous, labels) {
+		gauge.Delete(previous)
+	}
+	exported[key] = labels
+	var value float64
+	if startTime != nil {
+		value = float64(startTime.Unix())
+	}
+	gauge.With(labels).Set(value)
+}
+
+//deleteClusterMetrics deletes the series exported for the racks of a deleted cluster
+func deleteClusterMetrics(namespace, name string) {
+	rackMetricsLabels.Lock()
+	defer rackMetricsLabels.Unlock()
+
+	prefix := namespace + "/" + name + "/"
+	for _, m := range []struct {
+		gauge    *prometheus.GaugeVec
+		exported map[string]prometheus.Labels
+	}{{rackActionStartTime, rackMetricsLabels.actions}, {rackPodOperationStartTime, rackMetricsLabels.operations}} {
+		for key, labels := range m.exported {
+			if strings.HasPrefix(key, prefix) {
+				m.gauge.Delete(labels)
+				delete(m.exported, key)
+			}
+		}
+	}
+	reconcileDuration.DeleteLabelValues(namespace, name)
+	reconcileErrors.DeleteLabelValues(namespace, name)
+}
+
+func labelsEqual(a, b prometheus.Labels) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+	return true
+}
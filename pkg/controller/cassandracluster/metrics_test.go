@@ -0,0 +1,112 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestUpdateRackMetrics(t *testing.T) {
+	assert := assert.New(t)
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+
+	startTime := metav1.NewTime(time.Unix(1566000000, 0))
+	lastAction := &status.CassandraRackStatus["dc1-rack1"].CassandraLastAction
+	lastAction.Name = api.ActionScaleDown
+	lastAction.Status = api.StatusOngoing
+	lastAction.StartTime = &startTime
+	podLastOperation := &status.CassandraRackStatus["dc1-rack1"].PodLastOperation
+	podLastOperation.Name = api.OperationDecommission
+	podLastOperation.Status = api.StatusOngoing
+	podLastOperation.StartTime = &startTime
+	updateRackMetrics(cc, status)
+
+	scaleDownLabels := prometheus.Labels{"namespace": cc.Namespace, "cluster": cc.Name, "dc_rack": "dc1-rack1",
+		"action": api.ActionScaleDown, "status": api.StatusOngoing}
+	assert.Equal(float64(1566000000), testutil.ToFloat64(rackActionStartTime.With(scaleDownLabels)))
+	decommissionLabels := prometheus.Labels{"namespace": cc.Namespace, "cluster": cc.Name, "dc_rack": "dc1-rack1",
+		"operation": api.OperationDecommission, "status": api.StatusOngoing}
+	assert.Equal(float64(1566000000), testutil.ToFloat64(rackPodOperationStartTime.With(decommissionLabels)))
+
+	//The series of the previous action of the rack is replaced
+	lastAction.Status = api.StatusDone
+	updateRackMetrics(cc, status)
+	assert.False(rackActionStartTime.Delete(scaleDownLabels))
+	scaleDownLabels["status"] = api.StatusDone
+	assert.Equal(float64(1566000000), testutil.ToFloat64(rackActionStartTime.With(scaleDownLabels)))
+
+	deleteClusterMetrics(cc.Namespace, cc.Name)
+	assert.False(rackActionStartTime.Delete(scaleDownLabels))
+	assert.False(rackPodOperationStartTime.Delete(decommissionLabels))
+}
+
+func TestObserveJolokiaRequest(t *testing.T) {
+	assert := assert.New(t)
+	host := "cassandra-demo-dc1-rack1-0.cassandra-demo"
+
+	observeJolokiaRequest("ns", host, "OperationMode", time.Now(), false)
+	assert.Equal(float64(0), testutil.ToFloat64(jolokiaFailures.WithLabelValues("ns", "cassandra-demo",
+		"cassandra-demo-dc1-rack1-0", "OperationMode")))
+
+	observeJolokiaRequest("ns", host, "OperationMode", time.Now(), true)
+	assert.Equal(float64(1), testutil.ToFloat64(jolokiaFailures.WithLabelValues("ns", "cassandra-demo",
+		"cassandra-demo-dc1-rack1-0", "OperationMode")))
+}
+
+func TestObservePodOperation(t *testing.T) {
+	assert := assert.New(t)
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+
+	observePodOperation(cc, api.OperationCleanup, time.Now(), nil)
+	observePodOperation(cc, api.OperationCleanup, time.Now(), errors.New("Jolokia call failed"))
+	observePodOperation(cc, api.OperationCleanup, time.Now(), errors.New("Jolokia call failed"))
+	assert.Equal(float64(1), testutil.ToFloat64(podOperations.WithLabelValues(cc.Namespace, cc.Name,
+		api.OperationCleanup, api.StatusDone)))
+	assert.Equal(float64(2), testutil.ToFloat64(podOperations.WithLabelValues(cc.Namespace, cc.Name,
+		api.OperationCleanup, api.StatusError)))
+}
+
+func TestObserveResumedPodOperation(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	cc.Name = "cassandra-resumed"
+	defer deleteFinalizedOperations(types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", JolokiaURL(host, JolokiaPort),
+		httpmock.NewStringResponder(200, `{"request": {"mbean": "org.apache.cassandra.db:type=CompactionManager",
+			"attribute": "Compactions", "type": "read"}, "value": [], "timestamp": 1528850319, "status": 200}`))
+
+	//A cleanup started before a restart of the operator is recorded once it has ended
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cassandra-demo-dc1-rack1-0", Namespace: cc.Namespace}}
+	finalizedOperations(cc)
+	rcc.monitorOperation(host, cc, "dc1-rack1", pod, api.OperationCleanup, time.Now().Add(-time.Hour))
+	assert.Equal(float64(1), testutil.ToFloat64(podOperations.WithLabelValues(cc.Namespace, cc.Name,
+		api.OperationCleanup, api.StatusDone)))
+	assert.Len(finalizedOperations(cc), 1)
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"time"
 
 	"context"
 
@@ -27,34 +28,65 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var localSystemKeyspaces = []string{"system", "system_schema"}
 
+/*Status of a repair returned by getParentRepairStatus*/
+const (
+	repairInProgress = "IN_PROGRESS"
+	repairCompleted  = "COMPLETED"
+	repairFailed     = "FAILED"
+)
+
 /*JolokiaURL returns the url used to connect to a Jolokia server based on a host and a port*/
 func JolokiaURL(host string, port int) string {
 	return fmt.Sprintf("http://%s:%d/jolokia/", host, port)
 }
 
-// JolokiaClient is a structure that exposes a host and a jolokia client
+// JolokiaClient is a structure that exposes a host, its namespace and a jolokia client
 type JolokiaClient struct {
-	client *go_jolokia.JolokiaClient
-	host   string
+	client    *go_jolokia.JolokiaClient
+	host      string
+	namespace string
 }
 
-func (jolokiaClient *JolokiaClient) executeReadRequest(jolokiaRequest *go_jolokia.JolokiaRequest) (*go_jolokia.JolokiaReadResponse, error) {
-	return (*go_jolokia.JolokiaClient)(jolokiaClient.client).ExecuteReadRequest(jolokiaRequest)
+//executeReadRequest reads the attribute of mBean and records the duration of the request in the metrics
+func (jolokiaClient *JolokiaClient) executeReadRequest(mBean, attribute string) (*go_jolokia.JolokiaReadResponse, error) {
+	start := time.Now()
+	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, mBean, nil, attribute)
+	resp, err := (*go_jolokia.JolokiaClient)(jolokiaClient.client).ExecuteReadRequest(request)
+	observeJolokiaRequest(jolokiaClient.namespace, jolokiaClient.host, attribute, start,
+		err != nil || resp.Error != "")
+	return resp, err
 }
 
+//executeOperation executes the operation of mBean and records the duration of the request in the metrics
 func (jolokiaClient *JolokiaClient) executeOperation(mBean, operation string,
 	arguments interface{}, pattern string) (*go_jolokia.JolokiaReadResponse, error) {
-	return (*go_jolokia.JolokiaClient)(jolokiaClient.client).ExecuteOperation(mBean, operation, arguments, pattern)
+	start := time.Now()
+	resp, err := (*go_jolokia.JolokiaClient)(jolokiaClient.client).ExecuteOperation(mBean, operation, arguments, pattern)
+	observeJolokiaRequest(jolokiaClient.namespace, jolokiaClient.host, operation, start,
+		err != nil || resp.Error != "")
+	return resp, err
 }
 
 /*NewJolokiaClient returns a new Joloka client for the host name and port provided*/
 func NewJolokiaClient(host string, port int, rcc *ReconcileCassandraCluster,
 	secretRef v1.LocalObjectReference, namespace string) (*JolokiaClient, error) {
-	jolokiaClient := JolokiaClient{go_jolokia.NewJolokiaClient(JolokiaURL(host, port)), host}
+	var k8sClient client.Client
+	if rcc != nil {
+		k8sClient = rcc.client
+	}
+	return NewJolokiaClientWithK8sClient(host, port, k8sClient, secretRef, namespace)
+}
+
+/*NewJolokiaClientWithK8sClient returns a new Joloka client for the host name and port provided,
+the kubernetes client is used to read the credentials from secretRef*/
+func NewJolokiaClientWithK8sClient(host string, port int, k8sClient client.Client,
+	secretRef v1.LocalObjectReference, namespace string) (*JolokiaClient, error) {
+	jolokiaClient := JolokiaClient{go_jolokia.NewJolokiaClient(JolokiaURL(host, port)), host, namespace}
 	logrus.WithFields(logrus.Fields{"host": host, "port": port,
 		"secretRef": secretRef, "namespace": namespace}).Debug("Creating Jolokia connection")
 	if (secretRef != v1.LocalObjectReference{}) {
@@ -70,7 +102,7 @@ func NewJolokiaClient(host string, port int, rcc *ReconcileCassandraCluster,
 				Namespace: namespace,
 			},
 		}
-		err := rcc.client.Get(context.TODO(), types.NamespacedName{Name: secretRef.Name, Namespace: namespace}, secret)
+		err := k8sClient.Get(context.TODO(), types.NamespacedName{Name: secretRef.Name, Namespace: namespace}, secret)
 
 		if err != nil {
 			logrus.WithFields(logrus.Fields{"host": host, "port": port,
@@ -93,8 +125,7 @@ func checkJolokiaErrors(resp *go_jolokia.JolokiaReadResponse, err error) (*go_jo
 }
 
 func (jolokiaClient *JolokiaClient) leavingNodes() ([]string, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.db:type=StorageService", nil, "LeavingNodes")
-	result, err := checkJolokiaErrors(jolokiaClient.client.ExecuteReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.db:type=StorageService", "LeavingNodes"))
 	if err != nil {
 		return nil, fmt.Errorf("Cannot get list of leaving nodes: %v", err.Error())
 	}
@@ -114,8 +145,7 @@ func (jolokiaClient *JolokiaClient) leavingNodes() ([]string, error) {
 }
 
 func (jolokiaClient *JolokiaClient) hostIDMap() (map[string]string, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.db:type=StorageService", nil, "HostIdMap")
-	result, err := checkJolokiaErrors(jolokiaClient.client.ExecuteReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.db:type=StorageService", "HostIdMap"))
 	if err != nil {
 		return nil, fmt.Errorf("Cannot get host id map: %v", err.Error())
 	}
@@ -134,8 +164,7 @@ func (jolokiaClient *JolokiaClient) hostIDMap() (map[string]string, error) {
 }
 
 func (jolokiaClient *JolokiaClient) keyspaces() ([]string, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.db:type=StorageService", nil, "Keyspaces")
-	result, err := checkJolokiaErrors(jolokiaClient.client.ExecuteReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.db:type=StorageService", "Keyspaces"))
 	if err != nil {
 		return nil, fmt.Errorf("Cannot get list of keyspaces: %v", err.Error())
 	}
@@ -250,10 +279,111 @@ func (jolokiaClient *JolokiaClient) NodeRemove(hostid string) error {
 	return nil
 }
 
+/*NodeSnapshot takes a snapshot named tag of keyspaces (all keyspaces if empty) on the pod using a jolokia client
+and returns any error*/
+func (jolokiaClient *JolokiaClient) NodeSnapshot(tag string, keyspaces []string) error {
+	logrus.Infof("[%s]: Snapshot %s of keyspaces %v", jolokiaClient.host, tag, keyspaces)
+	// A nil slice would be sent as null, Cassandra expects an empty array for all keyspaces
+	if keyspaces == nil {
+		keyspaces = []string{}
+	}
+	_, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"takeSnapshot(java.lang.String,[Ljava.lang.String;)",
+		[]interface{}{tag, keyspaces}, ""))
+	if err != nil {
+		return fmt.Errorf("Cannot take snapshot %s: %v", tag, err.Error())
+	}
+	return nil
+}
+
+/*NodeClearSnapshot removes the snapshot named tag of keyspaces (all keyspaces if empty) on the pod using a
+jolokia client and returns any error*/
+func (jolokiaClient *JolokiaClient) NodeClearSnapshot(tag string, keyspaces []string) error {
+	logrus.Infof("[%s]: Clear snapshot %s of keyspaces %v", jolokiaClient.host, tag, keyspaces)
+	// A nil slice would be sent as null, Cassandra expects an empty array for all keyspaces
+	if keyspaces == nil {
+		keyspaces = []string{}
+	}
+	_, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"clearSnapshot(java.lang.String,[Ljava.lang.String;)",
+		[]interface{}{tag, keyspaces}, ""))
+	if err != nil {
+		return fmt.Errorf("Cannot clear snapshot %s: %v", tag, err.Error())
+	}
+	return nil
+}
+
+/*NodeRepairAsync starts a repair of the primary ranges of a keyspace, restricted to the datacenter dc if not empty,
+using a jolokia client and returns the number of the repair command and any error*/
+func (jolokiaClient *JolokiaClient) NodeRepairAsync(keyspace, dc string) (int, error) {
+	options := map[string]string{"primaryRange": "true"}
+	if dc != "" {
+		options["dataCenters"] = dc
+	}
+	logrus.Infof("[%s]: Repair of keyspace %s with options %v", jolokiaClient.host, keyspace, options)
+	result, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"repairAsync(java.lang.String,java.util.Map)",
+		[]interface{}{keyspace, options}, ""))
+	if err != nil {
+		return 0, fmt.Errorf("Cannot repair keyspace %s: %v", keyspace, err.Error())
+	}
+	command, isNumber := result.Value.(float64)
+	if !isNumber {
+		return 0, fmt.Errorf("Value returned by Jolokia is not a number: %v", result.Value)
+	}
+	return int(command), nil
+}
+
+/*NodeRepairStatus returns the status of a repair command (IN_PROGRESS, COMPLETED or FAILED, empty if the command is
+unknown) and its messages using a jolokia client and returns any error*/
+func (jolokiaClient *JolokiaClient) NodeRepairStatus(command int) (string, []string, error) {
+	result, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"getParentRepairStatus(int)",
+		[]interface{}{command}, ""))
+	if err != nil {
+		return "", nil, fmt.Errorf("Cannot get status of repair %d: %v", command, err.Error())
+	}
+	v, _ := result.Value.([]interface{})
+	values := []string{}
+	for _, value := range v {
+		if str, isString := value.(string); isString {
+			values = append(values, str)
+		}
+	}
+	if len(values) == 0 {
+		return "", nil, nil
+	}
+	return values[0], values[1:], nil
+}
+
+/*NodeTruncate removes all the data of a table on the whole cluster using a jolokia client and returns any error*/
+func (jolokiaClient *JolokiaClient) NodeTruncate(keyspace, table string) error {
+	logrus.Infof("[%s]: Truncate %s.%s", jolokiaClient.host, keyspace, table)
+	_, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"truncate(java.lang.String,java.lang.String)",
+		[]interface{}{keyspace, table}, ""))
+	if err != nil {
+		return fmt.Errorf("Cannot truncate %s.%s: %v", keyspace, table, err.Error())
+	}
+	return nil
+}
+
+/*NodeLoadNewSSTables loads the sstables copied in the directory of a table on the pod using a jolokia client
+and returns any error*/
+func (jolokiaClient *JolokiaClient) NodeLoadNewSSTables(keyspace, table string) error {
+	logrus.Infof("[%s]: Load new sstables of %s.%s", jolokiaClient.host, keyspace, table)
+	_, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
+		"loadNewSSTables(java.lang.String,java.lang.String)",
+		[]interface{}{keyspace, table}, ""))
+	if err != nil {
+		return fmt.Errorf("Cannot load new sstables of %s.%s: %v", keyspace, table, err.Error())
+	}
+	return nil
+}
+
 /*NodeOperationMode returns OperationMode of a node using a jolokia client and returns any error*/
 func (jolokiaClient *JolokiaClient) NodeOperationMode() (string, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.db:type=StorageService", nil, "OperationMode")
-	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.db:type=StorageService", "OperationMode"))
 	if err != nil {
 		return "", fmt.Errorf("Cannot get OperationMode: %v", err.Error())
 	}
@@ -262,8 +392,7 @@ func (jolokiaClient *JolokiaClient) NodeOperationMode() (string, error) {
 }
 
 func (jolokiaClient *JolokiaClient) hasStreamingSessions() (bool, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.net:type=StreamManager", nil, "CurrentStreams")
-	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.net:type=StreamManager", "CurrentStreams"))
 	if err != nil {
 		return true, fmt.Errorf("Cannot get list of current streams: %v", err.Error())
 	}
@@ -272,8 +401,7 @@ func (jolokiaClient *JolokiaClient) hasStreamingSessions() (bool, error) {
 }
 
 func (jolokiaClient *JolokiaClient) hasCompactions(name string) (bool, error) {
-	request := go_jolokia.NewJolokiaRequest(go_jolokia.READ, "org.apache.cassandra.db:type=CompactionManager", nil, "Compactions")
-	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest(request))
+	result, err := checkJolokiaErrors(jolokiaClient.executeReadRequest("org.apache.cassandra.db:type=CompactionManager", "Compactions"))
 	if err != nil {
 		logrus.Error(err.Error())
 		return true, fmt.Errorf("Cannot get list of current compactions: %v", err.Error())
@@ -306,35 +434,45 @@ func (jolokiaClient *JolokiaClient) hasLeavingNodes() (bool, error) {
 
 /*HasDataInDC checks partition ranges of all non local keyspaces and ensure no data is replicated to the chosen datacenter*/
 func (jolokiaClient *JolokiaClient) HasDataInDC(dc string) ([]string, error) {
+	return jolokiaClient.keyspacesWithData(regexp.MustCompile(fmt.Sprintf("datacenter:%s", dc)))
+}
+
+/*HasDataInRack checks partition ranges of all non local keyspaces and ensure no data is replicated to the chosen rack
+of the datacenter*/
+func (jolokiaClient *JolokiaClient) HasDataInRack(dc, rack string) ([]string, error) {
+	return jolokiaClient.keyspacesWithData(regexp.MustCompile(fmt.Sprintf("datacenter:%s, rack:%s\\)", dc, rack)))
+}
+
+//keyspacesWithData returns the non local keyspaces having token ranges replicated to the endpoints matching regexEndpoint
+func (jolokiaClient *JolokiaClient) keyspacesWithData(regexEndpoint *regexp.Regexp) ([]string, error) {
 	keyspaces, err := jolokiaClient.nonLocalKeyspaces()
-	keyspacesWithDataInDC := []string{}
+	keyspacesWithData := []string{}
 	if err != nil {
 		return nil, err
 	}
 	for _, keyspace := range keyspaces {
-		dataFound, err := jolokiaClient.hasKeyspaceDataInDC(keyspace, dc)
+		dataFound, err := jolokiaClient.hasKeyspaceData(keyspace, regexEndpoint)
 		// Returns if there is an error
 		if err != nil {
 			return nil, err
 		}
 		if dataFound {
-			keyspacesWithDataInDC = append(keyspacesWithDataInDC, keyspace)
+			keyspacesWithData = append(keyspacesWithData, keyspace)
 		}
 	}
-	return keyspacesWithDataInDC, nil
+	return keyspacesWithData, nil
 }
 
-func (jolokiaClient *JolokiaClient) hasKeyspaceDataInDC(keyspace, dc string) (bool, error) {
+func (jolokiaClient *JolokiaClient) hasKeyspaceData(keyspace string, regexEndpoint *regexp.Regexp) (bool, error) {
 	result, err := checkJolokiaErrors(jolokiaClient.executeOperation("org.apache.cassandra.db:type=StorageService",
 		"describeRingJMX", []interface{}{keyspace}, ""))
 	if err != nil {
 		return false, fmt.Errorf("Cannot describe ring using keyspace %s: %v", keyspace, err.Error())
 	}
-	regexDc := regexp.MustCompile(fmt.Sprintf("datacenter:%s", dc))
 	tokenRanges, _ := result.Value.([]interface{})
 	for _, tokenRange := range tokenRanges {
-		// Returns true as soon as we find one token range that is replicated to the chosen datacenter
-		if regexDc.MatchString(tokenRange.(string)) {
+		// Returns true as soon as we find one token range that is replicated to the chosen endpoints
+		if regexEndpoint.MatchString(tokenRange.(string)) {
 			return true, nil
 		}
 	}
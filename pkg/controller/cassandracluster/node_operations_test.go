@@ -21,9 +21,12 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var allKeyspaces = []string{"system", "system_auth", "system_schema", "demo1", "demo2"}
@@ -411,6 +414,49 @@ func TestReplicateData(t *testing.T) {
 
 }
 
+//describeRingResponder answers the keyspaces in keyspaces and a token range of each of them replicated to the endpoint
+//described by endpointDetails
+func describeRingResponder(t *testing.T, keyspaces, endpointDetails string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		var execrequestdata execRequestData
+		if err := json.NewDecoder(req.Body).Decode(&execrequestdata); err != nil {
+			t.Error("Can't decode request received")
+		}
+		if execrequestdata.Attribute == "Keyspaces" {
+			return httpmock.NewStringResponse(200, fmt.Sprintf(KeyspacesJolokiaQueryP, keyspaces)), nil
+		}
+		return httpmock.NewStringResponse(200, fmt.Sprintf(`{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+						  "arguments": ["%s"],
+						  "type": "exec",
+						  "operation": "describeRingJMX"},
+				      "timestamp": 1541908753,
+				      "status": 200,
+				      "value": ["TokenRange(start_token:4572538884437204647, end_token:4764428918503636065, endpoints:[10.244.3.8], rpc_endpoints:[10.244.3.8], endpoint_details:[EndpointDetails(host:10.244.3.8, %s)])"]}`,
+			execrequestdata.Arguments[0], endpointDetails)), nil
+	}
+}
+
+func TestHasDataInRack(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		describeRingResponder(t, `"system","demo1"`, "datacenter:dc1, rack:rack1"))
+
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+
+	keyspacesWithData, err := jolokiaClient.HasDataInRack("dc1", "rack1")
+	assert.Nil(err)
+	assert.Equal([]string{"demo1"}, keyspacesWithData)
+
+	for _, rackName := range []string{"rack2", "rack"} {
+		keyspacesWithData, err = jolokiaClient.HasDataInRack("dc1", rackName)
+		assert.Nil(err)
+		assert.Empty(keyspacesWithData)
+	}
+}
+
 func TestNodeDecommission(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -495,3 +541,274 @@ func TestHostIDMap(t *testing.T) {
 		t.Errorf("hostIDMap returned a bad answer: %s", hostIDMap)
 	}
 }
+
+func TestNodeSnapshot(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var operation string
+	var arguments []interface{}
+
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		func(req *http.Request) (*http.Response, error) {
+			var execrequestdata struct {
+				Operation string        `json:"operation"`
+				Arguments []interface{} `json:"arguments"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&execrequestdata); err != nil {
+				t.Error("Can't decode request received")
+			}
+			operation = execrequestdata.Operation
+			arguments = execrequestdata.Arguments
+			return httpmock.NewStringResponse(200, `{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+						  "type": "exec"},
+				      "value": null,
+				      "timestamp": 1528850319,
+				      "status": 200}`), nil
+		},
+	)
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+
+	if err := jolokiaClient.NodeSnapshot("backup1", []string{"demo1"}); err != nil {
+		t.Errorf("NodeSnapshot failed with : %v", err)
+	}
+	if operation != "takeSnapshot(java.lang.String,[Ljava.lang.String;)" ||
+		!reflect.DeepEqual(arguments, []interface{}{"backup1", []interface{}{"demo1"}}) {
+		t.Errorf("NodeSnapshot sent a bad request: %s %v", operation, arguments)
+	}
+
+	if err := jolokiaClient.NodeClearSnapshot("backup1", []string{"demo1"}); err != nil {
+		t.Errorf("NodeClearSnapshot failed with : %v", err)
+	}
+	if operation != "clearSnapshot(java.lang.String,[Ljava.lang.String;)" {
+		t.Errorf("NodeClearSnapshot sent a bad request: %s", operation)
+	}
+
+	// Keyspaces unset means all keyspaces, they are sent as an empty array
+	if err := jolokiaClient.NodeSnapshot("backup1", nil); err != nil {
+		t.Errorf("NodeSnapshot failed with : %v", err)
+	}
+	if !reflect.DeepEqual(arguments, []interface{}{"backup1", []interface{}{}}) {
+		t.Errorf("NodeSnapshot sent a bad request: %s %v", operation, arguments)
+	}
+
+	if err := jolokiaClient.NodeClearSnapshot("backup1", nil); err != nil {
+		t.Errorf("NodeClearSnapshot failed with : %v", err)
+	}
+	if !reflect.DeepEqual(arguments, []interface{}{"backup1", []interface{}{}}) {
+		t.Errorf("NodeClearSnapshot sent a bad request: %s %v", operation, arguments)
+	}
+}
+
+func TestNodeSnapshotError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		httpmock.NewStringResponder(200, `{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+							       "type": "exec"},
+						   "error": "java.io.IOException : Snapshot backup1 already exists.",
+						   "status": 500}`))
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+	if err := jolokiaClient.NodeSnapshot("backup1", []string{}); err == nil {
+		t.Errorf("NodeSnapshot should have failed")
+	}
+}
+
+func TestNodeTruncateAndLoadNewSSTables(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var operations []string
+	var arguments []interface{}
+
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		func(req *http.Request) (*http.Response, error) {
+			var execrequestdata struct {
+				Operation string        `json:"operation"`
+				Arguments []interface{} `json:"arguments"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&execrequestdata); err != nil {
+				t.Error("Can't decode request received")
+			}
+			operations = append(operations, execrequestdata.Operation)
+			arguments = execrequestdata.Arguments
+			return httpmock.NewStringResponse(200, `{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+						  "type": "exec"},
+				      "value": null,
+				      "timestamp": 1528850319,
+				      "status": 200}`), nil
+		},
+	)
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+
+	if err := jolokiaClient.NodeTruncate("demo1", "users"); err != nil {
+		t.Errorf("NodeTruncate failed with : %v", err)
+	}
+	if err := jolokiaClient.NodeLoadNewSSTables("demo1", "users"); err != nil {
+		t.Errorf("NodeLoadNewSSTables failed with : %v", err)
+	}
+	if !reflect.DeepEqual(operations, []string{"truncate(java.lang.String,java.lang.String)",
+		"loadNewSSTables(java.lang.String,java.lang.String)"}) ||
+		!reflect.DeepEqual(arguments, []interface{}{"demo1", "users"}) {
+		t.Errorf("NodeTruncate or NodeLoadNewSSTables sent bad requests: %v %v", operations, arguments)
+	}
+}
+
+func TestNodeRepairAsync(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var operation string
+	var arguments []interface{}
+
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		func(req *http.Request) (*http.Response, error) {
+			var execrequestdata struct {
+				Operation string        `json:"operation"`
+				Arguments []interface{} `json:"arguments"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&execrequestdata); err != nil {
+				t.Error("Can't decode request received")
+			}
+			operation = execrequestdata.Operation
+			arguments = execrequestdata.Arguments
+			return httpmock.NewStringResponse(200, `{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+						  "type": "exec"},
+				      "value": 3,
+				      "timestamp": 1528850319,
+				      "status": 200}`), nil
+		},
+	)
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+
+	command, err := jolokiaClient.NodeRepairAsync("demo1", "dc2")
+	if err != nil {
+		t.Errorf("NodeRepairAsync failed with : %v", err)
+	}
+	if command != 3 {
+		t.Errorf("NodeRepairAsync returned command %d instead of 3", command)
+	}
+	if operation != "repairAsync(java.lang.String,java.util.Map)" ||
+		!reflect.DeepEqual(arguments, []interface{}{"demo1",
+			map[string]interface{}{"primaryRange": "true", "dataCenters": "dc2"}}) {
+		t.Errorf("NodeRepairAsync sent a bad request: %v %v", operation, arguments)
+	}
+}
+
+func TestNodeRepairStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var value string
+
+	httpmock.RegisterResponder("POST", JolokiaURL(host, port),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, fmt.Sprintf(`{"request": {"mbean": "org.apache.cassandra.db:type=StorageService",
+						  "arguments": [3], "type": "exec", "operation": "getParentRepairStatus(int)"},
+				      "value": %s,
+				      "timestamp": 1528850319,
+				      "status": 200}`, value)), nil
+		},
+	)
+	jolokiaClient, _ := NewJolokiaClient(host, JolokiaPort, nil,
+		v1.LocalObjectReference{}, "ns")
+
+	value = `["IN_PROGRESS"]`
+	status, messages, err := jolokiaClient.NodeRepairStatus(3)
+	if err != nil || status != repairInProgress || len(messages) != 0 {
+		t.Errorf("NodeRepairStatus returned %s %v %v", status, messages, err)
+	}
+	if running, _ := hasRunningRepair(jolokiaClient,
+		v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{repairCommandsAnnotation: "3"}}}); !running {
+		t.Errorf("hasRunningRepair should find repair 3 running")
+	}
+	if running, _ := hasRunningRepair(jolokiaClient, v1.Pod{}); running {
+		t.Errorf("hasRunningRepair should not find a repair without command")
+	}
+
+	if err := waitForRepair(jolokiaClient, 3, time.Millisecond); err == nil ||
+		err.Error() != "Repair command 3 is not completed after 1ms" {
+		t.Errorf("waitForRepair should fail when the repair is not completed in time: %v", err)
+	}
+
+	value = `["FAILED", "Some repair failed"]`
+	if err := waitForRepair(jolokiaClient, 3, time.Minute); err == nil || err.Error() != "Some repair failed" {
+		t.Errorf("waitForRepair should fail with the message of the repair: %v", err)
+	}
+
+	value = `["COMPLETED", "Repair completed successfully"]`
+	if err := waitForRepair(jolokiaClient, 3, time.Minute); err != nil {
+		t.Errorf("waitForRepair failed with : %v", err)
+	}
+	if running, _ := hasRunningRepair(jolokiaClient,
+		v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{repairCommandsAnnotation: "3"}}}); running {
+		t.Errorf("hasRunningRepair should not find completed repairs running")
+	}
+
+	value = `null`
+	if err := waitForRepair(jolokiaClient, 3, time.Minute); err == nil {
+		t.Errorf("waitForRepair should fail with an unknown command")
+	}
+}
+
+func TestPostRunRepair(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var status3 string
+	var repaired []string
+	httpmock.RegisterResponder("POST", JolokiaURL(host, JolokiaPort),
+		func(req *http.Request) (*http.Response, error) {
+			var execrequestdata struct {
+				Operation string        `json:"operation"`
+				Arguments []interface{} `json:"arguments"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&execrequestdata); err != nil {
+				t.Error("Can't decode request received")
+			}
+			value := `["COMPLETED"]`
+			switch {
+			case execrequestdata.Operation == "repairAsync(java.lang.String,java.util.Map)":
+				repaired = append(repaired, execrequestdata.Arguments[0].(string))
+				value = "4"
+			case execrequestdata.Arguments[0] == float64(3):
+				value = status3
+			}
+			return httpmock.NewStringResponse(200, fmt.Sprintf(`{"request": {"type": "exec"},
+				      "value": %s, "timestamp": 1528850319, "status": 200}`, value)), nil
+		},
+	)
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cassandra-demo-dc1-rack1-0", Namespace: cc.Namespace}}
+	pod.Spec.Hostname = "cassandra-0"
+	pod.Spec.Subdomain = "cassandra.cassie1"
+	rcc.CreatePod(pod)
+	setRepairState := func() v1.Pod {
+		assert.Nil(rcc.UpdatePodAnnotations(pod,
+			map[string]string{repairKeyspacesAnnotation: "demo1,demo2", repairCommandsAnnotation: "3"}))
+		stored, _ := rcc.GetPod(pod.Namespace, pod.Name)
+		return *stored
+	}
+
+	//The operator restarted while demo1 was repaired, the repair goes on with demo2
+	status3 = `["COMPLETED"]`
+	assert.Nil(rcc.postRunRepair(cc, "dc1-rack1", setRepairState()))
+	assert.Equal([]string{"demo2"}, repaired)
+	stored, _ := rcc.GetPod(pod.Namespace, pod.Name)
+	assert.Empty(stored.GetAnnotations()[repairKeyspacesAnnotation])
+	assert.Empty(stored.GetAnnotations()[repairCommandsAnnotation])
+
+	//A failed repair or a repair the node does not know, after a restart of the node, fail the operation
+	repaired = nil
+	status3 = `["FAILED", "Some repair failed"]`
+	assert.EqualError(rcc.postRunRepair(cc, "dc1-rack1", setRepairState()),
+		"Repair of keyspace demo1 failed: Some repair failed")
+	status3 = `null`
+	assert.EqualError(rcc.postRunRepair(cc, "dc1-rack1", setRepairState()),
+		"Repair of keyspace demo1 failed: Repair command 3 is unknown by the node")
+	assert.Empty(repaired)
+	stored, _ = rcc.GetPod(pod.Namespace, pod.Name)
+	assert.Empty(stored.GetAnnotations()[repairKeyspacesAnnotation])
+}
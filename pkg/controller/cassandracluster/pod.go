@@ -121,6 +121,27 @@ func (rcc *ReconcileCassandraCluster) UpdatePodLabel(pod *v1.Pod, label map[stri
 	return rcc.UpdatePod(podToUpdate)
 }
 
+//UpdatePodAnnotations sets annotations on the pod, the annotations with an empty value are removed
+func (rcc *ReconcileCassandraCluster) UpdatePodAnnotations(pod *v1.Pod, annotations map[string]string) error {
+	podToUpdate, err := rcc.GetPod(pod.Namespace, pod.Name)
+	if err != nil {
+		return err
+	}
+	newAnnotations := podToUpdate.GetAnnotations()
+	if newAnnotations == nil {
+		newAnnotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(newAnnotations, key)
+		} else {
+			newAnnotations[key] = value
+		}
+	}
+	podToUpdate.SetAnnotations(newAnnotations)
+	return rcc.UpdatePod(podToUpdate)
+}
+
 //hasUnschedulablePod goal is to detect if Pods are unschedulable
 // - for lake of resources cpu/memory
 // - with bad docker image (imagepullbackoff)
@@ -21,7 +21,9 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
 	"time"
 
@@ -44,23 +46,83 @@ type finalizedOp struct {
 
 type op struct {
 	Action     func(*ReconcileCassandraCluster, string, *api.CassandraCluster, string, v1.Pod) error
-	Monitor    func(*JolokiaClient) (bool, error)
+	Monitor    func(*JolokiaClient, v1.Pod) (bool, error)
 	PostAction func(*ReconcileCassandraCluster, *api.CassandraCluster, string, v1.Pod) error
 }
 
+//monitorNode returns a Monitor which only needs to ask the node if the operation is still running
+func monitorNode(monitor func(*JolokiaClient) (bool, error)) func(*JolokiaClient, v1.Pod) (bool, error) {
+	return func(jolokiaClient *JolokiaClient, pod v1.Pod) (bool, error) {
+		return monitor(jolokiaClient)
+	}
+}
+
 var podOperationMap = map[string]op{
-	api.OperationCleanup:         op{(*ReconcileCassandraCluster).runCleanup, (*JolokiaClient).hasCleanupCompactions, nil},
-	api.OperationRebuild:         op{(*ReconcileCassandraCluster).runRebuild, (*JolokiaClient).hasStreamingSessions, nil},
-	api.OperationUpgradeSSTables: op{(*ReconcileCassandraCluster).runUpgradeSSTables, (*JolokiaClient).hasUpgradeSSTablesCompactions, nil},
-	api.OperationRemove: op{(*ReconcileCassandraCluster).runRemove, (*JolokiaClient).hasLeavingNodes,
-		(*ReconcileCassandraCluster).postRunRemove}}
+	api.OperationCleanup:         op{(*ReconcileCassandraCluster).runCleanup, monitorNode((*JolokiaClient).hasCleanupCompactions), nil},
+	api.OperationRebuild:         op{(*ReconcileCassandraCluster).runRebuild, monitorNode((*JolokiaClient).hasStreamingSessions), nil},
+	api.OperationUpgradeSSTables: op{(*ReconcileCassandraCluster).runUpgradeSSTables, monitorNode((*JolokiaClient).hasUpgradeSSTablesCompactions), nil},
+	api.OperationRemove: op{(*ReconcileCassandraCluster).runRemove, monitorNode((*JolokiaClient).hasLeavingNodes),
+		(*ReconcileCassandraCluster).postRunRemove},
+	api.OperationRepair: op{(*ReconcileCassandraCluster).runRepair, hasRunningRepair,
+		(*ReconcileCassandraCluster).postRunRepair}}
+
+//repairCommandsAnnotation stores on the pod the number of the repair command in progress so that it can be monitored
+//if the operator restarts
+const repairCommandsAnnotation = "operation-repair-commands"
+
+//repairKeyspacesAnnotation stores on the pod the keyspaces left to repair, separated by commas, the first one being
+//repaired by the command in progress, so that the repair can be resumed if the operator restarts
+const repairKeyspacesAnnotation = "operation-repair-keyspaces"
+
+//repairTimeout is the time given to the repair of a keyspace to complete
+const repairTimeout = 24 * time.Hour
+
+//repairMaxErrors is the number of consecutive errors of Jolokia after which a repair is considered failed
+const repairMaxErrors = 30
 
 const breakResyncLoop bool = true
 const continueResyncLoop bool = false
 const monitorSleepDelay = 10 * time.Second
 const deletedPvcTimeout = 30 * time.Second
 
-var chanRunningOp = make(chan finalizedOp, 100)
+//finalizedOps holds for each cluster the channel of its pod operations which have ended, they are finalized by the
+//next reconcile of the cluster
+var finalizedOps = struct {
+	sync.Mutex
+	channels map[types.NamespacedName]chan finalizedOp
+}{channels: map[types.NamespacedName]chan finalizedOp{}}
+
+//finalizedOperations returns the channel of the pod operations of cc which have ended
+func finalizedOperations(cc *api.CassandraCluster) chan finalizedOp {
+	finalizedOps.Lock()
+	defer finalizedOps.Unlock()
+	key := types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name}
+	if _, ok := finalizedOps.channels[key]; !ok {
+		finalizedOps.channels[key] = make(chan finalizedOp, 100)
+	}
+	return finalizedOps.channels[key]
+}
+
+//sendFinalizedOperation hands op to the next reconcile of cc. The channel of cc is created by the reconcile before it
+//starts any operation, if it no longer exists cc has been deleted and op is dropped
+func sendFinalizedOperation(cc *api.CassandraCluster, op finalizedOp) {
+	finalizedOps.Lock()
+	channel, ok := finalizedOps.channels[types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name}]
+	finalizedOps.Unlock()
+	if !ok {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": op.dcRackName, "pod": op.pod.Name,
+			"operation": op.operationName}).Info("Cluster deleted, the result of the operation is dropped")
+		return
+	}
+	channel <- op
+}
+
+//deleteFinalizedOperations forgets the channel of the pod operations of a deleted cluster
+func deleteFinalizedOperations(cluster types.NamespacedName) {
+	finalizedOps.Lock()
+	defer finalizedOps.Unlock()
+	delete(finalizedOps.channels, cluster)
+}
 
 func randomPodOperationKey() string {
 	r := rand.Intn(len(podOperationMap))
@@ -142,6 +204,34 @@ func (rcc *ReconcileCassandraCluster) addPodOperationLabels(cc *api.CassandraClu
 	}
 }
 
+//podOperationLabels returns the labels scheduling the Pod operation operationName, which is run by the operator only
+//if AutoPilot is enabled
+func podOperationLabels(cc *api.CassandraCluster, operationName string) map[string]string {
+	labels := map[string]string{"operation-name": operationName}
+	if cc.Spec.AutoPilot {
+		labels["operation-status"] = api.StatusToDo
+	} else {
+		labels["operation-status"] = api.StatusManual
+	}
+	return labels
+}
+
+//addCleanupToOtherRacks schedules a cleanup on the pods of the racks of the DC dcName other than rackName
+func (rcc *ReconcileCassandraCluster) addCleanupToOtherRacks(cc *api.CassandraCluster, dcName string,
+	rackName string) {
+	labels := podOperationLabels(cc, api.OperationCleanup)
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		if cc.GetDCName(dc) != dcName {
+			continue
+		}
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			if otherRackName := cc.GetRackName(dc, rack); otherRackName != rackName {
+				rcc.addPodOperationLabels(cc, dcName, otherRackName, labels)
+			}
+		}
+	}
+}
+
 // initOperation finds pods waiting for operation to run
 func (rcc *ReconcileCassandraCluster) initOperation(cc *api.CassandraCluster, status *api.CassandraClusterStatus,
 	dcName, rackName, operationName string) []v1.Pod {
@@ -210,6 +300,8 @@ func (rcc *ReconcileCassandraCluster) startOperation(cc *api.CassandraCluster, s
 		return err
 	}
 
+	rcc.recordEvent(&pod, v1.EventTypeNormal, reasonPodOperationStarted, "Operation %s started", operationName)
+
 	podLastOperation := &status.CassandraRackStatus[dcRackName].PodLastOperation
 	podLastOperation.Pods = append(podLastOperation.Pods, pod.Name)
 	podLastOperation.PodsOK = k8s.RemoveString(podLastOperation.PodsOK, pod.Name)
@@ -236,7 +328,13 @@ func (rcc *ReconcileCassandraCluster) ensureOperation(cc *api.CassandraCluster,
 		hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
 		// We check if an operation is running
 		if checkOnly {
-			go rcc.monitorOperation(hostName, cc, dcRackName, pod, operationName)
+			// The operation may have been started before a restart of the operator, its duration is measured
+			// from the start time stored in the status
+			start := time.Now()
+			if startTime := status.CassandraRackStatus[dcRackName].PodLastOperation.StartTime; startTime != nil {
+				start = startTime.Time
+			}
+			go rcc.monitorOperation(hostName, cc, dcRackName, pod, operationName, start)
 			continue
 		}
 		err := rcc.startOperation(cc, status, pod, dcRackName, operationName)
@@ -253,7 +351,8 @@ func (rcc *ReconcileCassandraCluster) ensureOperation(cc *api.CassandraCluster,
 
 func (rcc *ReconcileCassandraCluster) finalizeOperations(cc *api.CassandraCluster) {
 	// Finalize all operations here to avoid update conflicts
-	for chanOp := 0; chanOp < len(chanRunningOp); chanOp++ {
+	chanRunningOp := finalizedOperations(cc)
+	for pending := len(chanRunningOp); pending > 0; pending-- {
 		op := <-chanRunningOp
 		rcc.finalizeOperation(op.err, cc, op.dcRackName, op.pod, &rcc.cc.Status,
 			strings.Title(op.operationName))
@@ -262,18 +361,21 @@ func (rcc *ReconcileCassandraCluster) finalizeOperations(cc *api.CassandraCluste
 
 func (rcc *ReconcileCassandraCluster) runOperation(operationName, hostName string, cc *api.CassandraCluster, dcRackName string, pod v1.Pod,
 	status *api.CassandraClusterStatus) {
+	start := time.Now()
 	err := podOperationMap[operationName].Action(rcc, hostName, cc, dcRackName, pod)
 
 	// If there is an error we finalize the operation but skip any existing post action
 	if err != nil {
-		chanRunningOp <- finalizedOp{err, dcRackName, pod, operationName}
+		observePodOperation(cc, operationName, start, err)
+		sendFinalizedOperation(cc, finalizedOp{err, dcRackName, pod, operationName})
 		return
 	}
 	postAction := podOperationMap[operationName].PostAction
 	if postAction != nil {
 		err = postAction(rcc, cc, dcRackName, pod)
 	}
-	chanRunningOp <- finalizedOp{err, dcRackName, pod, operationName}
+	observePodOperation(cc, operationName, start, err)
+	sendFinalizedOperation(cc, finalizedOp{err, dcRackName, pod, operationName})
 }
 
 /* ensureDecommission will ensure that the Last Pod of the StatefulSet will be decommissionned
@@ -596,6 +698,17 @@ func (rcc *ReconcileCassandraCluster) finalizeOperation(err error, cc *api.Cassa
 	ccRefreshed := cc.DeepCopy()
 
 	rcc.updatePodLastOperation(cc.Name, dcRackName, pod.Name, strings.Title(operationName), status, err)
+	if err != nil {
+		rcc.recordEvent(&pod, v1.EventTypeWarning, reasonPodOperationFailed, "Operation %s failed: %v",
+			operationName, err)
+		rcc.recordEvent(cc, v1.EventTypeWarning, reasonPodOperationFailed, "Operation %s failed on pod %s: %v",
+			operationName, pod.Name, err)
+	} else {
+		rcc.recordEvent(&pod, v1.EventTypeNormal, reasonPodOperationSucceeded, "Operation %s succeeded",
+			operationName)
+		rcc.recordEvent(cc, v1.EventTypeNormal, reasonPodOperationSucceeded, "Operation %s succeeded on pod %s",
+			operationName, pod.Name)
+	}
 
 	for {
 		if err = rcc.UpdatePodLabel(&pod, labels); err != nil {
@@ -620,7 +733,7 @@ func (rcc *ReconcileCassandraCluster) finalizeOperation(err error, cc *api.Cassa
 }
 
 func (rcc *ReconcileCassandraCluster) monitorOperation(hostName string, cc *api.CassandraCluster, dcRackName string,
-	pod v1.Pod, operationName string) {
+	pod v1.Pod, operationName string, start time.Time) {
 	// Wait until there are no more cleanup compactions
 	for {
 		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName,
@@ -628,7 +741,7 @@ func (rcc *ReconcileCassandraCluster) monitorOperation(hostName string, cc *api.
 		jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
 			cc.Spec.ImageJolokiaSecret, cc.Namespace)
 		if err == nil {
-			operationIsRunning, err := podOperationMap[operationName].Monitor(jolokiaClient)
+			operationIsRunning, err := podOperationMap[operationName].Monitor(jolokiaClient, pod)
 			// When there is an error it returns true to try again during the next loop
 			if err != nil {
 				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName,
@@ -646,7 +759,8 @@ func (rcc *ReconcileCassandraCluster) monitorOperation(hostName string, cc *api.
 	if postAction != nil {
 		err = postAction(rcc, cc, dcRackName, pod)
 	}
-	chanRunningOp <- finalizedOp{err, dcRackName, pod, operationName}
+	observePodOperation(cc, operationName, start, err)
+	sendFinalizedOperation(cc, finalizedOp{err, dcRackName, pod, operationName})
 }
 
 func (rcc *ReconcileCassandraCluster) runUpgradeSSTables(hostName string, cc *api.CassandraCluster, dcRackName string,
@@ -864,3 +978,140 @@ func (rcc *ReconcileCassandraCluster) runCleanup(hostName string, cc *api.Cassan
 	}
 	return err
 }
+
+func (rcc *ReconcileCassandraCluster) runRepair(hostName string, cc *api.CassandraCluster, dcRackName string, pod v1.Pod) error {
+	var dc = pod.GetLabels()["operation-argument"]
+	operation := strings.Title(api.OperationRepair)
+
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+		"hostName": hostName, "operation": operation}).Info("Operation start")
+
+	// The repair can be restricted to a datacenter given in operation-argument
+	if dc != "" && cc.IsValidDC(dc) == false {
+		return fmt.Errorf("%s is not an existing datacenter", dc)
+	}
+
+	jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
+		cc.Spec.ImageJolokiaSecret, cc.Namespace)
+	if err != nil {
+		return err
+	}
+	keyspaces, err := jolokiaClient.nonLocalKeyspaces()
+	if err != nil {
+		return err
+	}
+	return rcc.repairKeyspaces(jolokiaClient, cc, dcRackName, pod, dc, keyspaces, 0)
+}
+
+//postRunRepair ends the repair of a pod monitored after a restart of the operator, it fails if the stored repair
+//command failed or is unknown by the node and repairs the keyspaces the repair had not reached
+func (rcc *ReconcileCassandraCluster) postRunRepair(cc *api.CassandraCluster, dcRackName string, pod v1.Pod) error {
+	hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+	keyspaces := pod.GetAnnotations()[repairKeyspacesAnnotation]
+	//Without keyspaces stored we can't know how far the repair went, it is run again
+	if keyspaces == "" {
+		return rcc.runRepair(hostName, cc, dcRackName, pod)
+	}
+
+	jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
+		cc.Spec.ImageJolokiaSecret, cc.Namespace)
+	if err != nil {
+		return err
+	}
+	command, _ := strconv.Atoi(pod.GetAnnotations()[repairCommandsAnnotation])
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+		"keyspaces": keyspaces, "command": command, "operation": strings.Title(api.OperationRepair)}).Info(
+		"Resume the repair")
+	return rcc.repairKeyspaces(jolokiaClient, cc, dcRackName, pod, pod.GetLabels()["operation-argument"],
+		strings.Split(keyspaces, ","), command)
+}
+
+//repairKeyspaces repairs the keyspaces one after the other, command is the repair command already started for the
+//first keyspace or 0. The keyspaces left and the command in progress are stored on the pod until the repair ends
+func (rcc *ReconcileCassandraCluster) repairKeyspaces(jolokiaClient *JolokiaClient, cc *api.CassandraCluster,
+	dcRackName string, pod v1.Pod, dc string, keyspaces []string, command int) error {
+	operation := strings.Title(api.OperationRepair)
+
+	//The repair is not resumed anymore once it has ended
+	defer func() {
+		if err := rcc.UpdatePodAnnotations(&pod,
+			map[string]string{repairKeyspacesAnnotation: "", repairCommandsAnnotation: ""}); err != nil {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+				"err": err}).Warn("Can't remove repair state from pod")
+		}
+	}()
+
+	var err error
+	for i, keyspace := range keyspaces {
+		if i > 0 || command == 0 {
+			if err = rcc.UpdatePodAnnotations(&pod, map[string]string{
+				repairKeyspacesAnnotation: strings.Join(keyspaces[i:], ","), repairCommandsAnnotation: ""}); err != nil {
+				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+					"err": err}).Warn("Can't store repair state on pod")
+			}
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+				"keyspace": keyspace, "datacenter": dc, "operation": operation}).Info("Execute the Jolokia Operation")
+			if command, err = jolokiaClient.NodeRepairAsync(keyspace, dc); err != nil {
+				return err
+			}
+			// Cassandra returns 0 when there is nothing to repair
+			if command == 0 {
+				continue
+			}
+			if err = rcc.UpdatePodAnnotations(&pod,
+				map[string]string{repairCommandsAnnotation: strconv.Itoa(command)}); err != nil {
+				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName, "pod": pod.Name,
+					"err": err}).Warn("Can't store repair command on pod")
+			}
+		}
+		if err = waitForRepair(jolokiaClient, command, repairTimeout); err != nil {
+			return fmt.Errorf("Repair of keyspace %s failed: %v", keyspace, err)
+		}
+	}
+	return nil
+}
+
+//waitForRepair polls the status of a repair command until it is completed and returns an error if it failed, if it
+//is not completed before timeout or if Jolokia fails repairMaxErrors times in a row
+func waitForRepair(jolokiaClient *JolokiaClient, command int, timeout time.Duration) error {
+	nbErrors := 0
+	err := wait.PollImmediate(monitorSleepDelay, timeout, func() (bool, error) {
+		status, messages, err := jolokiaClient.NodeRepairStatus(command)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"host": jolokiaClient.host, "command": command,
+				"err": err}).Error("Got an error from Jolokia")
+			if nbErrors++; nbErrors >= repairMaxErrors {
+				return false, fmt.Errorf("Can't get the status of repair command %d: %v", command, err)
+			}
+			return false, nil
+		}
+		nbErrors = 0
+		switch status {
+		case repairCompleted:
+			return true, nil
+		case repairFailed:
+			return false, fmt.Errorf("%s", strings.Join(messages, ", "))
+		case "":
+			return false, fmt.Errorf("Repair command %d is unknown by the node", command)
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("Repair command %d is not completed after %v", command, timeout)
+	}
+	return err
+}
+
+//hasRunningRepair returns true if the repair command stored on the pod is still in progress, postRunRepair checks
+//how it ended
+func hasRunningRepair(jolokiaClient *JolokiaClient, pod v1.Pod) (bool, error) {
+	command, err := strconv.Atoi(pod.GetAnnotations()[repairCommandsAnnotation])
+	if err != nil {
+		return false, nil
+	}
+	status, _, err := jolokiaClient.NodeRepairStatus(command)
+	if err != nil {
+		return true, err
+	}
+	return status == repairInProgress, nil
+}
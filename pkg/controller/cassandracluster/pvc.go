@@ -16,13 +16,28 @@ package cassandracluster
 
 import (
 	"context"
+	"strings"
 
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+//Annotations of the default storage class
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
 )
 
 func (rcc *ReconcileCassandraCluster) GetPVC(namespace, name string) (*v1.PersistentVolumeClaim, error) {
@@ -60,3 +75,185 @@ func (rcc *ReconcileCassandraCluster) deletePVC(pvc *v1.PersistentVolumeClaim) e
 	return rcc.client.Delete(context.TODO(), pvc)
 
 }
+
+//storageClassAllowsExpansion returns true if the storage class, or the default one if name is empty, allows the
+//expansion of its volumes
+func (rcc *ReconcileCassandraCluster) storageClassAllowsExpansion(name string) bool {
+	storageClasses := &storagev1.StorageClassList{}
+	if err := rcc.client.List(context.TODO(), &client.ListOptions{}, storageClasses); err != nil {
+		logrus.Errorf("failed to list storage classes: %v", err)
+		return false
+	}
+	for _, storageClass := range storageClasses.Items {
+		if (name != "" && storageClass.Name == name) ||
+			(name == "" && (storageClass.Annotations[defaultStorageClassAnnotation] == "true" ||
+				storageClass.Annotations[betaDefaultStorageClassAnnotation] == "true")) {
+			return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion
+		}
+	}
+	return false
+}
+
+//expandableDCs returns the existing DCs whose DataCapacity grows from oldCRD while their storage class is unchanged
+//and allows volume expansion
+func (rcc *ReconcileCassandraCluster) expandableDCs(cc *api.CassandraCluster,
+	oldCRD *api.CassandraCluster) map[string]bool {
+	expandableDCs := map[string]bool{}
+	for _, dcName := range existingDCNames(cc, oldCRD) {
+		oldDataCapacity, err := resource.ParseQuantity(oldCRD.GetDataCapacityForDC(dcName))
+		if err != nil {
+			continue
+		}
+		dataCapacity, err := resource.ParseQuantity(cc.GetDataCapacityForDC(dcName))
+		if err != nil || dataCapacity.Cmp(oldDataCapacity) <= 0 {
+			continue
+		}
+		dataStorageClass := cc.GetDataStorageClassForDC(dcName)
+		if dataStorageClass != oldCRD.GetDataStorageClassForDC(dcName) {
+			continue
+		}
+		if rcc.storageClassAllowsExpansion(dataStorageClass) {
+			expandableDCs[dcName] = true
+		}
+	}
+	return expandableDCs
+}
+
+//pvcIsResizing returns true while the volume of the pvc or its file system is being resized
+func pvcIsResizing(pvc *v1.PersistentVolumeClaim) bool {
+	for _, condition := range pvc.Status.Conditions {
+		if (condition.Type == v1.PersistentVolumeClaimResizing ||
+			condition.Type == v1.PersistentVolumeClaimFileSystemResizePending) &&
+			condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+//expandDataPVCs expands the data PVCs of a rack to the DataCapacity of its DC. Once the volumes and their file
+//systems are resized, the statefulset is deleted without its pods and recreated from the stored one with the new
+//volumeClaimTemplates. It returns true when the statefulset has the new volumeClaimTemplates
+func (rcc *ReconcileCassandraCluster) expandDataPVCs(cc *api.CassandraCluster, dcName, rackName string,
+	storedStatefulSet *appsv1.StatefulSet, status *api.CassandraClusterStatus) bool {
+	dcRackName := cc.GetDCRackName(dcName, rackName)
+	lastAction := &status.CassandraRackStatus[dcRackName].CassandraLastAction
+	now := metav1.Now()
+
+	if lastAction.Status == api.StatusToDo {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Info("Start UpdateDataCapacity")
+		lastAction.Status = api.StatusOngoing
+		lastAction.StartTime = &now
+		lastAction.EndTime = nil
+	}
+
+	dataCapacity, err := resource.ParseQuantity(cc.GetDataCapacityForDC(dcName))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+			"Invalid DataCapacity %s: %v", cc.GetDataCapacityForDC(dcName), err)
+		return false
+	}
+
+	pvcs, err := rcc.ListPVC(cc.Namespace, k8s.LabelsForCassandraDCRack(cc, dcName, rackName))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+			"failed to list cassandra's PVCs: %v", err)
+		return false
+	}
+	resized := true
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !strings.HasPrefix(pvc.Name, "data-") {
+			continue
+		}
+		request := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		if request.Cmp(dataCapacity) < 0 {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Infof(
+				"Expand PVC %s from %s to %s", pvc.Name, request.String(), dataCapacity.String())
+			pvc.Spec.Resources.Requests[v1.ResourceStorage] = dataCapacity
+			if err = rcc.client.Update(context.TODO(), pvc); err != nil {
+				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+					"failed to expand PVC %s: %v", pvc.Name, err)
+			}
+			resized = false
+			continue
+		}
+		capacity := pvc.Status.Capacity[v1.ResourceStorage]
+		if capacity.Cmp(dataCapacity) < 0 || pvcIsResizing(pvc) {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Infof(
+				"Waiting for PVC %s to be resized to %s", pvc.Name, dataCapacity.String())
+			resized = false
+		}
+	}
+	if !resized {
+		return false
+	}
+
+	for _, volumeClaimTemplate := range storedStatefulSet.Spec.VolumeClaimTemplates {
+		if volumeClaimTemplate.Name != "data" {
+			continue
+		}
+		request := volumeClaimTemplate.Spec.Resources.Requests[v1.ResourceStorage]
+		if request.Cmp(dataCapacity) == 0 {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Info("UpdateDataCapacity is Done")
+			lastAction.Status = api.StatusDone
+			lastAction.EndTime = &now
+			return true
+		}
+	}
+
+	//The volumeClaimTemplates of a statefulset can't be updated, the statefulset is deleted without its pods, which
+	//keep running and are adopted by the statefulset recreated from the stored one so that its pod template is unchanged
+	if storedStatefulSet.DeletionTimestamp == nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Info(
+			"PVCs are resized, recreate the statefulset with the new volumeClaimTemplates")
+		err = rcc.client.Delete(context.TODO(), storedStatefulSet,
+			client.PropagationPolicy(metav1.DeletePropagationOrphan))
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+				"failed to delete statefulset %s: %v", storedStatefulSet.Name, err)
+			return false
+		}
+	}
+	if err = rcc.waitUntilStatefulSetIsDeleted(storedStatefulSet.Namespace, storedStatefulSet.Name); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+			"statefulset %s is not deleted yet: %v", storedStatefulSet.Name, err)
+		return false
+	}
+	if err = rcc.CreateStatefulSet(expandedStatefulSet(storedStatefulSet, dataCapacity)); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Errorf(
+			"failed to recreate statefulset %s: %v", storedStatefulSet.Name, err)
+	}
+	return false
+}
+
+//expandedStatefulSet returns a copy of storedStatefulSet to create, with dataCapacity in its data
+//volumeClaimTemplate
+func expandedStatefulSet(storedStatefulSet *appsv1.StatefulSet, dataCapacity resource.Quantity) *appsv1.StatefulSet {
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta: storedStatefulSet.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            storedStatefulSet.Name,
+			Namespace:       storedStatefulSet.Namespace,
+			Labels:          storedStatefulSet.Labels,
+			Annotations:     storedStatefulSet.Annotations,
+			OwnerReferences: storedStatefulSet.OwnerReferences,
+		},
+		Spec: *storedStatefulSet.Spec.DeepCopy(),
+	}
+	for i := range statefulSet.Spec.VolumeClaimTemplates {
+		volumeClaimTemplate := &statefulSet.Spec.VolumeClaimTemplates[i]
+		if volumeClaimTemplate.Name == "data" {
+			volumeClaimTemplate.Spec.Resources.Requests[v1.ResourceStorage] = dataCapacity
+		}
+	}
+	return statefulSet
+}
+
+//waitUntilStatefulSetIsDeleted waits for the orphan deletion of a statefulset to complete
+func (rcc *ReconcileCassandraCluster) waitUntilStatefulSetIsDeleted(namespace, name string) error {
+	return wait.Poll(retryInterval, deletedPvcTimeout, func() (bool, error) {
+		_, err := rcc.GetStatefulSet(namespace, name)
+		return apierrors.IsNotFound(err), nil
+	})
+}
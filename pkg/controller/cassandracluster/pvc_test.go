@@ -0,0 +1,151 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func helperCreateStorageClass(t *testing.T, rcc *ReconcileCassandraCluster, name string, allowVolumeExpansion bool,
+	annotations map[string]string) {
+	storageClass := &storagev1.StorageClass{
+		TypeMeta:             metav1.TypeMeta{Kind: "StorageClass", APIVersion: "storage.k8s.io/v1"},
+		ObjectMeta:           metav1.ObjectMeta{Name: name, Annotations: annotations},
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}
+	if err := rcc.client.Create(context.TODO(), storageClass); err != nil {
+		t.Fatalf("create storage class: (%v)", err)
+	}
+}
+
+func TestExpandableDCs(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//The storage class local-storage does not exist
+	cc.Spec.Topology.DC[1].DataCapacity = "10Gi"
+	assert.Empty(rcc.expandableDCs(cc, lastAppliedConfiguration(cc)))
+
+	helperCreateStorageClass(t, rcc, "local-storage", true, nil)
+	assert.Equal(map[string]bool{"dc2": true}, rcc.expandableDCs(cc, lastAppliedConfiguration(cc)))
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), rcc.expandableDCs(cc,
+		lastAppliedConfiguration(cc))))
+
+	//The volumes can't shrink
+	cc.Spec.Topology.DC[1].DataCapacity = "2Gi"
+	assert.Empty(rcc.expandableDCs(cc, lastAppliedConfiguration(cc)))
+	assert.NotNil(rcc.ValidateChanges(cc))
+
+	//The storage class can't change
+	cc.Spec.Topology.DC[1].DataCapacity = "10Gi"
+	cc.Spec.Topology.DC[1].DataStorageClass = "fast"
+	helperCreateStorageClass(t, rcc, "fast", true, nil)
+	assert.Empty(rcc.expandableDCs(cc, lastAppliedConfiguration(cc)))
+
+	//The default storage class is used if none is set
+	helperCreateStorageClass(t, rcc, "standard", false,
+		map[string]string{defaultStorageClassAnnotation: "true"})
+	assert.False(rcc.storageClassAllowsExpansion(""))
+	assert.True(rcc.storageClassAllowsExpansion("fast"))
+}
+
+func TestUpdateDataCapacity(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	helperCreateStorageClass(t, rcc, "local-storage", true, nil)
+
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 1, 0)
+	sts := generateCassandraStatefulSet(cc, status, "dc2", "dc2-rack1", labels, nodeSelector, nil)
+	if err := rcc.client.Create(context.TODO(), sts); err != nil {
+		t.Fatalf("create statefulset: (%v)", err)
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "data-" + sts.Name + "-0", Namespace: cc.Namespace,
+			Labels: k8s.LabelsForCassandraDCRack(cc, "dc2", "rack1")},
+		Spec: v1.PersistentVolumeClaimSpec{Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("3Gi")}}},
+		Status: v1.PersistentVolumeClaimStatus{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("3Gi")}},
+	}
+	if err := rcc.client.Create(context.TODO(), pvc); err != nil {
+		t.Fatalf("create pvc: (%v)", err)
+	}
+
+	//Growing the DataCapacity of dc2 is accepted, it is flagged on its rack once no other action is in progress
+	status.CassandraRackStatus["dc2-rack1"].CassandraLastAction.Name = api.ActionRollingRestart
+	status.CassandraRackStatus["dc2-rack1"].CassandraLastAction.Status = api.StatusOngoing
+	cc.Spec.Topology.DC[1].DataCapacity = "10Gi"
+	assert.Nil(rcc.ValidateChanges(cc))
+	assert.False(rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("10Gi", cc.Spec.Topology.DC[1].DataCapacity)
+	lastAction := &status.CassandraRackStatus["dc2-rack1"].CassandraLastAction
+	assert.Equal(api.ActionRollingRestart, lastAction.Name)
+	assert.Equal(api.StatusOngoing, lastAction.Status)
+
+	lastAction.Status = api.StatusDone
+	assert.True(UpdateStatusIfDataCapacityHasChanged(cc, "dc2-rack1", sts, status))
+	assert.Equal(api.ActionUpdateDataCapacity, lastAction.Name)
+	assert.Equal(api.StatusToDo, lastAction.Status)
+	dc1Sts := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, nil)
+	assert.False(UpdateStatusIfDataCapacityHasChanged(cc, "dc1-rack1", dc1Sts, status))
+	assert.NotEqual(api.ActionUpdateDataCapacity, status.CassandraRackStatus["dc1-rack1"].CassandraLastAction.Name)
+
+	//The PVC is expanded
+	assert.False(rcc.expandDataPVCs(cc, "dc2", "rack1", sts, status))
+	assert.Equal(api.StatusOngoing, lastAction.Status)
+	pvc, _ = rcc.GetPVC(cc.Namespace, pvc.Name)
+	request := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("10Gi", request.String())
+
+	//The operator waits for the volume and its file system to be resized
+	assert.False(rcc.expandDataPVCs(cc, "dc2", "rack1", sts, status))
+	pvc.Status.Capacity[v1.ResourceStorage] = resource.MustParse("10Gi")
+	pvc.Status.Conditions = []v1.PersistentVolumeClaimCondition{
+		{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue}}
+	rcc.client.Update(context.TODO(), pvc)
+	assert.False(rcc.expandDataPVCs(cc, "dc2", "rack1", sts, status))
+	_, err := rcc.GetStatefulSet(cc.Namespace, sts.Name)
+	assert.Nil(err)
+
+	//Once resized, the statefulset is recreated from the stored one with the new volumeClaimTemplates
+	sts.Spec.Template.Labels["rolling-restart"] = "20190101T000000"
+	pvc.Status.Conditions = nil
+	rcc.client.Update(context.TODO(), pvc)
+	assert.False(rcc.expandDataPVCs(cc, "dc2", "rack1", sts, status))
+	recreatedSts, err := rcc.GetStatefulSet(cc.Namespace, sts.Name)
+	assert.Nil(err)
+	assert.Equal(api.StatusOngoing, lastAction.Status)
+	assert.Equal("20190101T000000", recreatedSts.Spec.Template.Labels["rolling-restart"])
+	assert.Equal(sts.Spec.Template.Spec.Containers, recreatedSts.Spec.Template.Spec.Containers)
+	request = recreatedSts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[v1.ResourceStorage]
+	assert.Equal("10Gi", request.String())
+
+	assert.True(rcc.expandDataPVCs(cc, "dc2", "rack1", recreatedSts, status))
+	assert.Equal(api.StatusDone, lastAction.Status)
+}
@@ -79,65 +79,79 @@ func (rcc *ReconcileCassandraCluster) CheckDeletePVC(cc *api.CassandraCluster) e
 	return nil
 }
 
-// CheckNonAllowedChanges - checks if there are some changes on CRD that are not allowed on statefulset
-// If a non Allowed Changed is Find we won't Update associated kubernetes objects, but we will put back the old value
-// and Patch the CRD with correct values
-func (rcc *ReconcileCassandraCluster) CheckNonAllowedChanges(cc *api.CassandraCluster,
-	status *api.CassandraClusterStatus) bool {
+//lastAppliedConfiguration returns the CassandraCluster stored in the last-applied-configuration annotation of cc
+//It returns nil if there is no annotation or no changes since
+func lastAppliedConfiguration(cc *api.CassandraCluster) *api.CassandraCluster {
 	var oldCRD api.CassandraCluster
 	if cc.Annotations[api.AnnotationLastApplied] == "" {
-		return false
+		return nil
 	}
 
 	if lac, _ := cc.ComputeLastAppliedConfiguration(); string(lac) == cc.Annotations[api.AnnotationLastApplied] {
 		//there are no changes to take care about
-		return false
+		return nil
 	}
 
 	//We retrieved our last-applied-configuration stored in the CRD
 	err := json.Unmarshal([]byte(cc.Annotations[api.AnnotationLastApplied]), &oldCRD)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Error("Can't get Old version of CRD")
+		return nil
+	}
+	return &oldCRD
+}
+
+//ValidateChanges returns an error if the changes of cc since its last-applied-configuration are refused by the
+//Operator. It runs the checks used by CheckNonAllowedChanges to restore the old values
+func (rcc *ReconcileCassandraCluster) ValidateChanges(cc *api.CassandraCluster) error {
+	oldCRD := lastAppliedConfiguration(cc)
+	if oldCRD == nil {
+		return nil
+	}
+	if err := ValidateNonAllowedChanges(cc, oldCRD, rcc.expandableDCs(cc, oldCRD)); err != nil {
+		return err
+	}
+	if err := ValidateTopologyChanges(cc, oldCRD); err != nil {
+		return fmt.Errorf(topologyChangeRefused+"%v", err)
+	}
+	//Nodes are asked only if the topology has changed
+	if reflect.DeepEqual(cc.Spec.Topology, oldCRD.Spec.Topology) {
+		return nil
+	}
+	return rcc.ValidateScaleDown(cc)
+}
+
+// CheckNonAllowedChanges - checks if there are some changes on CRD that are not allowed on statefulset
+// If a non Allowed Changed is Find we won't Update associated kubernetes objects, but we will put back the old value
+// and Patch the CRD with correct values
+func (rcc *ReconcileCassandraCluster) CheckNonAllowedChanges(cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus) bool {
+	oldCRD := lastAppliedConfiguration(cc)
+	if oldCRD == nil {
 		return false
 	}
 
-	//Global scaleDown to 0 is forbidden
-	if cc.Spec.NodesPerRacks == 0 {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).
-			Warningf("The Operator has refused the change on NodesPerRack=0 restore to OldValue[%d]",
-				oldCRD.Spec.NodesPerRacks)
-		cc.Spec.NodesPerRacks = oldCRD.Spec.NodesPerRacks
-		needUpdate = true
-	}
-	//DataCapacity change is forbidden
-	if cc.Spec.DataCapacity != oldCRD.Spec.DataCapacity {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).
-			Warningf("The Operator has refused the change on DataCapacity from [%s] to NewValue[%s]",
-				oldCRD.Spec.DataCapacity, cc.Spec.DataCapacity)
-		cc.Spec.DataCapacity = oldCRD.Spec.DataCapacity
-		needUpdate = true
-	}
-	//DataStorage
-	if cc.Spec.DataStorageClass != oldCRD.Spec.DataStorageClass {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).
-			Warningf("The Operator has refused the change on DataStorageClass from [%s] to NewValue[%s]",
-				oldCRD.Spec.DataStorageClass, cc.Spec.DataStorageClass)
-		cc.Spec.DataStorageClass = oldCRD.Spec.DataStorageClass
-		needUpdate = true
-	}
-
-	if needUpdate {
+	expandableDCs := rcc.expandableDCs(cc, oldCRD)
+	if err := ValidateNonAllowedChanges(cc, oldCRD, expandableDCs); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
+			"The Operator has refused the change: %v, old values restored", err)
+		rcc.setChangeRefused(cc, status, api.ReasonNonAllowedChange, err)
+		restoreNonAllowedChanges(cc, oldCRD, expandableDCs)
+		rcc.needUpdate = true
+	}
+
+	if rcc.needUpdate {
 		status.LastClusterAction = api.ActionCorrectCRDConfig
 		return true
 	}
 
 	var updateStatus string
-	if needUpdate, updateStatus = CheckTopologyChanges(rcc, cc, status, &oldCRD); needUpdate {
+	if rcc.needUpdate, updateStatus = CheckTopologyChanges(rcc, cc, status, oldCRD); rcc.needUpdate {
 		if updateStatus != "" {
 			status.LastClusterAction = updateStatus
 		}
 		if updateStatus == api.ActionCorrectCRDConfig {
-			cc.Spec.Topology = (&oldCRD).Spec.Topology
+			cc.Spec.Topology = oldCRD.Spec.Topology
 		}
 
 		return true
@@ -147,7 +161,7 @@ func (rcc *ReconcileCassandraCluster) CheckNonAllowedChanges(cc *api.CassandraCl
 		return true
 	}
 
-	if needUpdate, updateStatus = rcc.CheckNonAllowedScaleDown(cc, status, &oldCRD); needUpdate {
+	if rcc.needUpdate, updateStatus = rcc.CheckNonAllowedScaleDown(cc, status, oldCRD); rcc.needUpdate {
 		if updateStatus != "" {
 			status.LastClusterAction = updateStatus
 		}
@@ -156,30 +170,211 @@ func (rcc *ReconcileCassandraCluster) CheckNonAllowedChanges(cc *api.CassandraCl
 
 	//What if we ask to changes Pod ressources ?
 	// It is authorized, but the operator needs to detect it to prevent multiple statefulsets updates in the same time
-	// the operator must handle thoses updates sequentially, so we flag each dcrackname with this information
-	if !reflect.DeepEqual(cc.Spec.Resources, oldCRD.Spec.Resources) {
-		logrus.Infof("[%s]: We ask to Change Pod Resources from %v to %v", cc.Name, oldCRD.Spec.Resources, cc.Spec.Resources)
+	// the operator must handle thoses updates sequentially, so we flag each dcrackname whose resources change with
+	// this information. The resources of a rack are those of the rack, of its DC or of the cluster
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
 
-		for dc := 0; dc < cc.GetDCSize(); dc++ {
-			dcName := cc.GetDCName(dc)
-			for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			rackName := cc.GetRackName(dc, rack)
+			dcRackName := cc.GetDCRackName(dcName, rackName)
+			dcRackStatus, ok := status.CassandraRackStatus[dcRackName]
+			if !ok || dcRackStatus == nil {
+				continue
+			}
+			oldResources := oldCRD.GetResourcesFromDCRackName(dcRackName)
+			resources := cc.GetResourcesFromDCRackName(dcRackName)
+			if reflect.DeepEqual(resources, oldResources) {
+				continue
+			}
+			logrus.Infof("[%s][%s]: We ask to Change Pod Resources from %v to %v", cc.Name, dcRackName,
+				oldResources, resources)
+
+			logrus.Infof("[%s][%s]: Update Rack Status UpdateResources=Ongoing", cc.Name, dcRackName)
+			dcRackStatus.CassandraLastAction.Name = api.ActionUpdateResources
+			dcRackStatus.CassandraLastAction.Status = api.StatusToDo
+			now := metav1.Now()
+			dcRackStatus.CassandraLastAction.StartTime = &now
+			dcRackStatus.CassandraLastAction.EndTime = nil
+		}
+	}
 
-				rackName := cc.GetRackName(dc, rack)
-				dcRackName := cc.GetDCRackName(dcName, rackName)
-				dcRackStatus := status.CassandraRackStatus[dcRackName]
+	status.SetCondition(api.ClusterChangeRefused, v1.ConditionFalse, api.ReasonChangeAccepted, "")
+	return false
+}
 
-				logrus.Infof("[%s][%s]: Update Rack Status UpdateResources=Ongoing", cc.Name, dcRackName)
-				dcRackStatus.CassandraLastAction.Name = api.ActionUpdateResources
-				dcRackStatus.CassandraLastAction.Status = api.StatusToDo
-				now := metav1.Now()
-				status.CassandraRackStatus[dcRackName].CassandraLastAction.StartTime = &now
-				status.CassandraRackStatus[dcRackName].CassandraLastAction.EndTime = nil
-			}
+//ValidateNonAllowedChanges returns an error if cc has changes from oldCRD on fields which can't be changed:
+//NodesPerRacks can't be set to 0, DataCapacity and DataStorageClass can't be changed for the existing DCs, except
+//the DataCapacity of the expandableDCs whose PVCs can be expanded, CommitLogVolume, AdditionalDataVolumes and SeedMode
+//can't be changed, the capacities of the volumes must be quantities, RestoreFrom can only be removed, SeedsPerDC
+//can't be lower than 1
+func ValidateNonAllowedChanges(cc *api.CassandraCluster, oldCRD *api.CassandraCluster,
+	expandableDCs map[string]bool) error {
+	var refused []string
+
+	if cc.Spec.NodesPerRacks == 0 {
+		refused = append(refused, fmt.Sprintf("NodesPerRacks can't be set to 0 (old value %d)",
+			oldCRD.Spec.NodesPerRacks))
+	}
+	//The persistent volume claims of the existing DCs can't be changed, a new DC can have its own storage
+	for _, dcName := range existingDCNames(cc, oldCRD) {
+		oldDataCapacity, dataCapacity := oldCRD.GetDataCapacityForDC(dcName), cc.GetDataCapacityForDC(dcName)
+		if dataCapacity != oldDataCapacity && !expandableDCs[dcName] {
+			refused = append(refused, fmt.Sprintf("DataCapacity of DC %s can't be changed from [%s] to [%s]",
+				dcName, oldDataCapacity, dataCapacity))
+		}
+		oldDataStorageClass, dataStorageClass := oldCRD.GetDataStorageClassForDC(dcName),
+			cc.GetDataStorageClassForDC(dcName)
+		if dataStorageClass != oldDataStorageClass {
+			refused = append(refused, fmt.Sprintf("DataStorageClass of DC %s can't be changed from [%s] to [%s]",
+				dcName, oldDataStorageClass, dataStorageClass))
+		}
+	}
+	//The volumes are in the volumeClaimTemplates of all the statefulsets
+	if !reflect.DeepEqual(cc.Spec.CommitLogVolume, oldCRD.Spec.CommitLogVolume) {
+		refused = append(refused, "CommitLogVolume can't be changed")
+	}
+	if !reflect.DeepEqual(cc.Spec.AdditionalDataVolumes, oldCRD.Spec.AdditionalDataVolumes) {
+		refused = append(refused, "AdditionalDataVolumes can't be changed")
+	}
+	//The files of a backup are only downloaded in the nodes of a new cluster
+	if cc.Spec.RestoreFrom != nil && !reflect.DeepEqual(cc.Spec.RestoreFrom, oldCRD.Spec.RestoreFrom) {
+		refused = append(refused, "RestoreFrom can only be set when the cluster is created")
+	}
+	if err := cc.Spec.CommitLogVolume.Validate(); err != nil {
+		refused = append(refused, "CommitLogVolume "+err.Error())
+	}
+	for i := range cc.Spec.AdditionalDataVolumes {
+		if err := cc.Spec.AdditionalDataVolumes[i].Validate(); err != nil {
+			refused = append(refused, fmt.Sprintf("AdditionalDataVolumes[%d] %v", i, err))
+		}
+	}
+	if err := cc.Spec.TLS.Validate(); err != nil {
+		refused = append(refused, err.Error())
+	}
+	if err := cc.Spec.Authentication.Validate(); err != nil {
+		refused = append(refused, err.Error())
+	}
+	//The seeds of the running nodes are the seed pods or the seed Services
+	if !validSeedMode(cc) {
+		refused = append(refused, fmt.Sprintf("SeedMode must be %s or %s", api.SeedModePodList, api.SeedModeService))
+	} else if cc.GetSeedMode() != oldCRD.GetSeedMode() {
+		refused = append(refused, fmt.Sprintf("SeedMode can't be changed from [%s] to [%s]", oldCRD.GetSeedMode(),
+			cc.GetSeedMode()))
+	}
+	//A DC without seed can't be joined by new nodes
+	if cc.Spec.SeedsPerDC != nil && *cc.Spec.SeedsPerDC < 1 {
+		refused = append(refused, "SeedsPerDC must be at least 1")
+	}
+	for _, dc := range cc.Spec.Topology.DC {
+		if dc.SeedsPerDC != nil && *dc.SeedsPerDC < 1 {
+			refused = append(refused, fmt.Sprintf("SeedsPerDC of DC %s must be at least 1", dc.Name))
+		}
+	}
+	if len(refused) > 0 {
+		return fmt.Errorf("%s", strings.Join(refused, ", "))
+	}
+	return nil
+}
+
+//existingDCNames returns the names of the DCs of oldCRD which are still in cc
+func existingDCNames(cc *api.CassandraCluster, oldCRD *api.CassandraCluster) []string {
+	var dcNames []string
+	if oldCRD.GetDCSize() == 0 || cc.GetDCSize() == 0 {
+		return []string{oldCRD.GetDCName(0)}
+	}
+	for dc := 0; dc < oldCRD.GetDCSize(); dc++ {
+		if dcName := oldCRD.GetDCName(dc); cc.IsValidDC(dcName) {
+			dcNames = append(dcNames, dcName)
 		}
+	}
+	return dcNames
+}
+
+//validSeedMode returns true if the SeedMode of cc is PodList or Service, or is not set by a cluster created before it
+//was defaulted
+func validSeedMode(cc *api.CassandraCluster) bool {
+	return cc.Spec.SeedMode == "" || cc.Spec.SeedMode == api.SeedModePodList || cc.Spec.SeedMode == api.SeedModeService
+}
 
+//dcIndex returns the index of the DC dcName in the topology of cc, -1 if it is not in the topology
+func dcIndex(cc *api.CassandraCluster, dcName string) int {
+	for dc := range cc.Spec.Topology.DC {
+		if cc.Spec.Topology.DC[dc].Name == dcName {
+			return dc
+		}
 	}
+	return -1
+}
 
-	return false
+//restoreNonAllowedChanges restores from oldCRD the fields refused by ValidateNonAllowedChanges, the other changes
+//are kept
+func restoreNonAllowedChanges(cc *api.CassandraCluster, oldCRD *api.CassandraCluster, expandableDCs map[string]bool) {
+	//Global scaleDown to 0 is forbidden
+	if cc.Spec.NodesPerRacks == 0 {
+		cc.Spec.NodesPerRacks = oldCRD.Spec.NodesPerRacks
+	}
+	//The storage of an existing DC is restored on the DC, the one of the cluster is kept for the new DCs
+	for _, dcName := range existingDCNames(cc, oldCRD) {
+		dc, oldDC := dcIndex(cc, dcName), dcIndex(oldCRD, dcName)
+		oldDataCapacity, oldDataStorageClass := oldCRD.GetDataCapacityForDC(dcName), oldCRD.GetDataStorageClassForDC(dcName)
+		if dc < 0 {
+			if cc.GetDataCapacityForDC(dcName) != oldDataCapacity && !expandableDCs[dcName] {
+				cc.Spec.DataCapacity = oldCRD.Spec.DataCapacity
+			}
+			if cc.GetDataStorageClassForDC(dcName) != oldDataStorageClass {
+				cc.Spec.DataStorageClass = oldCRD.Spec.DataStorageClass
+			}
+			continue
+		}
+		if cc.GetDataCapacityForDC(dcName) != oldDataCapacity && !expandableDCs[dcName] {
+			cc.Spec.Topology.DC[dc].DataCapacity = ""
+			if oldDC >= 0 {
+				cc.Spec.Topology.DC[dc].DataCapacity = oldCRD.Spec.Topology.DC[oldDC].DataCapacity
+			}
+			if cc.GetDataCapacityForDC(dcName) != oldDataCapacity {
+				cc.Spec.Topology.DC[dc].DataCapacity = oldDataCapacity
+			}
+		}
+		if cc.GetDataStorageClassForDC(dcName) != oldDataStorageClass {
+			cc.Spec.Topology.DC[dc].DataStorageClass = ""
+			if oldDC >= 0 {
+				cc.Spec.Topology.DC[dc].DataStorageClass = oldCRD.Spec.Topology.DC[oldDC].DataStorageClass
+			}
+			if cc.GetDataStorageClassForDC(dcName) != oldDataStorageClass {
+				cc.Spec.Topology.DC[dc].DataStorageClass = oldDataStorageClass
+			}
+		}
+	}
+	if !reflect.DeepEqual(cc.Spec.CommitLogVolume, oldCRD.Spec.CommitLogVolume) {
+		cc.Spec.CommitLogVolume = oldCRD.Spec.CommitLogVolume
+	}
+	if !reflect.DeepEqual(cc.Spec.AdditionalDataVolumes, oldCRD.Spec.AdditionalDataVolumes) {
+		cc.Spec.AdditionalDataVolumes = oldCRD.Spec.AdditionalDataVolumes
+	}
+	if cc.Spec.RestoreFrom != nil && !reflect.DeepEqual(cc.Spec.RestoreFrom, oldCRD.Spec.RestoreFrom) {
+		cc.Spec.RestoreFrom = oldCRD.Spec.RestoreFrom
+	}
+	if cc.Spec.TLS.Validate() != nil {
+		cc.Spec.TLS = oldCRD.Spec.TLS
+	}
+	if cc.Spec.Authentication.Validate() != nil {
+		cc.Spec.Authentication = oldCRD.Spec.Authentication
+	}
+	if !validSeedMode(cc) || cc.GetSeedMode() != oldCRD.GetSeedMode() {
+		cc.Spec.SeedMode = oldCRD.Spec.SeedMode
+	}
+	if cc.Spec.SeedsPerDC != nil && *cc.Spec.SeedsPerDC < 1 {
+		cc.Spec.SeedsPerDC = oldCRD.Spec.SeedsPerDC
+	}
+	for dc := range cc.Spec.Topology.DC {
+		if seedsPerDC := cc.Spec.Topology.DC[dc].SeedsPerDC; seedsPerDC != nil && *seedsPerDC < 1 {
+			cc.Spec.Topology.DC[dc].SeedsPerDC = nil
+			if oldDC := dcIndex(oldCRD, cc.Spec.Topology.DC[dc].Name); oldDC >= 0 {
+				cc.Spec.Topology.DC[dc].SeedsPerDC = oldCRD.Spec.Topology.DC[oldDC].SeedsPerDC
+			}
+		}
+	}
 }
 
 func generatePaths(s string) []string {
@@ -256,59 +451,226 @@ func hasChange(changelog diff.Changelog, changeType string, paths ...string) boo
 	return false
 }
 
-//CheckTopologyChanges checks to see if the Operator accepts or refuses the CRD changes
-func CheckTopologyChanges(rcc *ReconcileCassandraCluster, cc *api.CassandraCluster,
-	status *api.CassandraClusterStatus, oldCRD *api.CassandraCluster) (bool, string) {
-
+//ValidateTopologyChanges returns an error if the topology of cc can't be changed from the one of oldCRD
+//Only adding or removing one DC, adding racks at the end of existing DCs and removing one rack are allowed, and a DC
+//can be removed only once scaled down to 0
+func ValidateTopologyChanges(cc *api.CassandraCluster, oldCRD *api.CassandraCluster) error {
 	changelog, _ := diff.Diff(oldCRD.Spec.Topology, cc.Spec.Topology)
 
-	if hasChange(changelog, diff.UPDATE) ||
-		hasChange(changelog, diff.DELETE, "DC.Rack", "-DC") ||
-		hasChange(changelog, diff.CREATE, "DC.Rack", "-DC") {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-			topologyChangeRefused+"No change other than adding/removing a DC can happen: %v restored to %v",
-			cc.Spec.Topology, oldCRD.Spec.Topology)
-		return true, api.ActionCorrectCRDConfig
+	if hasChange(changelog, diff.UPDATE) {
+		return fmt.Errorf("No change other than adding/removing a DC or adding/removing a Rack can happen")
 	}
 
-	if cc.GetDCSize() < oldCRD.GetDCSize()-1 {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-			topologyChangeRefused+"You can only remove 1 DC at a time, "+
-				"not only a Rack: %v restored to %v", cc.Spec.Topology, oldCRD.Spec.Topology)
-		return true, api.ActionCorrectCRDConfig
+	if err := validateRemovedRacks(cc, oldCRD); err != nil {
+		return err
+	}
 
+	oldDCRackNames := oldCRD.GetDCRackNames()
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		var rackNames []string
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			rackName := cc.GetRackName(dc, rack)
+			if k8s.Contains(rackNames, rackName) {
+				return fmt.Errorf("Rack %s is defined twice in DC %s", rackName, dcName)
+			}
+			rackNames = append(rackNames, rackName)
+			//The name of a new rack must be usable in the names of its objects
+			if !k8s.Contains(oldDCRackNames, cc.GetDCRackName(dcName, rackName)) &&
+				(strings.Contains(rackName, "-") || cc.GetDCRackName(dcName, rackName) == "") {
+				return fmt.Errorf("Rack name %s is not valid: it must consist of lower case alphanumeric characters",
+					rackName)
+			}
+		}
 	}
 
-	if cc.GetDCRackSize() < oldCRD.GetDCRackSize() {
+	if cc.GetDCSize() < oldCRD.GetDCSize()-1 {
+		return fmt.Errorf("You can only remove 1 DC at a time, not only a Rack")
+	}
 
+	if cc.GetDCSize() < oldCRD.GetDCSize() {
 		if cc.Status.LastClusterAction == api.ActionScaleDown &&
 			cc.Status.LastClusterActionStatus != api.StatusDone {
-			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).
-				Warningf(topologyChangeRefused +
-					"You must wait to the end of ScaleDown to 0 before deleting a DC")
-			return true, api.ActionCorrectCRDConfig
-
+			return fmt.Errorf("You must wait to the end of ScaleDown to 0 before deleting a DC")
 		}
 
 		dcName := cc.GetRemovedDCName(oldCRD)
 
 		//We need to check how many nodes were in the old CRD (before the user delete it)
 		if found, nbNodes := oldCRD.GetDCNodesPerRacksFromName(dcName); found && nbNodes > 0 {
-			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).
-				Warningf(topologyChangeRefused+
-					"You must scale down the DC %s to 0 before deleting it", dcName)
-			return true, api.ActionCorrectCRDConfig
+			return fmt.Errorf("You must scale down the DC %s to 0 before deleting it", dcName)
+		}
+	}
+	return nil
+}
+
+//CheckTopologyChanges checks to see if the Operator accepts or refuses the CRD changes
+func CheckTopologyChanges(rcc *ReconcileCassandraCluster, cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus, oldCRD *api.CassandraCluster) (bool, string) {
+
+	if err := ValidateTopologyChanges(cc, oldCRD); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
+			topologyChangeRefused+"%v: %v restored to %v", err, cc.Spec.Topology, oldCRD.Spec.Topology)
+		rcc.setChangeRefused(cc, status, api.ReasonTopologyChange, err)
+		return true, api.ActionCorrectCRDConfig
+	}
+
+	if removedRacks := removedDCRackNames(cc, oldCRD); len(removedRacks) > 0 {
+		//The rack is kept in the topology until its nodes are decommissioned, it is then deleted by deleteRackObjects
+		cc.Spec.Topology = oldCRD.Spec.Topology
+		for _, dcRackName := range removedRacks {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Warning(
+				"Removing Rack, its nodes are decommissioned")
+			if dcRackStatus, ok := status.CassandraRackStatus[dcRackName]; ok {
+				dcRackStatus.Removing = true
+			}
 		}
+		return true, api.ActionDeleteRack
+	}
 
+	if cc.GetDCSize() < oldCRD.GetDCSize() {
+		dcName := cc.GetRemovedDCName(oldCRD)
 		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf("Removing DC %s", dcName)
 
 		//We apply this change to the Cluster status
 		return rcc.deleteDCObjects(cc, status, oldCRD)
 	}
 
+	if addedRacks := addedDCRackNames(cc, oldCRD); len(addedRacks) > 0 {
+		now := metav1.Now()
+		for _, dcRackName := range addedRacks {
+			logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Info("Adding Rack")
+			dcName, rackName := cc.GetDCAndRackFromDCRackName(dcRackName)
+			cc.InitCassandraRackinStatus(status, dcName, rackName)
+			lastAction := &status.CassandraRackStatus[dcRackName].CassandraLastAction
+			lastAction.Name = api.ActionAddRack
+			lastAction.StartTime = &now
+		}
+		return true, api.ActionAddRack
+	}
+
 	return false, ""
 }
 
+//addedDCRackNames returns the racks of cc added to the DCs which already exist in oldCRD
+func addedDCRackNames(cc *api.CassandraCluster, oldCRD *api.CassandraCluster) []string {
+	var dcRackNames []string
+	oldDCRackNames := oldCRD.GetDCRackNames()
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		dcName := cc.GetDCName(dc)
+		if !oldCRD.IsValidDC(dcName) {
+			continue
+		}
+		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
+			dcRackName := cc.GetDCRackName(dcName, cc.GetRackName(dc, rack))
+			if !k8s.Contains(oldDCRackNames, dcRackName) {
+				dcRackNames = append(dcRackNames, dcRackName)
+			}
+		}
+	}
+	return dcRackNames
+}
+
+//removedDCRackNames returns the racks of oldCRD removed from the DCs which still exist in cc
+func removedDCRackNames(cc *api.CassandraCluster, oldCRD *api.CassandraCluster) []string {
+	return addedDCRackNames(oldCRD, cc)
+}
+
+//validateRemovedRacks returns an error if the racks removed from the DCs of oldCRD can't be removed
+func validateRemovedRacks(cc *api.CassandraCluster, oldCRD *api.CassandraCluster) error {
+	for dc := 0; dc < cc.GetDCSize(); dc++ {
+		if cc.GetRackSize(dc) > 0 {
+			continue
+		}
+		dcName := cc.GetDCName(dc)
+		for oldDC := 0; oldDC < oldCRD.GetDCSize(); oldDC++ {
+			if oldCRD.GetDCName(oldDC) == dcName && oldCRD.GetRackSize(oldDC) > 0 {
+				return fmt.Errorf("The Racks of DC %s can't all be removed, the DC must be removed instead", dcName)
+			}
+		}
+	}
+
+	removedRacks := removedDCRackNames(cc, oldCRD)
+	if len(removedRacks) == 0 {
+		return nil
+	}
+	if len(removedRacks) > 1 || cc.GetDCSize() < oldCRD.GetDCSize() {
+		return fmt.Errorf("You can only remove 1 Rack at a time")
+	}
+	if len(addedDCRackNames(cc, oldCRD)) > 0 {
+		return fmt.Errorf("You can't add and remove Racks at the same time")
+	}
+
+	for dcRackName, dcRackStatus := range cc.Status.CassandraRackStatus {
+		if dcRackStatus != nil && dcRackStatus.Removing && dcRackName != removedRacks[0] {
+			return fmt.Errorf("You must wait for the removal of Rack %s before removing another Rack", dcRackName)
+		}
+	}
+	return nil
+}
+
+//deleteRackObjects deletes the statefulset, the PVCs, the service and the status of the rack of index rack in the DC
+//of index dc once no token range is replicated to it anymore, the rack is then removed from the topology
+func (rcc *ReconcileCassandraCluster) deleteRackObjects(cc *api.CassandraCluster, status *api.CassandraClusterStatus,
+	dc, rack int) error {
+	dcName := cc.GetDCName(dc)
+	rackName := cc.GetRackName(dc, rack)
+	dcRackName := cc.GetDCRackName(dcName, rackName)
+
+	keyspacesWithData, err := rcc.rackHasData(cc, dcName, rackName)
+	if err == nil && len(keyspacesWithData) > 0 {
+		err = fmt.Errorf("keyspaces still having data %v", keyspacesWithData)
+	}
+	if err != nil {
+		rcc.recordEvent(cc, v1.EventTypeWarning, reasonTopologyFailed, "Rack %s not removed: %v", dcRackName, err)
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName}).Warning(
+		"Nodes of the Rack decommissioned, deleting the Rack")
+	err = rcc.DeleteStatefulSet(cc.Namespace, cc.Name+"-"+dcRackName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	rcc.DeletePVCs(cc, dcName, rackName)
+	err = rcc.DeleteService(cc.Namespace, cc.Name+"-"+dcRackName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackName}).Warnf(
+			"Can't Delete Service: %v", err)
+	}
+
+	//The removal of the rack is now accepted
+	cc.Spec.Topology.DC[dc].Rack.Remove(rack)
+	cc.FixCassandraRackList(status)
+	status.LastClusterAction = api.ActionDeleteRack
+	status.LastClusterActionStatus = api.StatusDone
+	rcc.needUpdate = true
+	rcc.recordEvent(cc, v1.EventTypeNormal, reasonRackRemoved, "Rack %s removed from DC %s", rackName, dcName)
+	return nil
+}
+
+//rackHasData returns the keyspaces still replicated to the rack rackName of the DC dcName, asking a running node of
+//the DC
+func (rcc *ReconcileCassandraCluster) rackHasData(cc *api.CassandraCluster, dcName, rackName string) ([]string, error) {
+	podsList, err := rcc.ListPods(cc.Namespace, k8s.LabelsForCassandraDC(cc, dcName))
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range podsList.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+		jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
+			cc.Spec.ImageJolokiaSecret, cc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return jolokiaClient.HasDataInRack(dcName, rackName)
+	}
+	return nil, fmt.Errorf("no running node in DC %s", dcName)
+}
+
 func (rcc *ReconcileCassandraCluster) deleteDCObjects(cc *api.CassandraCluster,
 	status *api.CassandraClusterStatus, oldCRD *api.CassandraCluster) (bool, string) {
 
@@ -335,6 +697,11 @@ func (rcc *ReconcileCassandraCluster) deleteDCObjects(cc *api.CassandraCluster,
 						"Can't Delete Service: %v", err)
 				}
 			}
+			err = rcc.deleteCassandraSeedServices(cc, cc.GetDCFromDCRackName(dcRackNameToDelete), nil)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"cluster": cc.Name, "rack": dcRackNameToDelete}).Warnf(
+					"Can't Delete Seed Services: %v", err)
+			}
 
 		}
 		return true, api.ActionDeleteDC
@@ -342,61 +709,63 @@ func (rcc *ReconcileCassandraCluster) deleteDCObjects(cc *api.CassandraCluster,
 	return false, ""
 }
 
+//ValidateScaleDown returns an error if a DC is scaled down to 0 while Cassandra still replicates data towards it
+func (rcc *ReconcileCassandraCluster) ValidateScaleDown(cc *api.CassandraCluster) error {
+	ok, dcName, dc := cc.FindDCWithNodesTo0()
+	if !ok {
+		return nil
+	}
+	logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Infof("Ask ScaleDown to 0 for dc %s", dcName)
+
+	//We take the first Rack
+	rackName := cc.GetRackName(dc, 0)
+
+	selector := k8s.MergeLabels(k8s.LabelsForCassandraDCRack(cc, dcName, rackName))
+	podsList, err := rcc.ListPods(cc.Namespace, selector)
+	if err != nil {
+		return fmt.Errorf("Can't scale down DC %s to 0: no pod found", dcName)
+	}
+
+	//We take the first available Pod, if there is already no pods it's ok
+	for _, pod := range podsList.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Debugf("The Operator will ask node %s", hostName)
+		jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
+			cc.Spec.ImageJolokiaSecret, cc.Namespace)
+		var keyspacesWithData []string
+		if err == nil {
+			keyspacesWithData, err = jolokiaClient.HasDataInDC(dcName)
+		}
+		if err != nil {
+			return fmt.Errorf("Can't scale down DC %s to 0: HasDataInDC failed %s", dcName, err)
+		}
+		if len(keyspacesWithData) != 0 {
+			return fmt.Errorf("Can't scale down DC %s to 0: keyspaces still having data %v",
+				dcName, keyspacesWithData)
+		}
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
+			"Cassandra has no more replicated data on dc %s, we can scale Down to 0", dcName)
+		return nil
+	}
+	return nil
+}
+
 //CheckNonAllowedScaleDown goal is to discard the scaleDown to 0 is there is still replicated data towards the
 // corresponding DC
 func (rcc *ReconcileCassandraCluster) CheckNonAllowedScaleDown(cc *api.CassandraCluster,
 	status *api.CassandraClusterStatus,
 	oldCRD *api.CassandraCluster) (bool, string) {
 
-	if ok, dcName, dc := cc.FindDCWithNodesTo0(); ok {
-		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Infof("Ask ScaleDown to 0 for dc %s", dcName)
-
-		//We take the first Rack
-		rackName := cc.GetRackName(dc, 0)
-
-		selector := k8s.MergeLabels(k8s.LabelsForCassandraDCRack(cc, dcName, rackName))
-		podsList, err := rcc.ListPods(cc.Namespace, selector)
-		if err != nil || len(podsList.Items) < 1 {
-			if err != nil {
-				logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-					"The Operator has refused the ScaleDown (no pod found). "+
-						"topology %v restored to %v", cc.Spec.Topology, oldCRD.Spec.Topology)
-				cc.Spec.Topology = oldCRD.Spec.Topology
-				return true, api.ActionCorrectCRDConfig
-			}
-			//else there is already no pods so it's ok
-			return false, ""
-		}
-
-		//We take the first available Pod
-		for _, pod := range podsList.Items {
-			if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
-				continue
-			}
-			hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
-			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Debugf("The Operator will ask node %s", hostName)
-			jolokiaClient, err := NewJolokiaClient(hostName, JolokiaPort, rcc,
-				cc.Spec.ImageJolokiaSecret, cc.Namespace)
-			var keyspacesWithData []string
-			if err == nil {
-				keyspacesWithData, err = jolokiaClient.HasDataInDC(dcName)
-			}
-			if err != nil {
-				logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-					"The Operator has refused the ScaleDown (HasDataInDC failed %s). ", err)
-				cc.Spec.Topology = oldCRD.Spec.Topology
-				return true, api.ActionCorrectCRDConfig
-			}
-			if len(keyspacesWithData) != 0 {
-				logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-					"The Operator has refused the ScaleDown. Keyspaces still having data %v", keyspacesWithData)
-				cc.Spec.Topology = oldCRD.Spec.Topology
-				return true, api.ActionCorrectCRDConfig
-			}
-			logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
-				"Cassandra has no more replicated data on dc %s, we can scale Down to 0", dcName)
-			return false, ""
-		}
+	if err := rcc.ValidateScaleDown(cc); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf(
+			"The Operator has refused the ScaleDown (%v). topology %v restored to %v",
+			err, cc.Spec.Topology, oldCRD.Spec.Topology)
+		rcc.setChangeRefused(cc, status, api.ReasonScaleDownRefused, err)
+		cc.Spec.Topology = oldCRD.Spec.Topology
+		return true, api.ActionCorrectCRDConfig
 	}
 	return false, ""
 }
@@ -445,6 +814,26 @@ func (rcc *ReconcileCassandraCluster) ReconcileRack(cc *api.CassandraCluster,
 				//Find if there is an Action to execute or to end
 				rcc.getNextCassandraClusterStatus(cc, dc, rack, dcName, rackName, storedStatefulSet, status)
 
+				//A removed rack is deleted once all its nodes have been decommissioned
+				if dcRackStatus.Removing && !rcc.weAreScalingDown(dcRackStatus) &&
+					*storedStatefulSet.Spec.Replicas == 0 && storedStatefulSet.Status.Replicas == 0 {
+					if err = rcc.deleteRackObjects(cc, status, dc, rack); err != nil {
+						logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc-rack": dcRackName,
+							"err": err}).Warning("Waiting for the Rack to be deleted")
+					}
+					//The topology may have changed, we don't go through next racks
+					return nil
+				}
+
+				//The PVCs of the rack are expanded before updating the statefulset, we don't go to next racks until
+				//the statefulset has been recreated with the new DataCapacity
+				if dcRackStatus.CassandraLastAction.Name == api.ActionUpdateDataCapacity &&
+					(dcRackStatus.CassandraLastAction.Status == api.StatusToDo ||
+						dcRackStatus.CassandraLastAction.Status == api.StatusOngoing) &&
+					!rcc.expandDataPVCs(cc, dcName, rackName, storedStatefulSet, status) {
+					return nil
+				}
+
 				//If Not in +Initial State
 				// Find if we have some Pod Operation to Execute, and execute thees
 				if dcRackStatus.Phase != api.ClusterPhaseInitial {
@@ -486,6 +875,13 @@ func (rcc *ReconcileCassandraCluster) ReconcileRack(cc *api.CassandraCluster,
 					"dc-rack": dcRackName}).Errorf("ensureCassandraServiceMonitoring Error: %v", err)
 			}
 
+			if cc.Spec.SeedMode == api.SeedModeService {
+				if err = rcc.ensureCassandraSeedServices(cc, status, dcName); err != nil {
+					logrus.WithFields(logrus.Fields{"cluster": cc.Name,
+						"dc-rack": dcRackName}).Errorf("ensureCassandraSeedServices Error: %v", err)
+				}
+			}
+
 			if err = rcc.ensureCassandraStatefulSet(cc, status, dcName, dcRackName, dc, rack); err != nil {
 				logrus.WithFields(logrus.Fields{"cluster": cc.Name,
 					"dc-rack": dcRackName}).Errorf("ensureCassandraStatefulSet Error: %v", err)
@@ -494,7 +890,7 @@ func (rcc *ReconcileCassandraCluster) ReconcileRack(cc *api.CassandraCluster,
 			if cc.Spec.UnlockNextOperation {
 				//If we enter specific change we remove _unlockNextOperation from Spec
 				cc.Spec.UnlockNextOperation = false
-				needUpdate = true
+				rcc.needUpdate = true
 			}
 
 			//If the Phase is not running Then we won't check on Next Racks so we return
@@ -524,6 +920,7 @@ func (rcc *ReconcileCassandraCluster) ReconcileRack(cc *api.CassandraCluster,
 // UpdateCassandraClusterStatusPhase goal is to calculate the Cluster Phase according to StatefulSet Status.
 func UpdateCassandraClusterStatusPhase(cc *api.CassandraCluster, status *api.CassandraClusterStatus) {
 	var setLastClusterActionStatus bool
+	defer UpdateClusterConditions(cc, status)
 	for dc := 0; dc < cc.GetDCSize(); dc++ {
 		dcName := cc.GetDCName(dc)
 		for rack := 0; rack < cc.GetRackSize(dc); rack++ {
@@ -28,9 +28,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
 	"github.com/ghodss/yaml"
 	"github.com/r3labs/diff"
 	"github.com/stretchr/testify/assert"
@@ -348,17 +351,203 @@ func TestCheckNonAllowedChangesMix1(t *testing.T) {
 	cc.Spec.DataStorageClass = "fast" //instead of "local-storage"
 	//Allow Changed
 	cc.Spec.AutoPilot = false //instead of true
+	cc.Spec.TLS = &api.TLSSpec{ClientEncryption: true}
+	cc.Spec.Authentication = &api.AuthenticationSpec{SuperuserSecret: v1.LocalObjectReference{Name: "admin"}}
 
 	res := rcc.CheckNonAllowedChanges(cc, status)
 	assert.Equal(true, res)
 
-	//Forbidden Changes
+	//Forbidden Changes, the storage of the cluster is kept for the new DCs
 	assert.Equal(int32(1), cc.Spec.NodesPerRacks)
-	assert.Equal("3Gi", cc.Spec.DataCapacity)
-	assert.Equal("local-storage", cc.Spec.DataStorageClass)
+	for _, dcName := range []string{"dc1", "dc2"} {
+		assert.Equal("3Gi", cc.GetDataCapacityForDC(dcName))
+		assert.Equal("local-storage", cc.GetDataStorageClassForDC(dcName))
+	}
+	assert.Equal(v1.ConditionTrue, status.GetCondition(api.ClusterChangeRefused).Status)
+	assert.Equal(api.ReasonNonAllowedChange, status.GetCondition(api.ClusterChangeRefused).Reason)
 
 	//Allow Change
 	assert.Equal(false, cc.Spec.AutoPilot)
+	assert.Equal(&api.TLSSpec{ClientEncryption: true}, cc.Spec.TLS)
+	assert.Equal("admin", cc.Spec.Authentication.SuperuserSecret.Name)
+
+	//Only the invalid TLS is restored
+	status = cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	cc.Spec.TLS = &api.TLSSpec{InternodeEncryption: "everything"}
+	cc.Spec.AutoPilot = true
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal(&api.TLSSpec{ClientEncryption: true}, cc.Spec.TLS)
+	assert.Equal(true, cc.Spec.AutoPilot)
+	assert.Equal("admin", cc.Spec.Authentication.SuperuserSecret.Name)
+}
+
+func TestCheckNonAllowedChangesDCDataStorage(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//The storage of an existing DC can't be changed
+	cc.Spec.Topology.DC[1].DataCapacity = "10Gi"
+	cc.Spec.Topology.DC[1].DataStorageClass = "fast"
+	err := ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("DataCapacity of DC dc2 can't be changed from [3Gi] to [10Gi], "+
+		"DataStorageClass of DC dc2 can't be changed from [local-storage] to [fast]", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("", cc.Spec.Topology.DC[1].DataCapacity)
+	assert.Equal("", cc.Spec.Topology.DC[1].DataStorageClass)
+
+	//The storage of the cluster can be changed if the existing DCs keep theirs
+	status = cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	cc.Spec.DataCapacity = "10Gi"
+	cc.Spec.DataStorageClass = "fast"
+	for dc := range cc.Spec.Topology.DC {
+		cc.Spec.Topology.DC[dc].DataCapacity = "3Gi"
+		cc.Spec.Topology.DC[dc].DataStorageClass = "local-storage"
+	}
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil))
+
+	//A new DC can have its own storage
+	cc.Spec.Topology.DC = append(cc.Spec.Topology.DC, api.DC{Name: "dc3", DataCapacity: "20Gi",
+		Rack: []api.Rack{{Name: "rack1"}}})
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil))
+	assert.Equal("20Gi", cc.GetDataCapacityForDC("dc3"))
+	assert.Equal("fast", cc.GetDataStorageClassForDC("dc3"))
+	assert.Equal("3Gi", cc.GetDataCapacityForDC("dc1"))
+}
+
+func TestCheckNonAllowedChangesVolumes(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	cc.Spec.CommitLogVolume = &api.StorageVolume{Capacity: "1Gi"}
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	cc.Spec.CommitLogVolume.Capacity = "2Gi"
+	cc.Spec.AdditionalDataVolumes = []api.StorageVolume{{Capacity: "5Gi"}}
+	err := ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("CommitLogVolume can't be changed, AdditionalDataVolumes can't be changed", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("1Gi", cc.Spec.CommitLogVolume.Capacity)
+	assert.Empty(cc.Spec.AdditionalDataVolumes)
+
+	//The capacities must be quantities
+	cc.Spec.CommitLogVolume.Capacity = "ten"
+	err = ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Contains(err.Error(), "CommitLogVolume capacity [ten] is not a valid quantity")
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("1Gi", cc.Spec.CommitLogVolume.Capacity)
+}
+
+func TestCheckNonAllowedChangesRestoreFrom(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//A backup can't be restored in an existing cluster
+	cc.Spec.RestoreFrom = &api.RestoreFrom{Backup: "backup"}
+	err := ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("RestoreFrom can only be set when the cluster is created", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Nil(cc.Spec.RestoreFrom)
+
+	//RestoreFrom is removed once the restore is done
+	cc.Spec.RestoreFrom = &api.RestoreFrom{Backup: "backup"}
+	rcc.updateCassandraStatus(cc, status)
+	cc.Spec.RestoreFrom = nil
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil))
+}
+
+func TestCheckNonAllowedChangesSeedMode(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//A cluster created before SeedMode was defaulted uses PodList
+	cc.Spec.SeedMode = api.SeedModePodList
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil))
+
+	cc.Spec.SeedMode = api.SeedModeService
+	err := ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("SeedMode can't be changed from [PodList] to [Service]", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("", cc.Spec.SeedMode)
+
+	cc.Spec.SeedMode = "Pods"
+	err = ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("SeedMode must be PodList or Service", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal("", cc.Spec.SeedMode)
+}
+
+func TestCheckNonAllowedChangesSeedsPerDC(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	cc.Spec.SeedsPerDC = func(i int32) *int32 { return &i }(1)
+	cc.Spec.Topology.DC[1].SeedsPerDC = func(i int32) *int32 { return &i }(5)
+	assert.Nil(ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil))
+
+	cc.Spec.Topology.DC[1].SeedsPerDC = func(i int32) *int32 { return &i }(0)
+	err := ValidateNonAllowedChanges(cc, lastAppliedConfiguration(cc), nil)
+	assert.Equal("SeedsPerDC of DC dc2 must be at least 1", err.Error())
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal(int32(1), *cc.Spec.SeedsPerDC)
+	assert.Nil(cc.Spec.Topology.DC[1].SeedsPerDC)
+}
+
+//ValidateChanges must refuse the changes restored by CheckNonAllowedChanges without modifying the cluster
+func TestValidateChanges(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	assert.Nil(rcc.ValidateChanges(cc))
+
+	//Allowed change
+	cc.Spec.AutoPilot = !cc.Spec.AutoPilot
+	assert.Nil(rcc.ValidateChanges(cc))
+
+	cc.Spec.DataCapacity = "4Gi" //instead of "3Gi"
+	err := rcc.ValidateChanges(cc)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "DataCapacity")
+	assert.Equal("4Gi", cc.Spec.DataCapacity)
+	cc.Spec.DataCapacity = "3Gi"
+
+	cc.Spec.Topology.DC[0].Rack = append(cc.Spec.Topology.DC[0].Rack, api.Rack{Name: "ForbiddenRack"})
+	err = rcc.ValidateChanges(cc)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), topologyChangeRefused)
+	assert.Equal(5, cc.GetDCRackSize())
+}
+
+func TestCheckNonAllowedChangesRecordsEvents(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	recorder := record.NewFakeRecorder(10)
+	rcc.recorder = recorder
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	assert.Equal(0, len(recorder.Events))
+
+	cc.Spec.DataCapacity = "4Gi" //instead of "3Gi"
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal(1, len(recorder.Events))
+	assert.Equal("Warning NonAllowedChange Change refused: DataCapacity of DC dc1 can't be changed from [3Gi] to "+
+		"[4Gi], DataCapacity of DC dc2 can't be changed from [3Gi] to [4Gi]", <-recorder.Events)
+
+	status.CassandraRackStatus["dc1-rack2"].CassandraLastAction.Status = api.StatusDone
+	rcc.updateCassandraStatus(cc, status)
+	assert.Equal(1, len(recorder.Events))
+	assert.Equal("Normal Initializing Action Initializing is Done on rack dc1-rack2", <-recorder.Events)
 }
 
 func TestCheckNonAllowedChangesResourcesIsAllowedButNeedAttention(t *testing.T) {
@@ -390,6 +579,45 @@ func TestCheckNonAllowedChangesResourcesIsAllowedButNeedAttention(t *testing.T)
 	assert.Equal(api.StatusToDo, status.CassandraRackStatus[dcRackName].CassandraLastAction.Status)
 }
 
+func TestCheckNonAllowedChangesDCAndRackResources(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//Only the racks whose resources change are updated
+	cc.Spec.Topology.DC[1].Rack[0].Resources = &api.CassandraResources{
+		Requests: api.CPUAndMem{CPU: "4", Memory: "8Gi"},
+		Limits:   api.CPUAndMem{CPU: "4", Memory: "8Gi"},
+	}
+	assert.Equal(false, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal(api.ActionUpdateResources, status.CassandraRackStatus["dc2-rack1"].CassandraLastAction.Name)
+	assert.Equal(api.StatusToDo, status.CassandraRackStatus["dc2-rack1"].CassandraLastAction.Status)
+	for _, dcRackName := range []string{"dc1-rack1", "dc1-rack2"} {
+		assert.NotEqual(api.ActionUpdateResources, status.CassandraRackStatus[dcRackName].CassandraLastAction.Name)
+	}
+
+	//The resources of a DC apply to its racks without resources
+	status = cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	cc.Spec.Topology.DC[0].Resources = cc.Spec.Topology.DC[1].Rack[0].Resources
+	assert.Equal(false, rcc.CheckNonAllowedChanges(cc, status))
+	for _, dcRackName := range []string{"dc1-rack1", "dc1-rack2"} {
+		assert.Equal(api.ActionUpdateResources, status.CassandraRackStatus[dcRackName].CassandraLastAction.Name)
+	}
+	assert.NotEqual(api.ActionUpdateResources, status.CassandraRackStatus["dc2-rack1"].CassandraLastAction.Name)
+
+	//A change of the resources of the cluster doesn't apply to the racks with their own resources
+	status = cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	cc.Spec.Resources.Requests.CPU = "2"
+	assert.Equal(false, rcc.CheckNonAllowedChanges(cc, status))
+	for _, dcRackName := range []string{"dc1-rack1", "dc1-rack2", "dc2-rack1"} {
+		assert.NotEqual(api.ActionUpdateResources, status.CassandraRackStatus[dcRackName].CassandraLastAction.Name)
+	}
+}
+
 func TestCheckNonAllowedChangesRemove2DC(t *testing.T) {
 	assert := assert.New(t)
 
@@ -426,7 +654,7 @@ func TestCheckNonAllowedChangesUpdateRack(t *testing.T) {
 	//Topology must have been restored
 	assert.Equal(4, cc.GetDCRackSize())
 
-	needUpdate = false
+	rcc.needUpdate = false
 
 	//Remove 1 rack/dc at specified index
 	cc.Spec.Topology.DC[0].Rack = append(cc.Spec.Topology.DC[0].Rack, api.Rack{Name: "ForbiddenRack"})
@@ -442,6 +670,117 @@ func TestCheckNonAllowedChangesUpdateRack(t *testing.T) {
 	assert.Equal(4, cc.GetDCRackSize())
 }
 
+func TestCheckNonAllowedChangesAddRack(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+	assert.Equal(4, cc.GetDCRackSize())
+
+	//A rack name must be usable in the names of its objects
+	for _, rackName := range []string{"rack-3", "rack1"} {
+		cc.Spec.Topology.DC[0].Rack = append(cc.Spec.Topology.DC[0].Rack, api.Rack{Name: rackName})
+		assert.NotNil(rcc.ValidateChanges(cc))
+		assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+		assert.Equal(4, cc.GetDCRackSize())
+		rcc.needUpdate = false
+	}
+
+	cc.Spec.Topology.DC[0].Rack = append(cc.Spec.Topology.DC[0].Rack, api.Rack{Name: "rack3"})
+	assert.Nil(rcc.ValidateChanges(cc))
+
+	res := rcc.CheckNonAllowedChanges(cc, status)
+	assert.Equal(true, res)
+
+	//Topology must have been kept
+	assert.Equal(5, cc.GetDCRackSize())
+	assert.Equal(api.ActionAddRack, status.LastClusterAction)
+	assert.Equal(api.ClusterPhaseInitial, status.CassandraRackStatus["dc1-rack3"].Phase)
+	assert.Equal(api.ActionAddRack, status.CassandraRackStatus["dc1-rack3"].CassandraLastAction.Name)
+	assert.Equal(true, racksAreChanging(status))
+}
+
+func TestCheckNonAllowedChangesRemoveRack(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	status := cc.Status.DeepCopy()
+	rcc.updateCassandraStatus(cc, status)
+
+	//The last rack of a DC can't be removed
+	cc.Spec.Topology.DC[1].Rack.Remove(0)
+	assert.NotNil(rcc.ValidateChanges(cc))
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+	assert.Equal(4, cc.GetDCRackSize())
+	assert.Equal(api.ActionCorrectCRDConfig, status.LastClusterAction)
+	rcc.needUpdate = false
+
+	cc.Spec.Topology.DC[0].Rack.Remove(1)
+	assert.Nil(rcc.ValidateChanges(cc))
+	assert.Equal(true, rcc.CheckNonAllowedChanges(cc, status))
+
+	//The rack is kept until its nodes are decommissioned
+	assert.Equal(4, cc.GetDCRackSize())
+	assert.Equal(api.ActionDeleteRack, status.LastClusterAction)
+	assert.Equal(true, status.CassandraRackStatus["dc1-rack2"].Removing)
+	assert.Equal(true, racksAreChanging(status))
+	rcc.updateCassandraStatus(cc, status)
+	assert.Equal(int32(0), cc.GetNodesPerRacks("dc1-rack2"))
+
+	//Another rack can't be removed meanwhile
+	cc.Spec.Topology.DC[0].Rack.Remove(0)
+	assert.NotNil(rcc.ValidateChanges(cc))
+}
+
+//Uses K8s fake client, & Jolokia Mock
+func TestDeleteRackObjects(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	status := cc.Status.DeepCopy()
+	status.CassandraRackStatus["dc1-rack2"].Removing = true
+	rcc.updateCassandraStatus(cc, status)
+
+	rcc.CreateStatefulSet(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "cassandra-demo-dc1-rack2", Namespace: "ns"},
+	})
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cassandra-demo-dc1-rack1-0",
+			Namespace: "ns",
+			Labels:    k8s.LabelsForCassandraDCRack(cc, "dc1", "rack1"),
+		},
+	}
+	pod.Status.Phase = v1.PodRunning
+	pod.Spec.Hostname = "cassandra-demo-dc1-rack1-0"
+	pod.Spec.Subdomain = "cassandra-demo"
+	hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+	rcc.CreatePod(pod)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	//Token ranges are still replicated to the rack
+	httpmock.RegisterResponder("POST", JolokiaURL(hostName, JolokiaPort),
+		describeRingResponder(t, `"demo1"`, "datacenter:dc1, rack:rack2"))
+	assert.NotNil(rcc.deleteRackObjects(cc, status, 0, 1))
+	assert.Equal(4, cc.GetDCRackSize())
+	assert.Equal(4, len(status.CassandraRackStatus))
+
+	httpmock.RegisterResponder("POST", JolokiaURL(hostName, JolokiaPort),
+		describeRingResponder(t, `"demo1"`, "datacenter:dc1, rack:rack1"))
+	assert.Nil(rcc.deleteRackObjects(cc, status, 0, 1))
+	assert.Equal(3, cc.GetDCRackSize())
+	assert.Equal(3, len(status.CassandraRackStatus))
+	assert.NotContains(status.CassandraRackStatus, "dc1-rack2")
+	assert.Equal(api.ActionDeleteRack, status.LastClusterAction)
+	assert.Equal(true, rcc.needUpdate)
+
+	_, err := rcc.GetStatefulSet("ns", "cassandra-demo-dc1-rack2")
+	assert.NotNil(err)
+}
+
 //remove only a rack is not allowed
 func TestCheckNonAllowedChangesRemoveDCNot0(t *testing.T) {
 	assert := assert.New(t)
@@ -648,3 +987,34 @@ func TestHasChange(t *testing.T) {
 	assert.False(hasChange(changelog, diff.UPDATE, "DC.Rack"))
 
 }
+
+func TestForRequest(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	rcc.cc = cc
+	rcc.needUpdate = true
+
+	requestRcc := rcc.forRequest()
+	assert.Equal(rcc.client, requestRcc.client)
+	assert.Nil(requestRcc.cc)
+	assert.False(requestRcc.needUpdate)
+}
+
+func TestFinalizedOperationsPerCluster(t *testing.T) {
+	assert := assert.New(t)
+	_, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	otherCC := cc.DeepCopy()
+	otherCC.Name = "other-cluster"
+
+	finalizedOperations(cc)
+	sendFinalizedOperation(cc, finalizedOp{nil, "dc1-rack1", v1.Pod{}, api.OperationCleanup})
+	assert.Equal(1, len(finalizedOperations(cc)))
+	assert.Equal(0, len(finalizedOperations(otherCC)))
+
+	//An operation ending after the deletion of its cluster does not recreate its channel
+	key := types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name}
+	deleteFinalizedOperations(key)
+	sendFinalizedOperation(cc, finalizedOp{nil, "dc1-rack1", v1.Pod{}, api.OperationCleanup})
+	assert.NotContains(finalizedOps.channels, key)
+	deleteFinalizedOperations(types.NamespacedName{Namespace: otherCC.Namespace, Name: otherCC.Name})
+}
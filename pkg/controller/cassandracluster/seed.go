@@ -0,0 +1,178 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+//seedLabel is set to true on the seed Services
+const seedLabel = "cassandraclusters.db.orange.com.seed"
+
+//ensureSeedPods keeps the seeds of the status on ready pods and points the seed Services of the DCs at their pods
+//A seed whose pod is missing or not ready is replaced by a ready pod of the same DC, unless it is pinned by the
+//SeedOrdinals of its rack or an action is in progress in its rack
+func (rcc *ReconcileCassandraCluster) ensureSeedPods(cc *api.CassandraCluster, status *api.CassandraClusterStatus) error {
+	podList, err := rcc.ListPods(cc.Namespace, k8s.LabelsForCassandra(cc))
+	if err != nil {
+		return err
+	}
+	pods := map[string]*v1.Pod{}
+	for i := range podList.Items {
+		pods[podList.Items[i].Name] = &podList.Items[i]
+	}
+
+	if cc.Spec.AutoUpdateSeedList && !racksAreChanging(status) {
+		status.SeedList = adjustSeedList(cc, status.SeedList)
+	}
+
+	pinnedSeedList := cc.GetPinnedSeedList()
+	for i, seed := range status.SeedList {
+		if pod, ok := pods[seedPodName(seed)]; (ok && cassandraPodIsReady(pod)) || k8s.Contains(pinnedSeedList, seed) {
+			continue
+		}
+		dcRackName := seedDCRackName(cc, seed)
+		if dcRackStatus, ok := status.CassandraRackStatus[dcRackName]; ok &&
+			actionInProgress(dcRackStatus.CassandraLastAction.Status) {
+			continue
+		}
+		pod := seedCandidate(cc, status.SeedList, pods, dcRackName)
+		if pod == nil {
+			continue
+		}
+		status.SeedList[i] = seedFQDN(cc, pod.Name)
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "seed": seed,
+			"pod": pod.Name}).Info("Seed is not ready, promote another pod")
+		rcc.recordEvent(cc, v1.EventTypeNormal, reasonSeedPromoted, "Pod %s replaces the seed %s which is not ready",
+			pod.Name, seed)
+	}
+
+	//Without topology, the cluster has the default DC
+	dcsize := cc.GetDCSize()
+	if dcsize < 1 {
+		dcsize = 1
+	}
+	for dc := 0; dc < dcsize; dc++ {
+		if err = rcc.ensureCassandraSeedServices(cc, status, cc.GetDCName(dc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//adjustSeedList keeps the seeds of seedList and adds or removes seeds so each DC has as many seeds as in InitSeedList,
+//the seeds pinned by SeedOrdinals are always in the list
+func adjustSeedList(cc *api.CassandraCluster, seedList []string) []string {
+	wantedSeedList := cc.InitSeedList()
+	pinnedSeedList := cc.GetPinnedSeedList()
+	nbWantedSeeds := map[string]int{}
+	for _, seed := range wantedSeedList {
+		nbWantedSeeds[seedDCName(cc, seed)]++
+	}
+	//The places left by the pinned seeds are taken by the current seeds first
+	nbSeeds := map[string]int{}
+	for _, seed := range pinnedSeedList {
+		nbSeeds[seedDCName(cc, seed)]++
+	}
+
+	var newSeedList []string
+	for _, seed := range seedList {
+		dcName := seedDCName(cc, seed)
+		if k8s.Contains(pinnedSeedList, seed) {
+			newSeedList = append(newSeedList, seed)
+		} else if nbSeeds[dcName] < nbWantedSeeds[dcName] {
+			newSeedList = append(newSeedList, seed)
+			nbSeeds[dcName]++
+		}
+	}
+	for _, seed := range pinnedSeedList {
+		if !k8s.Contains(newSeedList, seed) {
+			newSeedList = append(newSeedList, seed)
+		}
+	}
+	for _, seed := range wantedSeedList {
+		dcName := seedDCName(cc, seed)
+		if nbSeeds[dcName] < nbWantedSeeds[dcName] && !k8s.Contains(newSeedList, seed) {
+			newSeedList = append(newSeedList, seed)
+			nbSeeds[dcName]++
+		}
+	}
+	return newSeedList
+}
+
+//seedCandidate returns the first ready pod of the DC of dcRackName which is not a seed, the pods of the rack
+//dcRackName come first
+func seedCandidate(cc *api.CassandraCluster, seedList []string, pods map[string]*v1.Pod,
+	dcRackName string) *v1.Pod {
+	dcRack := strings.SplitN(dcRackName, "-", 2)
+	dcName, rackName := dcRack[0], ""
+	if len(dcRack) > 1 {
+		rackName = dcRack[1]
+	}
+	var candidates []*v1.Pod
+	for _, pod := range pods {
+		if pod.Labels["cassandraclusters.db.orange.com.dc"] == dcName && cassandraPodIsReady(pod) &&
+			pod.DeletionTimestamp == nil && !k8s.Contains(seedList, seedFQDN(cc, pod.Name)) {
+			candidates = append(candidates, pod)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		iInRack := candidates[i].Labels["cassandraclusters.db.orange.com.rack"] == rackName
+		jInRack := candidates[j].Labels["cassandraclusters.db.orange.com.rack"] == rackName
+		if iInRack != jInRack {
+			return iInRack
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
+
+func actionInProgress(actionStatus string) bool {
+	return actionStatus == api.StatusToDo || actionStatus == api.StatusOngoing ||
+		actionStatus == api.StatusContinue || actionStatus == api.StatusFinalizing
+}
+
+//seedFQDN returns the name of the seed of the pod podName, as built by addNewSeed
+func seedFQDN(cc *api.CassandraCluster, podName string) string {
+	return fmt.Sprintf("%s.%s.%s", podName, cc.Name, cc.Namespace)
+}
+
+//seedPodName returns the name of the pod of a seed
+func seedPodName(seed string) string {
+	return strings.Split(seed, ".")[0]
+}
+
+//seedDCRackName returns the dc-rack name of a seed named <cluster>-<dc>-<rack>-<index>.<cluster>.<namespace>
+func seedDCRackName(cc *api.CassandraCluster, seed string) string {
+	dcRackName := strings.TrimPrefix(seedPodName(seed), cc.Name+"-")
+	if i := strings.LastIndex(dcRackName, "-"); i > 0 {
+		return dcRackName[:i]
+	}
+	return dcRackName
+}
+
+func seedDCName(cc *api.CassandraCluster, seed string) string {
+	return strings.Split(seedDCRackName(cc, seed), "-")[0]
+}
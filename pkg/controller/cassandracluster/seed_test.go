@@ -0,0 +1,206 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func helperCreateCassandraPod(t *testing.T, rcc *ReconcileCassandraCluster, cc *api.CassandraCluster, dcName,
+	rackName, name string, ready bool) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cc.Namespace,
+			Labels: k8s.MergeLabels(k8s.LabelsForCassandraDCRack(cc, dcName, rackName),
+				map[string]string{appsv1.StatefulSetPodNameLabel: name}),
+		},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Name: "cassandra", Ready: ready}},
+		},
+	}
+	if err := rcc.client.Create(context.TODO(), pod); err != nil {
+		t.Fatalf("can't create pod %s: %v", name, err)
+	}
+}
+
+//helperSeedServicePods returns the pods selected by the seed Services of the DC dcName, in the order of the seeds
+func helperSeedServicePods(t *testing.T, rcc *ReconcileCassandraCluster, cc *api.CassandraCluster,
+	dcName string) []string {
+	svcList, err := rcc.ListServices(cc.Namespace, k8s.MergeLabels(k8s.LabelsForCassandraDC(cc, dcName),
+		map[string]string{seedLabel: "true"}))
+	if err != nil {
+		t.Fatalf("can't list the seed services of %s: %v", dcName, err)
+	}
+	pods := make([]string, len(svcList.Items))
+	for _, svc := range svcList.Items {
+		index, err := strconv.Atoi(strings.TrimPrefix(svc.Name, cc.Name+"-"+dcName+"-seed-"))
+		if err != nil || index >= len(pods) {
+			t.Fatalf("unexpected seed service %s", svc.Name)
+		}
+		pods[index] = svc.Spec.Selector[appsv1.StatefulSetPodNameLabel]
+	}
+	return pods
+}
+
+func TestEnsureSeedPods(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	cc.Spec.SeedMode = api.SeedModeService
+	status := cc.Status.DeepCopy()
+	status.SeedList = cc.InitSeedList()
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		dcRackStatus.CassandraLastAction.Status = api.StatusDone
+	}
+
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack1", "cassandra-demo-dc1-rack1-0", false)
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack1", "cassandra-demo-dc1-rack1-1", true)
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack2", "cassandra-demo-dc1-rack2-0", true)
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack2", "cassandra-demo-dc1-rack2-1", true)
+	helperCreateCassandraPod(t, rcc, cc, "dc2", "rack1", "cassandra-demo-dc2-rack1-0", true)
+	//The Services of the seeds dc1 no longer has are deleted
+	assert.Nil(rcc.ensureCassandraSeedServices(cc, &api.CassandraClusterStatus{SeedList: []string{
+		"cassandra-demo-dc1-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack1-1.cassandra-demo.ns"}}, "dc1"))
+	assert.Equal([]string{"cassandra-demo-dc1-rack1-0", "cassandra-demo-dc1-rack2-0", "cassandra-demo-dc1-rack1-1"},
+		helperSeedServicePods(t, rcc, cc, "dc1"))
+
+	assert.Nil(rcc.ensureSeedPods(cc, status))
+
+	//The seed not ready is replaced by a ready pod of its rack, the seed of dc3 has no pod to replace it
+	assert.Equal([]string{
+		"cassandra-demo-dc1-rack1-1.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc3-rack1-0.cassandra-demo.ns"}, status.SeedList)
+	assert.Equal([]string{"cassandra-demo-dc1-rack1-1", "cassandra-demo-dc1-rack2-0"},
+		helperSeedServicePods(t, rcc, cc, "dc1"))
+	assert.Equal([]string{"cassandra-demo-dc2-rack1-0"}, helperSeedServicePods(t, rcc, cc, "dc2"))
+	assert.Equal([]string{"cassandra-demo-dc3-rack1-0"}, helperSeedServicePods(t, rcc, cc, "dc3"))
+}
+
+func TestEnsureSeedPodsNoReadyPods(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	cc.Spec.SeedMode = api.SeedModeService
+	status := cc.Status.DeepCopy()
+	status.SeedList = cc.InitSeedList()
+
+	//A new cluster whose pods are starting, none of them is ready
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack1", "cassandra-demo-dc1-rack1-0", false)
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack2", "cassandra-demo-dc1-rack2-0", false)
+	helperCreateCassandraPod(t, rcc, cc, "dc2", "rack1", "cassandra-demo-dc2-rack1-0", false)
+	helperCreateCassandraPod(t, rcc, cc, "dc3", "rack1", "cassandra-demo-dc3-rack1-0", false)
+
+	assert.Nil(rcc.ensureSeedPods(cc, status))
+	assert.Equal(cc.InitSeedList(), status.SeedList)
+
+	//The seeds given to Cassandra resolve to the addresses of their pods before they are ready
+	labels, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 0, 0)
+	ss := generateCassandraStatefulSet(cc, status, "dc1", "dc1-rack1", labels, nodeSelector, nil)
+	var seeds []string
+	for _, envVar := range ss.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name == "CASSANDRA_SEEDS" {
+			seeds = strings.Split(envVar.Value, ",")
+		}
+	}
+	var seedPods []string
+	for _, seed := range seeds {
+		svc := &v1.Service{}
+		err := rcc.client.Get(context.TODO(), types.NamespacedName{Name: strings.TrimSuffix(seed, "."+cc.Namespace),
+			Namespace: cc.Namespace}, svc)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		assert.Nil(err)
+		assert.True(svc.Spec.PublishNotReadyAddresses)
+		podList, err := rcc.ListPods(cc.Namespace, svc.Spec.Selector)
+		assert.Nil(err)
+		for _, pod := range podList.Items {
+			seedPods = append(seedPods, seedFQDN(cc, pod.Name))
+		}
+	}
+	assert.Equal(cc.InitSeedList(), seedPods)
+}
+
+func TestEnsureSeedPodsActionInProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	cc.Spec.SeedMode = api.SeedModeService
+	status := cc.Status.DeepCopy()
+	status.SeedList = cc.InitSeedList()
+
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack1", "cassandra-demo-dc1-rack1-0", false)
+	helperCreateCassandraPod(t, rcc, cc, "dc1", "rack1", "cassandra-demo-dc1-rack1-1", true)
+
+	//The nodes of dc1-rack1 are still initializing, its seed is kept
+	assert.Nil(rcc.ensureSeedPods(cc, status))
+	assert.Equal(cc.InitSeedList(), status.SeedList)
+	assert.Equal([]string{"cassandra-demo-dc1-rack1-0", "cassandra-demo-dc1-rack2-0"},
+		helperSeedServicePods(t, rcc, cc, "dc1"))
+}
+
+func TestAdjustSeedList(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+
+	//The seed of the removed DC dc4 and the extra seed of dc2 are dropped, dc3 gets its seed
+	assert.Equal([]string{
+		"cassandra-demo-dc1-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack1-3.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-2.cassandra-demo.ns",
+		"cassandra-demo-dc3-rack1-0.cassandra-demo.ns"}, adjustSeedList(cc, []string{
+		"cassandra-demo-dc1-rack2-0.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack1-3.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-2.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc4-rack1-0.cassandra-demo.ns"}))
+}
+
+func TestAdjustSeedListSeedOrdinals(t *testing.T) {
+	assert := assert.New(t)
+
+	_, cc := helperInitCluster(t, "cassandracluster-3DC.yaml")
+	cc.Spec.NodesPerRacks = 3
+	cc.Spec.Topology.DC[0].Rack[1].SeedOrdinals = []int32{2}
+
+	//The pinned seed is added and counted in the seeds of dc1
+	assert.Equal([]string{
+		"cassandra-demo-dc1-rack1-1.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack2-2.cassandra-demo.ns",
+		"cassandra-demo-dc1-rack1-0.cassandra-demo.ns",
+		"cassandra-demo-dc3-rack1-0.cassandra-demo.ns"}, adjustSeedList(cc, []string{
+		"cassandra-demo-dc1-rack1-1.cassandra-demo.ns",
+		"cassandra-demo-dc2-rack1-0.cassandra-demo.ns"}))
+}
@@ -16,9 +16,14 @@ package cassandracluster
 
 import (
 	"context"
+	"reflect"
 
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (rcc *ReconcileCassandraCluster) DeleteService(namespace, name string) error {
@@ -35,3 +40,32 @@ func (rcc *ReconcileCassandraCluster) DeleteService(namespace, name string) erro
 	}
 	return rcc.client.Delete(context.TODO(), svc)
 }
+
+//ListServices returns the Services of the namespace matching the labels of selector
+func (rcc *ReconcileCassandraCluster) ListServices(namespace string, selector map[string]string) (*v1.ServiceList,
+	error) {
+	opt := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.SelectorFromSet(selector),
+	}
+	sl := &v1.ServiceList{}
+	return sl, rcc.client.List(context.TODO(), opt, sl)
+}
+
+//CreateOrUpdateService creates the Service svc or updates the labels and the selector of the stored one
+func (rcc *ReconcileCassandraCluster) CreateOrUpdateService(svc *v1.Service) error {
+	storedSvc := &v1.Service{}
+	err := rcc.client.Get(context.TODO(), types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, storedSvc)
+	if apierrors.IsNotFound(err) {
+		return rcc.client.Create(context.TODO(), svc)
+	}
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(storedSvc.Labels, svc.Labels) && reflect.DeepEqual(storedSvc.Spec.Selector, svc.Spec.Selector) {
+		return nil
+	}
+	storedSvc.Labels = svc.Labels
+	storedSvc.Spec.Selector = svc.Spec.Selector
+	return rcc.client.Update(context.TODO(), storedSvc)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -85,6 +86,7 @@ func (rcc *ReconcileCassandraCluster) CreateStatefulSet(statefulSet *appsv1.Stat
 
 //UpdateStatefulSet updates an existing statefulset ss
 func (rcc *ReconcileCassandraCluster) UpdateStatefulSet(statefulSet *appsv1.StatefulSet) error {
+	cluster := statefulSet.Labels["cassandracluster"]
 	err := rcc.client.Update(context.TODO(), statefulSet)
 	if err != nil {
 		if !apierrors.IsAlreadyExists(err) {
@@ -99,16 +101,16 @@ func (rcc *ReconcileCassandraCluster) UpdateStatefulSet(statefulSet *appsv1.Stat
 			return false, fmt.Errorf("failed to get cassandra statefulset: %cc", err)
 		}
 		if statefulSet.ResourceVersion != newSts.ResourceVersion {
-			logrus.WithFields(logrus.Fields{"cluster": rcc.cc.Name, "statefulset": statefulSet.Name}).Info(
+			logrus.WithFields(logrus.Fields{"cluster": cluster, "statefulset": statefulSet.Name}).Info(
 				"Statefulset has new revision, we continue")
 			return true, nil
 		}
-		logrus.WithFields(logrus.Fields{"cluster": rcc.cc.Name, "statefulset": statefulSet.Name}).Info(
+		logrus.WithFields(logrus.Fields{"cluster": cluster, "statefulset": statefulSet.Name}).Info(
 			"Waiting for new version of statefulset")
 		return false, nil
 	})
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"cluster": rcc.cc.Name, "statefulset": statefulSet.Name}).Info(
+		logrus.WithFields(logrus.Fields{"cluster": cluster, "statefulset": statefulSet.Name}).Info(
 			"Error Waiting for sts change")
 	}
 	return nil
@@ -120,15 +122,35 @@ func statefulSetsAreEqual(sts1, sts2 *appsv1.StatefulSet) bool {
 	//updates to statefulset spec for fields other than 'replicas', 'template', and 'updateStrategy' are forbidden.
 
 	//Things we won't check :
-	sts1.Spec.Template.Spec.SchedulerName = sts2.Spec.Template.Spec.SchedulerName
+	//The default scheduler is set by kubernetes when none is given
+	for _, sts := range []*appsv1.StatefulSet{sts1, sts2} {
+		if sts.Spec.Template.Spec.SchedulerName == "" {
+			sts.Spec.Template.Spec.SchedulerName = v1.DefaultSchedulerName
+		}
+	}
+	//serviceAccount is copied from serviceAccountName by kubernetes
+	sts1.Spec.Template.Spec.DeprecatedServiceAccount = sts2.Spec.Template.Spec.DeprecatedServiceAccount
 	sts1.Spec.Template.Spec.DNSPolicy = sts2.Spec.Template.Spec.DNSPolicy // ClusterFirst
-	sts1.Spec.Template.Spec.Containers[0].LivenessProbe.SuccessThreshold = sts2.Spec.Template.Spec.Containers[0].LivenessProbe.SuccessThreshold
-	sts1.Spec.Template.Spec.Containers[0].LivenessProbe.FailureThreshold = sts2.Spec.Template.Spec.Containers[0].LivenessProbe.FailureThreshold
-	sts1.Spec.Template.Spec.Containers[0].ReadinessProbe.SuccessThreshold = sts2.Spec.Template.Spec.Containers[0].ReadinessProbe.SuccessThreshold
-	sts1.Spec.Template.Spec.Containers[0].ReadinessProbe.FailureThreshold = sts2.Spec.Template.Spec.Containers[0].ReadinessProbe.FailureThreshold
+	//The containers are matched by name as the cassandra container may have sidecars
+	for i := range sts1.Spec.Template.Spec.Containers {
+		container1 := &sts1.Spec.Template.Spec.Containers[i]
+		for _, container2 := range sts2.Spec.Template.Spec.Containers {
+			if container1.Name != container2.Name {
+				continue
+			}
+			if container1.LivenessProbe != nil && container2.LivenessProbe != nil {
+				container1.LivenessProbe.SuccessThreshold = container2.LivenessProbe.SuccessThreshold
+				container1.LivenessProbe.FailureThreshold = container2.LivenessProbe.FailureThreshold
+			}
+			if container1.ReadinessProbe != nil && container2.ReadinessProbe != nil {
+				container1.ReadinessProbe.SuccessThreshold = container2.ReadinessProbe.SuccessThreshold
+				container1.ReadinessProbe.FailureThreshold = container2.ReadinessProbe.FailureThreshold
+			}
 
-	sts1.Spec.Template.Spec.Containers[0].TerminationMessagePath = sts2.Spec.Template.Spec.Containers[0].TerminationMessagePath
-	sts1.Spec.Template.Spec.Containers[0].TerminationMessagePolicy = sts2.Spec.Template.Spec.Containers[0].TerminationMessagePolicy
+			container1.TerminationMessagePath = container2.TerminationMessagePath
+			container1.TerminationMessagePolicy = container2.TerminationMessagePolicy
+		}
+	}
 
 	//some defaultMode changes make falsepositif, so we bypass this, we already have check on configmap changes
 	sts1.Spec.VolumeClaimTemplates = sts2.Spec.VolumeClaimTemplates
@@ -183,8 +205,11 @@ func (rcc *ReconcileCassandraCluster) CreateOrUpdateStatefulSet(statefulSet *app
 
 	// Already exists, need to Update.
 	statefulSet.ResourceVersion = rcc.storedStatefulSet.ResourceVersion
-	// We grab the existing labels and add them back to the generated StatefulSet
-	statefulSet.Spec.Template.SetLabels(rcc.storedStatefulSet.Spec.Template.GetLabels())
+	//The volumeClaimTemplates can't be updated, they change when expandDataPVCs recreates the statefulset
+	statefulSet.Spec.VolumeClaimTemplates = rcc.storedStatefulSet.Spec.VolumeClaimTemplates
+	// We grab the existing labels and add them back to the generated StatefulSet with the new pod labels
+	statefulSet.Spec.Template.SetLabels(k8s.MergeLabels(rcc.storedStatefulSet.Spec.Template.GetLabels(),
+		statefulSet.Spec.Template.GetLabels()))
 
 	//If UpdateSeedList=Ongoing, we allow the new SeedList to be propagated into the Statefulset
 	//and change the status to Finalizing (it start a RollingUpdate)
@@ -196,11 +221,15 @@ func (rcc *ReconcileCassandraCluster) CreateOrUpdateStatefulSet(statefulSet *app
 	} else {
 
 		//We need to keep the SeedList from the stored statefulset
-		for i, env := range statefulSet.Spec.Template.Spec.Containers[0].Env {
-			if env.Name == "CASSANDRA_SEEDS" {
-				for _, oldenv := range rcc.storedStatefulSet.Spec.Template.Spec.Containers[0].Env {
-					if oldenv.Name == "CASSANDRA_SEEDS" && env.Value != oldenv.Value {
-						statefulSet.Spec.Template.Spec.Containers[0].Env[i].Value = oldenv.Value
+		container := cassandraContainer(&statefulSet.Spec.Template.Spec)
+		storedContainer := cassandraContainer(&rcc.storedStatefulSet.Spec.Template.Spec)
+		if container != nil && storedContainer != nil {
+			for i, env := range container.Env {
+				if env.Name == "CASSANDRA_SEEDS" {
+					for _, oldenv := range storedContainer.Env {
+						if oldenv.Name == "CASSANDRA_SEEDS" && env.Value != oldenv.Value {
+							container.Env[i].Value = oldenv.Value
+						}
 					}
 				}
 			}
@@ -255,7 +284,11 @@ func (rcc *ReconcileCassandraCluster) CreateOrUpdateStatefulSet(statefulSet *app
 
 func getStoredSeedListTab(storedStatefulSet *appsv1.StatefulSet) []string {
 
-	for _, env := range storedStatefulSet.Spec.Template.Spec.Containers[0].Env {
+	container := cassandraContainer(&storedStatefulSet.Spec.Template.Spec)
+	if container == nil {
+		return []string{}
+	}
+	for _, env := range container.Env {
 		if env.Name == "CASSANDRA_SEEDS" {
 			return strings.Split(env.Value, ",")
 		}
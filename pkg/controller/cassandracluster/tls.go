@@ -0,0 +1,450 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	tlsVolumeName = "cassandra-tls"
+	//tlsMountPath is where the keystores and the truststore are mounted in the cassandra container
+	tlsMountPath = "/etc/cassandra-tls"
+	//tlsStoreType is the store_type of the keystores and the truststore
+	tlsStoreType = "PKCS12"
+
+	tlsKeystorePasswordKey = "keystore-password"
+	tlsTruststoreKey       = "truststore.p12"
+	//tlsKeystoreSuffix is the suffix of the keys of the keystores of the pods in the keystore Secret
+	tlsKeystoreSuffix = ".keystore.p12"
+
+	tlsCAValidity = 10 * 365 * 24 * time.Hour
+	tlsKeySize    = 2048
+
+	annotationTLSGeneration    = "cassandraclusters.db.orange.com/tls-generation"
+	annotationTLSNotAfter      = "cassandraclusters.db.orange.com/tls-not-after"
+	annotationTLSCAFingerprint = "cassandraclusters.db.orange.com/tls-ca-fingerprint"
+)
+
+//tlsCASecretName returns the name of the Secret holding the CA signing the certificates of the nodes
+func tlsCASecretName(cc *api.CassandraCluster) string {
+	if cc.Spec.TLS.CASecret != "" {
+		return cc.Spec.TLS.CASecret
+	}
+	return cc.Name + "-ca"
+}
+
+//tlsKeystoreSecretName returns the name of the Secret holding the keystores of the nodes
+func tlsKeystoreSecretName(cc *api.CassandraCluster) string {
+	return cc.Name + "-tls"
+}
+
+//tlsPodNames returns the names of all the pods of the topology of the cluster
+func tlsPodNames(cc *api.CassandraCluster) []string {
+	var podNames []string
+	for _, dcRackName := range cc.GetDCRackNames() {
+		for i := int32(0); i < cc.GetNodesPerRacks(dcRackName); i++ {
+			podNames = append(podNames, fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, i))
+		}
+	}
+	return podNames
+}
+
+//tlsFingerprint returns the SHA-256 fingerprint of a certificate
+func tlsFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+//ensureCassandraTLS issues the keystores of the nodes of the cluster in the keystore Secret
+//All the certificates are issued again when they are about to expire, the racks are then restarted one by one as
+//their TLSGeneration is no longer the one of the cluster
+//When the CA changes, the new CA is first added to the truststore and the racks are restarted, then the certificates
+//are issued by the new CA and the racks are restarted again, the previous CA is removed from the truststore once all
+//the racks have been restarted
+func (rcc *ReconcileCassandraCluster) ensureCassandraTLS(cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus) error {
+	if cc.Spec.TLS == nil {
+		status.TLS = nil
+		for _, dcRackStatus := range status.CassandraRackStatus {
+			dcRackStatus.TLSGeneration = ""
+		}
+		return nil
+	}
+	if err := cc.Spec.TLS.Validate(); err != nil {
+		return err
+	}
+
+	caCert, caKey, err := rcc.getOrCreateTLSCA(cc)
+	if err != nil {
+		return err
+	}
+
+	secret := &v1.Secret{}
+	err = rcc.client.Get(context.TODO(), types.NamespacedName{Namespace: cc.Namespace,
+		Name: tlsKeystoreSecretName(cc)}, secret)
+	create := apierrors.IsNotFound(err)
+	if err != nil && !create {
+		return fmt.Errorf("failed to get TLS keystore secret: %v", err)
+	}
+	if create {
+		secret = &v1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Secret",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tlsKeystoreSecretName(cc),
+				Namespace: cc.Namespace,
+				Labels:    k8s.LabelsForCassandra(cc),
+			},
+		}
+		k8s.AddOwnerRefToObject(secret, k8s.AsOwner(cc))
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	password := string(secret.Data[tlsKeystorePasswordKey])
+	if password == "" {
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	fingerprint := tlsFingerprint(caCert)
+	var trusted []*x509.Certificate
+	if !create {
+		//An unreadable truststore is replaced with the new certificates
+		trusted, _ = pkcs12.DecodeTrustStore(secret.Data[tlsTruststoreKey], password)
+	}
+	rolledOut := tlsRolledOut(status, secret.Annotations[annotationTLSGeneration])
+	oldFingerprint := secret.Annotations[annotationTLSCAFingerprint]
+	rotating := !create && oldFingerprint != "" && oldFingerprint != fingerprint
+	notAfter, err := time.Parse(time.RFC3339, secret.Annotations[annotationTLSNotAfter])
+	var renew, trustNewCA, untrustOldCAs bool
+	switch {
+	case rotating && !tlsTrusts(trusted, caCert):
+		//The nodes must trust the new CA before any node presents a certificate signed by it
+		trustNewCA = true
+		trusted = append(trusted, caCert)
+	case rotating:
+		//The certificates are issued by the new CA once all the racks have been restarted with the new truststore
+		renew = rolledOut
+	case err != nil || oldFingerprint != fingerprint || now.Add(cc.Spec.TLS.GetRenewBefore()).After(notAfter):
+		renew = true
+		if !tlsTrusts(trusted, caCert) {
+			trusted = []*x509.Certificate{caCert}
+		}
+	case len(trusted) > 1 && rolledOut:
+		//All the nodes present certificates issued by the new CA, the previous CAs are no longer trusted
+		untrustOldCAs = true
+		trusted = []*x509.Certificate{caCert}
+	}
+	changed := create || renew || trustNewCA || untrustOldCAs
+
+	if renew || trustNewCA || untrustOldCAs {
+		truststore, err := pkcs12.EncodeTrustStore(rand.Reader, trusted, password)
+		if err != nil {
+			return fmt.Errorf("failed to encode TLS truststore: %v", err)
+		}
+		if renew || secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[tlsKeystorePasswordKey] = []byte(password)
+		secret.Data[tlsTruststoreKey] = truststore
+	}
+	if trustNewCA {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Info("Trust the new CA before issuing the certificates")
+		secret.Annotations[annotationTLSGeneration] = k8s.LabelTime()
+	}
+	if renew {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Info("Issue the certificates of the nodes")
+		notAfter = now.Add(cc.Spec.TLS.GetCertificateDuration()).Truncate(time.Second)
+		secret.Annotations[annotationTLSGeneration] = k8s.LabelTime()
+		secret.Annotations[annotationTLSNotAfter] = notAfter.UTC().Format(time.RFC3339)
+		secret.Annotations[annotationTLSCAFingerprint] = fingerprint
+	}
+
+	//Keystores of new pods are added to the current generation, the ones of removed pods are dropped
+	keystores := map[string]bool{}
+	for _, podName := range tlsPodNames(cc) {
+		key := podName + tlsKeystoreSuffix
+		keystores[key] = true
+		if _, ok := secret.Data[key]; ok {
+			continue
+		}
+		keystore, err := issueTLSKeystore(cc, podName, caCert, caKey, now, notAfter, password)
+		if err != nil {
+			return err
+		}
+		secret.Data[key] = keystore
+		changed = true
+	}
+	for key := range secret.Data {
+		if strings.HasSuffix(key, tlsKeystoreSuffix) && !keystores[key] {
+			delete(secret.Data, key)
+			changed = true
+		}
+	}
+
+	if create {
+		if err = rcc.client.Create(context.TODO(), secret); err != nil {
+			return fmt.Errorf("failed to create TLS keystore secret: %v", err)
+		}
+	} else if changed {
+		if err = rcc.client.Update(context.TODO(), secret); err != nil {
+			return fmt.Errorf("failed to update TLS keystore secret: %v", err)
+		}
+	}
+
+	if trustNewCA {
+		rcc.recordEvent(cc, v1.EventTypeNormal, reasonTLSCertificatesRenewed,
+			"New CA added to the truststore, the certificates are issued by it once the racks are restarted")
+	}
+	if renew && !create {
+		rcc.recordEvent(cc, v1.EventTypeNormal, reasonTLSCertificatesRenewed,
+			"Certificates of the nodes renewed, they expire on %s", notAfter.UTC().Format(time.RFC3339))
+	}
+	updateTLSStatus(status, secret.Annotations[annotationTLSGeneration], notAfter)
+	return nil
+}
+
+//tlsTrusts returns true if caCert is one of the trusted certificates
+func tlsTrusts(trusted []*x509.Certificate, caCert *x509.Certificate) bool {
+	for _, cert := range trusted {
+		if cert.Equal(caCert) {
+			return true
+		}
+	}
+	return false
+}
+
+//tlsRolledOut returns true when the nodes of all the racks have been restarted with the certificates of generation
+func tlsRolledOut(status *api.CassandraClusterStatus, generation string) bool {
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		lastAction := dcRackStatus.CassandraLastAction
+		if dcRackStatus.TLSGeneration != generation ||
+			(lastAction.Name == api.ActionRollingRestart && lastAction.Status != api.StatusDone) {
+			return false
+		}
+	}
+	return true
+}
+
+//updateTLSStatus stores the generation of the certificates in the status
+//Racks which have not yet a TLSGeneration get the previous one of the cluster, so that a rack started before a
+//renewal is restarted as well
+func updateTLSStatus(status *api.CassandraClusterStatus, generation string, notAfter time.Time) {
+	previous := generation
+	if status.TLS != nil && status.TLS.Generation != "" {
+		previous = status.TLS.Generation
+	}
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		if dcRackStatus.TLSGeneration == "" {
+			dcRackStatus.TLSGeneration = previous
+		}
+	}
+	status.TLS = &api.TLSStatus{
+		Generation: generation,
+		NotAfter:   &metav1.Time{Time: notAfter},
+	}
+}
+
+//getOrCreateTLSCA returns the CA of the cluster, it is generated if no CA Secret is given and it does not exist
+func (rcc *ReconcileCassandraCluster) getOrCreateTLSCA(cc *api.CassandraCluster) (*x509.Certificate,
+	crypto.Signer, error) {
+	secret := &v1.Secret{}
+	err := rcc.client.Get(context.TODO(), types.NamespacedName{Namespace: cc.Namespace,
+		Name: tlsCASecretName(cc)}, secret)
+	if err != nil {
+		if !apierrors.IsNotFound(err) || cc.Spec.TLS.CASecret != "" {
+			return nil, nil, fmt.Errorf("failed to get TLS CA secret %s: %v", tlsCASecretName(cc), err)
+		}
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Infof("Generate TLS CA in secret %s",
+			tlsCASecretName(cc))
+		if secret, err = generateTLSCASecret(cc); err != nil {
+			return nil, nil, err
+		}
+		if err = rcc.client.Create(context.TODO(), secret); err != nil {
+			return nil, nil, fmt.Errorf("failed to create TLS CA secret: %v", err)
+		}
+	}
+	return parseTLSCA(secret)
+}
+
+//generateTLSCASecret returns a Secret of type kubernetes.io/tls holding a new self signed CA
+func generateTLSCASecret(cc *api.CassandraCluster) (*v1.Secret, error) {
+	key, err := rsa.GenerateKey(rand.Reader, tlsKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TLS CA key: %v", err)
+	}
+	serialNumber, err := generateTLSSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: cc.Name + " CA", Organization: []string{cc.Name}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(tlsCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS CA certificate: %v", err)
+	}
+
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tlsCASecretName(cc),
+			Namespace: cc.Namespace,
+			Labels:    k8s.LabelsForCassandra(cc),
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			v1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		},
+	}
+	k8s.AddOwnerRefToObject(secret, k8s.AsOwner(cc))
+	return secret, nil
+}
+
+//parseTLSCA returns the certificate and the key of the CA stored in secret
+func parseTLSCA(secret *v1.Secret) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(secret.Data[v1.TLSCertKey])
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("No PEM certificate in %s of TLS CA secret %s", v1.TLSCertKey, secret.Name)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse TLS CA certificate: %v", err)
+	}
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("Certificate of TLS CA secret %s is not a CA", secret.Name)
+	}
+
+	keyBlock, _ := pem.Decode(secret.Data[v1.TLSPrivateKeyKey])
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("No PEM key in %s of TLS CA secret %s", v1.TLSPrivateKeyKey, secret.Name)
+	}
+	var key interface{}
+	switch keyBlock.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse TLS CA key: %v", err)
+	}
+	switch signer := key.(type) {
+	case *rsa.PrivateKey:
+		return cert, signer, nil
+	case *ecdsa.PrivateKey:
+		return cert, signer, nil
+	}
+	return nil, nil, fmt.Errorf("Unsupported key type %T in TLS CA secret %s", key, secret.Name)
+}
+
+//issueTLSKeystore returns the PKCS12 keystore of podName holding its key and its certificate signed by the CA
+func issueTLSKeystore(cc *api.CassandraCluster, podName string, caCert *x509.Certificate, caKey crypto.Signer,
+	notBefore time.Time, notAfter time.Time, password string) ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, tlsKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TLS key of %s: %v", podName, err)
+	}
+	serialNumber, err := generateTLSSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	//The pods are reached through the headless service of the cluster
+	host := podName + "." + cc.Name
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: podName, Organization: []string{cc.Name}},
+		DNSNames: []string{podName, host, host + "." + cc.Namespace, host + "." + cc.Namespace + ".svc",
+			host + "." + cc.Namespace + ".svc.cluster.local"},
+		NotBefore:   notBefore.Add(-time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS certificate of %s: %v", podName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate of %s: %v", podName, err)
+	}
+	keystore, err := pkcs12.Encode(rand.Reader, key, cert, []*x509.Certificate{caCert}, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode TLS keystore of %s: %v", podName, err)
+	}
+	return keystore, nil
+}
+
+func generateTLSSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TLS serial number: %v", err)
+	}
+	return serialNumber, nil
+}
+
+//generatePassword returns a random password for the keystores and the roles created by the Operator
+func generatePassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func helperGetSecret(t *testing.T, rcc *ReconcileCassandraCluster, namespace, name string) *v1.Secret {
+	secret := &v1.Secret{}
+	if err := rcc.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name},
+		secret); err != nil {
+		t.Fatalf("Secret %s not found: %v", name, err)
+	}
+	return secret
+}
+
+func TestEnsureCassandraTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	cc.Spec.TLS = &api.TLSSpec{ClientEncryption: true}
+
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+
+	caSecret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-ca")
+	caCert, _, err := parseTLSCA(caSecret)
+	assert.Nil(err)
+
+	secret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	password := string(secret.Data[tlsKeystorePasswordKey])
+	assert.NotEmpty(password)
+	trusted, err := pkcs12.DecodeTrustStore(secret.Data[tlsTruststoreKey], password)
+	assert.Nil(err)
+	assert.Equal(caCert.Raw, trusted[0].Raw)
+
+	podNames := []string{"cassandra-demo-dc1-rack1-0", "cassandra-demo-dc1-rack2-0", "cassandra-demo-dc2-rack1-0"}
+	assert.Equal(podNames, tlsPodNames(cc))
+	assert.Equal(len(podNames)+2, len(secret.Data))
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, podName := range podNames {
+		_, cert, chain, err := pkcs12.DecodeChain(secret.Data[podName+tlsKeystoreSuffix], password)
+		assert.Nil(err)
+		assert.Equal(caCert.Raw, chain[0].Raw)
+		assert.Equal(podName, cert.Subject.CommonName)
+		assert.Contains(cert.DNSNames, podName+".cassandra-demo."+cc.Namespace)
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: podName,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}})
+		assert.Nil(err)
+	}
+
+	generation := secret.Annotations[annotationTLSGeneration]
+	assert.Equal(generation, status.TLS.Generation)
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		assert.Equal(generation, dcRackStatus.TLSGeneration)
+	}
+	assert.False(UpdateStatusIfTLSCertificatesRenewed(cc, "dc1-rack1", nil, status))
+
+	//A new pod gets a keystore without renewing the others
+	keystore := secret.Data["cassandra-demo-dc1-rack1-0"+tlsKeystoreSuffix]
+	cc.Spec.NodesPerRacks = 2
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret = helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	assert.Equal(keystore, secret.Data["cassandra-demo-dc1-rack1-0"+tlsKeystoreSuffix])
+	assert.NotEmpty(secret.Data["cassandra-demo-dc1-rack1-1"+tlsKeystoreSuffix])
+	assert.Equal(generation, status.TLS.Generation)
+
+	//Certificates close to their expiration are all renewed
+	secret.Annotations[annotationTLSGeneration] = "20190101T000000"
+	secret.Annotations[annotationTLSNotAfter] = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	assert.Nil(rcc.client.Update(context.TODO(), secret))
+	status.TLS.Generation = "20190101T000000"
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		dcRackStatus.TLSGeneration = "20190101T000000"
+	}
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret = helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	assert.NotEqual(keystore, secret.Data["cassandra-demo-dc1-rack1-0"+tlsKeystoreSuffix])
+	assert.NotEqual("20190101T000000", status.TLS.Generation)
+	assert.True(status.TLS.NotAfter.After(time.Now().Add(api.DefaultTLSCertificateDuration - time.Hour)))
+
+	//The racks are restarted to load the new certificates
+	assert.True(UpdateStatusIfTLSCertificatesRenewed(cc, "dc1-rack1", nil, status))
+	lastAction := status.CassandraRackStatus["dc1-rack1"].CassandraLastAction
+	assert.Equal(api.ActionRollingRestart, lastAction.Name)
+	assert.Equal(api.StatusToDo, lastAction.Status)
+	assert.False(UpdateStatusIfTLSCertificatesRenewed(cc, "dc1-rack1", nil, status))
+
+	//Disabling TLS clears the status
+	cc.Spec.TLS = nil
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	assert.Nil(status.TLS)
+	assert.Empty(status.CassandraRackStatus["dc1-rack2"].TLSGeneration)
+}
+
+func TestEnsureCassandraTLSWithCASecret(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	cc.Spec.TLS = &api.TLSSpec{CASecret: "my-ca"}
+
+	assert.NotNil(rcc.ensureCassandraTLS(cc, status))
+
+	caSecret, err := generateTLSCASecret(cc)
+	assert.Nil(err)
+	assert.Equal("my-ca", caSecret.Name)
+	assert.Nil(rcc.client.Create(context.TODO(), caSecret))
+
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	caCert, _, _ := parseTLSCA(caSecret)
+	secret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	assert.Equal(tlsFingerprint(caCert), secret.Annotations[annotationTLSCAFingerprint])
+
+	cc.Spec.TLS.InternodeEncryption = "everything"
+	assert.NotNil(rcc.ensureCassandraTLS(cc, status))
+}
+
+//helperRollOutTLS sets the generation of the keystore Secret and restarts all the racks with it
+func helperRollOutTLS(t *testing.T, rcc *ReconcileCassandraCluster, cc *api.CassandraCluster,
+	status *api.CassandraClusterStatus, generation string) {
+	secret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	secret.Annotations[annotationTLSGeneration] = generation
+	if err := rcc.client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("can't update Secret: %v", err)
+	}
+	status.TLS.Generation = generation
+	for _, dcRackStatus := range status.CassandraRackStatus {
+		dcRackStatus.TLSGeneration = generation
+		dcRackStatus.CassandraLastAction.Name = api.ActionRollingRestart
+		dcRackStatus.CassandraLastAction.Status = api.StatusDone
+	}
+}
+
+func helperTrustedCAs(t *testing.T, secret *v1.Secret) []*x509.Certificate {
+	trusted, err := pkcs12.DecodeTrustStore(secret.Data[tlsTruststoreKey],
+		string(secret.Data[tlsKeystorePasswordKey]))
+	if err != nil {
+		t.Fatalf("can't decode truststore: %v", err)
+	}
+	return trusted
+}
+
+func TestEnsureCassandraTLSCARotation(t *testing.T) {
+	assert := assert.New(t)
+
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+	status := cc.Status.DeepCopy()
+	cc.Spec.TLS = &api.TLSSpec{}
+	keystoreKey := "cassandra-demo-dc1-rack1-0" + tlsKeystoreSuffix
+
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	helperRollOutTLS(t, rcc, cc, status, "20190101T000000")
+	caSecret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-ca")
+	oldCACert, _, _ := parseTLSCA(caSecret)
+	keystore := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls").Data[keystoreKey]
+
+	newCASecret, err := generateTLSCASecret(cc)
+	assert.Nil(err)
+	caSecret.Data = newCASecret.Data
+	assert.Nil(rcc.client.Update(context.TODO(), caSecret))
+	newCACert, _, _ := parseTLSCA(newCASecret)
+
+	//The new CA is trusted first, the certificates issued by the previous CA are kept
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret := helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	trusted := helperTrustedCAs(t, secret)
+	assert.Equal(2, len(trusted))
+	assert.True(tlsTrusts(trusted, oldCACert))
+	assert.True(tlsTrusts(trusted, newCACert))
+	assert.Equal(keystore, secret.Data[keystoreKey])
+	assert.Equal(tlsFingerprint(oldCACert), secret.Annotations[annotationTLSCAFingerprint])
+	assert.NotEqual("20190101T000000", status.TLS.Generation)
+
+	//Nothing changes while the racks are restarted
+	assert.True(UpdateStatusIfTLSCertificatesRenewed(cc, "dc1-rack1", nil, status))
+	generation := status.TLS.Generation
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret = helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	assert.Equal(keystore, secret.Data[keystoreKey])
+	assert.Equal(generation, status.TLS.Generation)
+
+	//The certificates are issued by the new CA, both CAs are still trusted
+	helperRollOutTLS(t, rcc, cc, status, "20190101T000001")
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret = helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	assert.Equal(2, len(helperTrustedCAs(t, secret)))
+	_, _, chain, err := pkcs12.DecodeChain(secret.Data[keystoreKey], string(secret.Data[tlsKeystorePasswordKey]))
+	assert.Nil(err)
+	assert.Equal(newCACert.Raw, chain[0].Raw)
+	assert.Equal(tlsFingerprint(newCACert), secret.Annotations[annotationTLSCAFingerprint])
+	assert.NotEqual("20190101T000001", status.TLS.Generation)
+	keystore = secret.Data[keystoreKey]
+
+	//The previous CA is dropped once all the racks are restarted with the new certificates
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	assert.Equal(2, len(helperTrustedCAs(t, helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls"))))
+	helperRollOutTLS(t, rcc, cc, status, "20190101T000002")
+	assert.Nil(rcc.ensureCassandraTLS(cc, status))
+	secret = helperGetSecret(t, rcc, cc.Namespace, "cassandra-demo-tls")
+	trusted = helperTrustedCAs(t, secret)
+	assert.Equal(1, len(trusted))
+	assert.Equal(newCACert.Raw, trusted[0].Raw)
+	assert.Equal(keystore, secret.Data[keystoreKey])
+	assert.Equal("20190101T000002", status.TLS.Generation)
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//nonRackNameCharacters are removed from the zones to name their racks, the name of a rack can't contain '-'
+var nonRackNameCharacters = regexp.MustCompile("[^a-z0-9]")
+
+//zoneRackName returns the name of the rack generated for zone
+func zoneRackName(zone string) string {
+	return nonRackNameCharacters.ReplaceAllString(strings.ToLower(zone), "")
+}
+
+//listZones returns the sorted values of the label key of the nodes matching selector
+func (rcc *ReconcileCassandraCluster) listZones(selector map[string]string, key string) ([]string, error) {
+	nodes := &v1.NodeList{}
+	opt := &client.ListOptions{LabelSelector: labels.SelectorFromSet(selector)}
+	if err := rcc.client.List(context.TODO(), opt, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	zones := []string{}
+	for _, node := range nodes.Items {
+		zone, ok := node.Labels[key]
+		if !ok || zone == "" {
+			continue
+		}
+		if !k8s.Contains(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+//addZoneRacks adds to dc a rack for each zone, targeting the nodes whose NodeTopologyKey label is the zone.
+//It returns the names of the racks added
+func addZoneRacks(dc *api.DC, zones []string) []string {
+	var rackNames []string
+	for _, zone := range zones {
+		rackName := zoneRackName(zone)
+		if rackName == "" || k8s.Contains(rackNames, rackName) {
+			logrus.Warningf("No rack created in DC %s for zone %s, its name is empty or already used",
+				dc.Name, zone)
+			continue
+		}
+		dc.Rack = append(dc.Rack, api.Rack{
+			Name:   rackName,
+			Labels: map[string]string{dc.NodeTopologyKey: zone},
+		})
+		rackNames = append(rackNames, rackName)
+	}
+	return rackNames
+}
+
+//generateTopologyRacks creates the racks of the DCs of cc having a NodeTopologyKey and no rack, one per zone found
+//on the nodes matching the labels of the DC. It returns true if cc has been changed and must be updated
+func (rcc *ReconcileCassandraCluster) generateTopologyRacks(cc *api.CassandraCluster) (bool, error) {
+	changed := false
+	for i := range cc.Spec.Topology.DC {
+		dc := &cc.Spec.Topology.DC[i]
+		if dc.NodeTopologyKey == "" || len(dc.Rack) > 0 {
+			continue
+		}
+		zones, err := rcc.listZones(dc.Labels, dc.NodeTopologyKey)
+		if err != nil {
+			return false, err
+		}
+		rackNames := addZoneRacks(dc, zones)
+		if len(rackNames) == 0 {
+			err = fmt.Errorf("no node with the label %s found for DC %s", dc.NodeTopologyKey, dc.Name)
+			rcc.recordEvent(cc, v1.EventTypeWarning, reasonTopologyFailed, "Racks not created: %v", err)
+			return false, err
+		}
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name, "dc": dc.Name}).Infof(
+			"Racks %v created from the label %s of the nodes", rackNames, dc.NodeTopologyKey)
+		rcc.recordEvent(cc, v1.EventTypeNormal, reasonTopologyRacksCreated, "Racks %v created in DC %s",
+			rackNames, dc.Name)
+		changed = true
+	}
+	return changed, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func helperCreateNode(t *testing.T, rcc *ReconcileCassandraCluster, name string, labels map[string]string) {
+	node := &v1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+	if err := rcc.client.Create(context.TODO(), node); err != nil {
+		t.Fatalf("create node: (%v)", err)
+	}
+}
+
+func TestZoneRackName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("euwest1a", zoneRackName("eu-west-1a"))
+	assert.Equal("zonea", zoneRackName("Zone_A"))
+	assert.Equal("", zoneRackName("--"))
+}
+
+func TestGenerateTopologyRacks(t *testing.T) {
+	assert := assert.New(t)
+	rcc, cc := helperInitCluster(t, "cassandracluster-2DC.yaml")
+
+	cc.Spec.Topology.DC[1].Rack = nil
+	cc.Spec.Topology.DC[1].NodeTopologyKey = zoneKey
+
+	//Without racks, the DC is not initialized and the other racks are kept
+	cc.InitCassandraRackList()
+	assert.Equal(2, len(cc.Status.CassandraRackStatus))
+	assert.Equal(2, cc.GetDCSize())
+
+	//No node has the label
+	generated, err := rcc.generateTopologyRacks(cc)
+	assert.False(generated)
+	assert.NotNil(err)
+
+	site := map[string]string{"location.dfy.orange.com/site": "mts"}
+	helperCreateNode(t, rcc, "node1", k8s.MergeLabels(site, map[string]string{zoneKey: "eu-west-1b"}))
+	helperCreateNode(t, rcc, "node2", k8s.MergeLabels(site, map[string]string{zoneKey: "eu-west-1a"}))
+	helperCreateNode(t, rcc, "node3", k8s.MergeLabels(site, map[string]string{zoneKey: "eu-west-1a"}))
+	//Nodes not matching the labels of the DC are ignored
+	helperCreateNode(t, rcc, "node4", map[string]string{zoneKey: "eu-west-1c"})
+
+	generated, err = rcc.generateTopologyRacks(cc)
+	assert.True(generated)
+	assert.Nil(err)
+	assert.Equal(api.RackSlice{
+		{Name: "euwest1a", Labels: map[string]string{zoneKey: "eu-west-1a"}},
+		{Name: "euwest1b", Labels: map[string]string{zoneKey: "eu-west-1b"}},
+	}, cc.Spec.Topology.DC[1].Rack)
+
+	cc.InitCassandraRackList()
+	assert.Equal([]string{"dc1-rack1", "dc1-rack2", "dc2-euwest1a", "dc2-euwest1b"}, cc.GetDCRackNames())
+	assert.Contains(cc.Status.CassandraRackStatus, "dc2-euwest1b")
+	_, nodeSelector := k8s.GetDCRackLabelsAndNodeSelectorForStatefulSet(cc, 1, 1)
+	assert.Equal(k8s.MergeLabels(site, map[string]string{zoneKey: "eu-west-1b"}), nodeSelector)
+
+	//The racks are generated once
+	generated, err = rcc.generateTopologyRacks(cc)
+	assert.False(generated)
+	assert.Nil(err)
+}
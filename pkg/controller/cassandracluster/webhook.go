@@ -0,0 +1,136 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandracluster
+
+import (
+	"context"
+	"net/http"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/sirupsen/logrus"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// NewValidatingWebhook returns a webhook refusing the changes of the CassandraClusters of namespace which the
+// Operator would restore, so that they are rejected when they are applied
+func NewValidatingWebhook(mgr manager.Manager, namespace string) (*admission.Webhook, error) {
+	return builder.NewWebhookBuilder().
+		Name("validating.cassandraclusters.db.orange.com").
+		Path("/validate-cassandraclusters").
+		Validating().
+		Operations(admissionregistrationv1beta1.Update).
+		FailurePolicy(admissionregistrationv1beta1.Fail).
+		WithManager(mgr).
+		ForType(&api.CassandraCluster{}).
+		Handlers(&clusterValidator{namespace: namespace}).
+		Build()
+}
+
+//clusterValidator validates the changes of a CassandraCluster with the checks of the reconciler
+type clusterValidator struct {
+	namespace string
+	rcc       *ReconcileCassandraCluster
+	decoder   types.Decoder
+}
+
+var _ admission.Handler = &clusterValidator{}
+var _ inject.Client = &clusterValidator{}
+var _ inject.Decoder = &clusterValidator{}
+
+//InjectClient injects the client used to ask the nodes of the cluster
+func (v *clusterValidator) InjectClient(c client.Client) error {
+	v.rcc = &ReconcileCassandraCluster{client: c}
+	return nil
+}
+
+//InjectDecoder injects the decoder of the admission requests
+func (v *clusterValidator) InjectDecoder(d types.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+//Handle refuses the CassandraCluster if ValidateChanges returns an error
+func (v *clusterValidator) Handle(ctx context.Context, req types.Request) types.Response {
+	cc := &api.CassandraCluster{}
+	if err := v.decoder.Decode(req, cc); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	//Clusters of other namespaces are managed by other operators
+	if v.namespace != "" && req.AdmissionRequest.Namespace != v.namespace {
+		return admission.ValidationResponse(true, "")
+	}
+
+	if err := v.rcc.ValidateChanges(cc); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Warningf("Change refused by the webhook: %v", err)
+		return admission.ValidationResponse(false, err.Error())
+	}
+	return admission.ValidationResponse(true, "")
+}
+
+// NewMutatingWebhook returns a webhook setting the defaults of the spec of the CassandraClusters of namespace when
+// they are created, so that they are visible in the objects applied
+func NewMutatingWebhook(mgr manager.Manager, namespace string) (*admission.Webhook, error) {
+	return builder.NewWebhookBuilder().
+		Name("mutating.cassandraclusters.db.orange.com").
+		Path("/mutate-cassandraclusters").
+		Mutating().
+		Operations(admissionregistrationv1beta1.Create).
+		FailurePolicy(admissionregistrationv1beta1.Fail).
+		WithManager(mgr).
+		ForType(&api.CassandraCluster{}).
+		Handlers(&clusterDefaulter{namespace: namespace}).
+		Build()
+}
+
+//clusterDefaulter sets the defaults of the spec of a CassandraCluster
+type clusterDefaulter struct {
+	namespace string
+	decoder   types.Decoder
+}
+
+var _ admission.Handler = &clusterDefaulter{}
+var _ inject.Decoder = &clusterDefaulter{}
+
+//InjectDecoder injects the decoder of the admission requests
+func (d *clusterDefaulter) InjectDecoder(decoder types.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+//Handle patches the CassandraCluster with the values set by SetSpecDefaults
+func (d *clusterDefaulter) Handle(ctx context.Context, req types.Request) types.Response {
+	cc := &api.CassandraCluster{}
+	if err := d.decoder.Decode(req, cc); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	//Clusters of other namespaces are managed by other operators
+	if d.namespace != "" && req.AdmissionRequest.Namespace != d.namespace {
+		return admission.ValidationResponse(true, "")
+	}
+
+	defaulted := cc.DeepCopy()
+	if defaulted.SetSpecDefaults() {
+		logrus.WithFields(logrus.Fields{"cluster": cc.Name}).Info("Defaults set by the webhook")
+	}
+	return admission.PatchResponse(cc, defaulted)
+}
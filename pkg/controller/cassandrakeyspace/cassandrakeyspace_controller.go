@@ -0,0 +1,171 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrakeyspace
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_cassandrakeyspace")
+
+// Add creates a new CassandraKeyspace Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCassandraKeyspace{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		recorder: mgr.GetRecorder("cassandrakeyspace-controller"), cqlClient: cql.NewClient()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("cassandrakeyspace-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CassandraKeyspace
+	err = c.Watch(&source.Kind{Type: &api.CassandraKeyspace{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the CassandraClusters so that the keyspaces are created once their cluster is running and
+	// a DC replicating a keyspace which is scaled down is reported as soon as the cluster is changed
+	return c.Watch(&source.Kind{Type: &api.CassandraCluster{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			return keyspacesOfCluster(mgr.GetClient(), object.Meta.GetNamespace(), object.Meta.GetName())
+		}),
+	})
+}
+
+//keyspacesOfCluster returns the requests of the CassandraKeyspaces of a CassandraCluster
+func keyspacesOfCluster(k8sClient client.Client, namespace, clusterName string) []reconcile.Request {
+	keyspaces := &api.CassandraKeyspaceList{}
+	if err := k8sClient.List(context.TODO(), &client.ListOptions{Namespace: namespace}, keyspaces); err != nil {
+		logrus.WithFields(logrus.Fields{"cluster": clusterName}).Errorf("Can't list CassandraKeyspaces: %v", err)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, ck := range keyspaces.Items {
+		if ck.Spec.Cluster == clusterName {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: ck.Namespace, Name: ck.Name}})
+		}
+	}
+	return requests
+}
+
+var _ reconcile.Reconciler = &ReconcileCassandraKeyspace{}
+
+// ReconcileCassandraKeyspace reconciles a CassandraKeyspace object
+type ReconcileCassandraKeyspace struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+
+	// recorder records the events of the keyspaces
+	recorder record.EventRecorder
+	// cqlClient opens the CQL sessions creating and altering the keyspaces
+	cqlClient cql.Client
+}
+
+// Reconcile creates the keyspace of a CassandraKeyspace in its CassandraCluster once the cluster is running, then
+// alters it each time its replication changes. The applied state is stored in CassandraKeyspace.Status
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCassandraKeyspace) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraKeyspace")
+
+	requeue30 := reconcile.Result{RequeueAfter: 30 * time.Second}
+	forget := reconcile.Result{}
+
+	// Fetch the CassandraKeyspace instance
+	ck := &api.CassandraKeyspace{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, ck)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return forget, nil
+		}
+		return forget, err
+	}
+
+	status := ck.Status.DeepCopy()
+
+	//We Update Status at the end
+	defer r.updateCassandraKeyspaceStatus(ck, status)
+
+	cc := &api.CassandraCluster{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: ck.Spec.Cluster, Namespace: ck.Namespace}, cc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logrus.WithFields(logrus.Fields{"keyspace": ck.Name,
+				"cluster": ck.Spec.Cluster}).Warn("CassandraCluster of keyspace does not exist, waiting..")
+			setKeyspacePending(status)
+			return requeue30, nil
+		}
+		return forget, err
+	}
+
+	if r.checkDCsScaledDownTo0(ck, cc, status) {
+		return forget, nil
+	}
+
+	if ck.IsApplied() {
+		return forget, nil
+	}
+
+	if err = ck.Validate(cc); err != nil {
+		r.setKeyspaceError(ck, status, err)
+		return forget, nil
+	}
+
+	if cc.Status.Phase != api.ClusterPhaseRunning {
+		logrus.WithFields(logrus.Fields{"keyspace": ck.Name,
+			"cluster": cc.Name}).Info("CassandraCluster of keyspace is not running yet, waiting..")
+		setKeyspacePending(status)
+		return requeue30, nil
+	}
+
+	if err = r.applyKeyspace(ck, cc, status); err != nil {
+		r.setKeyspaceError(ck, status, err)
+		return requeue30, nil
+	}
+	return forget, nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrakeyspace
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	cqlfake "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql/fake"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	path := filepath.Join("testdata", name) // relative path
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+func helperInitKeyspace(t *testing.T) (*ReconcileCassandraKeyspace, *api.CassandraKeyspace, *api.CassandraCluster,
+	*cqlfake.Client) {
+	var cc api.CassandraCluster
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandracluster.yaml"), &cc); err != nil {
+		t.Fatal(err)
+	}
+	var ck api.CassandraKeyspace
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandrakeyspace.yaml"), &ck); err != nil {
+		t.Fatal(err)
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraCluster{}, &api.CassandraClusterList{},
+		&api.CassandraKeyspace{}, &api.CassandraKeyspaceList{})
+	cl := fake.NewFakeClient([]runtime.Object{&cc, &ck}...)
+	cqlClient := cqlfake.NewClient()
+	r := &ReconcileCassandraKeyspace{client: cl, scheme: s, recorder: record.NewFakeRecorder(10),
+		cqlClient: cqlClient}
+	return r, &ck, &cc, cqlClient
+}
+
+func helperReconcileKeyspace(t *testing.T, r *ReconcileCassandraKeyspace,
+	ck *api.CassandraKeyspace) (reconcile.Result, *api.CassandraKeyspace) {
+	name := types.NamespacedName{Name: ck.Name, Namespace: ck.Namespace}
+	res, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	ck = &api.CassandraKeyspace{}
+	if err = r.client.Get(context.TODO(), name, ck); err != nil {
+		t.Fatalf("get keyspace: (%v)", err)
+	}
+	return res, ck
+}
+
+func helperUpdate(t *testing.T, r *ReconcileCassandraKeyspace, object runtime.Object) {
+	if err := r.client.Update(context.TODO(), object); err != nil {
+		t.Fatalf("update: (%v)", err)
+	}
+}
+
+func TestCassandraKeyspace(t *testing.T) {
+	assert := assert.New(t)
+
+	r, ck, cc, cqlClient := helperInitKeyspace(t)
+
+	//The keyspace is created then altered so that an existing keyspace gets the replication
+	res, ck := helperReconcileKeyspace(t, r, ck)
+	assert.Equal(reconcile.Result{}, res)
+	options := `WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': 3, 'dc2': 1} AND durable_writes = true`
+	assert.Equal([]string{`CREATE KEYSPACE IF NOT EXISTS "demo" ` + options, `ALTER KEYSPACE "demo" ` + options},
+		cqlClient.Statements)
+	assert.Equal(api.StatusDone, ck.Status.Phase)
+	assert.Equal("demo", ck.Status.Name)
+	assert.Equal(api.NetworkTopologyStrategy, ck.Status.Strategy)
+	assert.Equal(map[string]int32{"dc1": 3, "dc2": 1}, ck.Status.ReplicationFactor)
+	assert.True(*ck.Status.DurableWrites)
+
+	//Nothing is done while the keyspace is applied
+	res, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(reconcile.Result{}, res)
+	assert.Equal(2, len(cqlClient.Statements))
+
+	//The replication is altered when it changes
+	ck.Spec.ReplicationFactor["dc1"] = 4
+	helperUpdate(t, r, ck)
+	_, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(4, len(cqlClient.Statements))
+	assert.Equal(`ALTER KEYSPACE "demo" WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': 4, 'dc2': 1} `+
+		`AND durable_writes = true`, cqlClient.Statements[3])
+	assert.Equal(int32(4), ck.Status.ReplicationFactor["dc1"])
+
+	//A replication factor larger than the nodes of the DC is refused, the applied state is kept
+	ck.Spec.ReplicationFactor["dc2"] = 2
+	helperUpdate(t, r, ck)
+	_, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(4, len(cqlClient.Statements))
+	assert.Equal(api.StatusError, ck.Status.Phase)
+	assert.Equal("Replication factor 2 of DC dc2 is larger than its 1 nodes", ck.Status.Message)
+	assert.Equal(int32(1), ck.Status.ReplicationFactor["dc2"])
+
+	//Scaling the DC down to 0 is reported without altering the keyspace
+	ck.Spec.ReplicationFactor["dc2"] = 1
+	helperUpdate(t, r, ck)
+	nodesPerRacks := int32(0)
+	cc.Spec.Topology.DC[1].NodesPerRacks = &nodesPerRacks
+	helperUpdate(t, r, cc)
+	assert.Equal([]reconcile.Request{{NamespacedName: types.NamespacedName{Name: ck.Name, Namespace: ck.Namespace}}},
+		keyspacesOfCluster(r.client, cc.Namespace, cc.Name))
+	_, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(4, len(cqlClient.Statements))
+	assert.Equal([]string{"DC dc2 replicating keyspace demo is scaled down to 0, " +
+		"its replication factor must be set to 0 before"}, ck.Status.Warnings)
+
+	//Once the replication factor of the DC is 0, the keyspace is altered and the warning removed
+	ck.Spec.ReplicationFactor["dc2"] = 0
+	helperUpdate(t, r, ck)
+	_, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(6, len(cqlClient.Statements))
+	assert.Empty(ck.Status.Warnings)
+	assert.Equal(api.StatusDone, ck.Status.Phase)
+	assert.Equal(int32(0), ck.Status.ReplicationFactor["dc2"])
+}
+
+func TestCassandraKeyspacePending(t *testing.T) {
+	assert := assert.New(t)
+
+	r, ck, cc, cqlClient := helperInitKeyspace(t)
+	cc.Status.Phase = api.ClusterPhaseInitial
+	helperUpdate(t, r, cc)
+
+	res, ck := helperReconcileKeyspace(t, r, ck)
+	assert.NotEqual(reconcile.Result{}, res)
+	assert.Empty(cqlClient.Statements)
+	assert.Equal(api.KeyspacePhasePending, ck.Status.Phase)
+
+	//Connection errors are reported and retried
+	cc.Status.Phase = api.ClusterPhaseRunning
+	helperUpdate(t, r, cc)
+	cqlClient.Err = fmt.Errorf("connection refused")
+	res, ck = helperReconcileKeyspace(t, r, ck)
+	assert.NotEqual(reconcile.Result{}, res)
+	assert.Equal(api.StatusError, ck.Status.Phase)
+
+	cqlClient.Err = nil
+	_, ck = helperReconcileKeyspace(t, r, ck)
+	assert.Equal(api.StatusDone, ck.Status.Phase)
+	assert.Empty(ck.Status.Message)
+}
+
+func TestCassandraKeyspaceValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ck, cc, _ := helperInitKeyspace(t)
+	assert.Nil(ck.Validate(cc))
+
+	invalid := ck.DeepCopy()
+	invalid.Spec.Name = "my-keyspace"
+	assert.NotNil(invalid.Validate(cc))
+
+	invalid = ck.DeepCopy()
+	invalid.Status.Name = "other"
+	assert.NotNil(invalid.Validate(cc))
+
+	invalid = ck.DeepCopy()
+	invalid.Spec.ReplicationFactor["dc3"] = 1
+	assert.Equal("DC dc3 does not exist in CassandraCluster cassandra-demo", invalid.Validate(cc).Error())
+
+	invalid = ck.DeepCopy()
+	invalid.Spec.ReplicationFactor["dc1"] = 5
+	assert.Equal("Replication factor 5 of DC dc1 is larger than its 4 nodes", invalid.Validate(cc).Error())
+
+	invalid = ck.DeepCopy()
+	invalid.Spec.ReplicationFactor["dc1"] = -1
+	assert.NotNil(invalid.Validate(cc))
+
+	invalid = ck.DeepCopy()
+	invalid.Spec.Strategy = api.SimpleStrategy
+	assert.NotNil(invalid.Validate(cc))
+
+	invalid = ck.DeepCopy()
+	invalid.Spec.Strategy = "LocalStrategy"
+	assert.NotNil(invalid.Validate(cc))
+
+	simple := ck.DeepCopy()
+	simple.Spec.Strategy = api.SimpleStrategy
+	simple.Spec.ReplicationFactor = map[string]int32{"dc1": 3}
+	cc.Spec.Topology.DC = cc.Spec.Topology.DC[:1]
+	assert.Nil(simple.Validate(cc))
+	assert.Equal("{'class': 'SimpleStrategy', 'replication_factor': 3}", replication(simple))
+}
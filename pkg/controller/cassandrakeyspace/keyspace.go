@@ -0,0 +1,171 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrakeyspace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//Reasons of the events recorded on the keyspaces
+const (
+	reasonKeyspaceApplied = "KeyspaceApplied"
+	reasonKeyspaceFailed  = "KeyspaceFailed"
+	reasonDCScaledDownTo0 = "DCScaledDownTo0"
+)
+
+//warningDCScaledDownTo0 is the warning stored in the status of a keyspace replicated in a DC scaled down to 0
+const warningDCScaledDownTo0 = "DC %s replicating keyspace %s is scaled down to 0, " +
+	"its replication factor must be set to 0 before"
+
+//updateCassandraKeyspaceStatus updates the CassandraKeyspace if its status has changed
+func (r *ReconcileCassandraKeyspace) updateCassandraKeyspaceStatus(ck *api.CassandraKeyspace,
+	status *api.CassandraKeyspaceStatus) error {
+	if reflect.DeepEqual(ck.Status, *status) {
+		return nil
+	}
+	ck.Status = *status
+	err := r.client.Update(context.TODO(), ck)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"keyspace": ck.Name}).Errorf("Issue when updating CassandraKeyspace: %v", err)
+	}
+	return err
+}
+
+//recordEvent records an event on the keyspace if the reconciler has a recorder
+func (r *ReconcileCassandraKeyspace) recordEvent(ck *api.CassandraKeyspace, eventType, reason, messageFmt string,
+	args ...interface{}) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Eventf(ck, eventType, reason, messageFmt, args...)
+}
+
+//setKeyspacePending sets the phase of a keyspace which has never been applied to Pending
+func setKeyspacePending(status *api.CassandraKeyspaceStatus) {
+	if status.Phase == "" {
+		status.Phase = api.KeyspacePhasePending
+	}
+}
+
+//setKeyspaceError stores err in the status of the keyspace and records it as an event
+func (r *ReconcileCassandraKeyspace) setKeyspaceError(ck *api.CassandraKeyspace, status *api.CassandraKeyspaceStatus,
+	err error) {
+	logrus.WithFields(logrus.Fields{"keyspace": ck.Name, "cluster": ck.Spec.Cluster}).Errorf(
+		"Keyspace %s not applied: %v", ck.Spec.Name, err)
+	if status.Phase != api.StatusError || status.Message != err.Error() {
+		now := metav1.Now()
+		status.LastUpdateTime = &now
+		r.recordEvent(ck, v1.EventTypeWarning, reasonKeyspaceFailed, "Keyspace %s not applied: %v", ck.Spec.Name, err)
+	}
+	status.Phase = api.StatusError
+	status.Message = err.Error()
+}
+
+//checkDCsScaledDownTo0 stores a warning in the status of the keyspace for each DC replicating it which is scaled
+//down to 0 in cc, as the CassandraCluster will refuse the scale down while the keyspace has data in the DC
+//It returns true if there is such a DC, the keyspace is then not altered until the DC is scaled up again
+func (r *ReconcileCassandraKeyspace) checkDCsScaledDownTo0(ck *api.CassandraKeyspace, cc *api.CassandraCluster,
+	status *api.CassandraKeyspaceStatus) bool {
+	var warnings []string
+	for _, dcName := range ck.DCsScaledDownTo0(cc) {
+		warnings = append(warnings, fmt.Sprintf(warningDCScaledDownTo0, dcName, ck.Spec.Name))
+	}
+	if !reflect.DeepEqual(warnings, status.Warnings) {
+		for _, warning := range warnings {
+			logrus.WithFields(logrus.Fields{"keyspace": ck.Name, "cluster": cc.Name}).Warn(warning)
+			r.recordEvent(ck, v1.EventTypeWarning, reasonDCScaledDownTo0, warning)
+		}
+		status.Warnings = warnings
+	}
+	return len(warnings) != 0
+}
+
+//replication returns the replication map of the keyspace in CQL
+func replication(ck *api.CassandraKeyspace) string {
+	strategy := ck.GetStrategy()
+	options := []string{fmt.Sprintf("'class': %s", cql.Literal(strategy))}
+	var dcNames []string
+	for dcName := range ck.Spec.ReplicationFactor {
+		dcNames = append(dcNames, dcName)
+	}
+	sort.Strings(dcNames)
+	for _, dcName := range dcNames {
+		key := dcName
+		if strategy == api.SimpleStrategy {
+			key = "replication_factor"
+		}
+		options = append(options, fmt.Sprintf("%s: %d", cql.Literal(key), ck.Spec.ReplicationFactor[dcName]))
+	}
+	return "{" + strings.Join(options, ", ") + "}"
+}
+
+//applyKeyspace creates the keyspace if it does not exist then alters it so that an existing keyspace gets the
+//requested replication. The applied state is stored in the status
+func (r *ReconcileCassandraKeyspace) applyKeyspace(ck *api.CassandraKeyspace, cc *api.CassandraCluster,
+	status *api.CassandraKeyspaceStatus) error {
+	cqlClient := r.cqlClient
+	if cqlClient == nil {
+		cqlClient = cql.NewClient()
+	}
+	username, password, err := cassandracluster.CQLSuperuserCredentials(r.client, cc)
+	if err != nil {
+		return err
+	}
+	session, err := cassandracluster.NewCQLSession(cqlClient, r.client, cc, username, password)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CassandraCluster %s: %v", cc.Name, err)
+	}
+	defer session.Close()
+
+	options := fmt.Sprintf("WITH replication = %s AND durable_writes = %t", replication(ck), ck.GetDurableWrites())
+	logrus.WithFields(logrus.Fields{"keyspace": ck.Name, "cluster": cc.Name}).Infof(
+		"Apply keyspace %s %s", ck.Spec.Name, options)
+	for _, statement := range []string{
+		fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %q %s", ck.Spec.Name, options),
+		fmt.Sprintf("ALTER KEYSPACE %q %s", ck.Spec.Name, options),
+	} {
+		if err = session.Exec(statement); err != nil {
+			return fmt.Errorf("failed to apply keyspace %s: %v", ck.Spec.Name, err)
+		}
+	}
+
+	now := metav1.Now()
+	durableWrites := ck.GetDurableWrites()
+	status.Phase = api.StatusDone
+	status.Message = ""
+	status.Name = ck.Spec.Name
+	status.Strategy = ck.GetStrategy()
+	status.DurableWrites = &durableWrites
+	status.ReplicationFactor = map[string]int32{}
+	for dcName, rf := range ck.Spec.ReplicationFactor {
+		status.ReplicationFactor[dcName] = rf
+	}
+	status.LastUpdateTime = &now
+	r.recordEvent(ck, v1.EventTypeNormal, reasonKeyspaceApplied, "Keyspace %s applied with replication %s",
+		ck.Spec.Name, replication(ck))
+	return nil
+}
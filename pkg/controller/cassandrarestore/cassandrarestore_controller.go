@@ -0,0 +1,168 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandrabackup"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_cassandrarestore")
+
+// Add creates a new CassandraRestore Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCassandraRestore{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		files: execPodTableFiles{}, newStorage: cassandrabackup.NewObjectStorage}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("cassandrarestore-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CassandraRestore
+	return c.Watch(&source.Kind{Type: &api.CassandraRestore{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileCassandraRestore{}
+
+// ReconcileCassandraRestore reconciles a CassandraRestore object
+type ReconcileCassandraRestore struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+
+	//files gives access to the table directories of the pods
+	files PodTableFiles
+	//newStorage returns the object store where the files of the backup are stored
+	newStorage func(client.Client, string, api.BackupStorage) (cassandrabackup.ObjectStorage, error)
+}
+
+// Reconcile restores a CassandraBackup either in a new CassandraCluster whose nodes are seeded with the files of
+// the backup or in an existing CassandraCluster whose tables are truncated then loaded pod by pod, rack by rack.
+// Progress is stored in CassandraRestore.Status
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCassandraRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraRestore")
+
+	requeue30 := reconcile.Result{RequeueAfter: 30 * time.Second}
+	requeue := reconcile.Result{Requeue: true}
+	forget := reconcile.Result{}
+
+	// Fetch the CassandraRestore instance
+	cr := &api.CassandraRestore{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, cr)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return forget, nil
+		}
+		return forget, err
+	}
+
+	//A restore is never done twice
+	if cr.Status.Phase == api.StatusDone || cr.Status.Phase == api.StatusError {
+		return forget, nil
+	}
+
+	cb := &api.CassandraBackup{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Backup, Namespace: cr.Namespace}, cb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logrus.WithFields(logrus.Fields{"restore": cr.Name,
+				"backup": cr.Spec.Backup}).Warn("CassandraBackup to restore does not exist, waiting..")
+			return requeue30, nil
+		}
+		return forget, err
+	}
+
+	status := cr.Status.DeepCopy()
+
+	//We Update Status at the end
+	defer r.updateCassandraRestoreStatus(cr, status)
+
+	switch cb.Status.Phase {
+	case api.StatusDone:
+	case api.StatusError:
+		setRestoreError(cr, status, fmt.Errorf("CassandraBackup %s has failed", cb.Name))
+		return forget, nil
+	default:
+		logrus.WithFields(logrus.Fields{"restore": cr.Name,
+			"backup": cb.Name}).Info("CassandraBackup to restore is not done yet, waiting..")
+		return requeue30, nil
+	}
+
+	if status.Phase == "" {
+		if err = r.initRestore(cr, cb, status); err != nil {
+			setRestoreError(cr, status, err)
+			return forget, nil
+		}
+		return requeue, nil
+	}
+
+	cc := &api.CassandraCluster{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Cluster, Namespace: cr.Namespace}, cc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return requeue30, nil
+		}
+		return forget, err
+	}
+
+	if cr.IsNewCluster() {
+		if !r.checkSeededPods(cr, cc, status) {
+			return requeue30, nil
+		}
+		if err = r.clearRestoreFrom(cc); err != nil {
+			return requeue30, err
+		}
+	} else if dcRackName, podName := nextPodToRestore(status); podName != "" {
+		if !r.restorePod(cr, cb, cc, dcRackName, podName, status) {
+			return requeue30, nil
+		}
+		return requeue, nil
+	}
+
+	finalizeRestore(cr, status)
+	return forget, nil
+}
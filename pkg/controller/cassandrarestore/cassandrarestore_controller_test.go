@@ -0,0 +1,348 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandrabackup"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	"github.com/ghodss/yaml"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	path := filepath.Join("testdata", name) // relative path
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+//memStorage is an object store keeping objects in memory
+type memStorage map[string]string
+
+func (s memStorage) PutObject(key string, reader io.Reader, size int64) error {
+	content, err := ioutil.ReadAll(reader)
+	s[key] = string(content)
+	return err
+}
+
+func (s memStorage) GetObject(key string) (io.ReadCloser, error) {
+	content, ok := s[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s memStorage) ListObjects(prefix string) ([]string, error) {
+	keys := []string{}
+	for key := range s {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+//helperBackupObjects returns the objects stored by backup1 for the 2 pods of cassandra-demo
+func helperBackupObjects() memStorage {
+	return memStorage{
+		"k8s/backup1/dc1-rack1/0/demo/users-1111/mc-1-big-Data.db":           "rack1 users data",
+		"k8s/backup1/dc1-rack1/0/demo/users-1111/mc-1-big-Index.db":          "rack1 users index",
+		"k8s/backup1/dc1-rack1/0/demo/events-2222/mc-1-big-Data.db":          "rack1 events data",
+		"k8s/backup1/dc1-rack1/0/system_schema/tables-3333/mc-1-big-Data.db": "rack1 schema",
+		"k8s/backup1/dc1-rack2/0/demo/users-1111/mc-2-big-Data.db":           "rack2 users data",
+		"k8s/backup1/dc1-rack2/0/system_schema/tables-3333/mc-2-big-Data.db": "rack2 schema",
+	}
+}
+
+//fakePodTableFiles keeps the files written in memory, indexed by pod name then by path
+//The tables have been recreated since the backup so their directory has a new id
+type fakePodTableFiles map[string]map[string]string
+
+func (f fakePodTableFiles) TableDir(pod *v1.Pod, keyspace, table string) (string, error) {
+	return keyspace + "/" + table + "-9999", nil
+}
+
+func (f fakePodTableFiles) WriteFile(pod *v1.Pod, path string, reader io.Reader) error {
+	content, err := ioutil.ReadAll(reader)
+	if f[pod.Name] == nil {
+		f[pod.Name] = map[string]string{}
+	}
+	f[pod.Name][path] = string(content)
+	return err
+}
+
+func helperPod(cc *api.CassandraCluster, dcName, rackName string, ordinal int, ready bool) *v1.Pod {
+	dcRackName := cc.GetDCRackName(dcName, rackName)
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, ordinal),
+			Namespace: cc.Namespace,
+			Labels:    k8s.LabelsForCassandraDCRack(cc, dcName, rackName),
+		},
+		Spec: v1.PodSpec{
+			Hostname:  fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, ordinal),
+			Subdomain: cc.Name,
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				v1.ContainerStatus{Name: "cassandra", Ready: ready},
+			},
+		},
+	}
+}
+
+func helperLoadObjects(t *testing.T) (*api.CassandraRestore, *api.CassandraBackup, *api.CassandraCluster) {
+	var cc api.CassandraCluster
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandracluster.yaml"), &cc); err != nil {
+		t.Fatal(err)
+	}
+	var cb api.CassandraBackup
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandrabackup.yaml"), &cb); err != nil {
+		t.Fatal(err)
+	}
+	var cr api.CassandraRestore
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandrarestore.yaml"), &cr); err != nil {
+		t.Fatal(err)
+	}
+	return &cr, &cb, &cc
+}
+
+func helperInitRestore(objs []runtime.Object, storage memStorage, files fakePodTableFiles) *ReconcileCassandraRestore {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraCluster{}, &api.CassandraClusterList{},
+		&api.CassandraBackup{}, &api.CassandraBackupList{}, &api.CassandraRestore{}, &api.CassandraRestoreList{})
+	cl := fake.NewFakeClient(objs...)
+	return &ReconcileCassandraRestore{client: cl, scheme: s, files: files,
+		newStorage: func(client.Client, string, api.BackupStorage) (cassandrabackup.ObjectStorage, error) {
+			return storage, nil
+		}}
+}
+
+//helperJolokia mocks the jolokia endpoint of the pods and records the operations received by each host
+func helperJolokia(cc *api.CassandraCluster) map[string][]string {
+	operations := map[string][]string{}
+	for _, podName := range []string{"cassandra-demo-dc1-rack1-0", "cassandra-demo-dc1-rack2-0"} {
+		hostName := podName + "." + cc.Name
+		httpmock.RegisterResponder("POST", cassandracluster.JolokiaURL(hostName, cassandracluster.JolokiaPort),
+			func(req *http.Request) (*http.Response, error) {
+				var request struct {
+					Operation string        `json:"operation"`
+					Arguments []interface{} `json:"arguments"`
+				}
+				json.NewDecoder(req.Body).Decode(&request)
+				operations[hostName] = append(operations[hostName],
+					fmt.Sprintf("%s %v", strings.Split(request.Operation, "(")[0], request.Arguments))
+				return httpmock.NewStringResponse(200, `{"value": null, "status": 200}`), nil
+			})
+	}
+	return operations
+}
+
+func helperReconcile(t *testing.T, r *ReconcileCassandraRestore, cr *api.CassandraRestore) *api.CassandraRestore {
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	restore := &api.CassandraRestore{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	return restore
+}
+
+func TestCassandraRestoreExistingCluster(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	cr, cb, cc := helperLoadObjects(t)
+	files := fakePodTableFiles{}
+	r := helperInitRestore([]runtime.Object{cr, cb, cc,
+		helperPod(cc, "dc1", "rack1", 0, true), helperPod(cc, "dc1", "rack2", 0, true)},
+		helperBackupObjects(), files)
+	operations := helperJolokia(cc)
+
+	restore := cr
+	for i := 0; i < 10 && restore.Status.Phase != api.StatusDone && restore.Status.Phase != api.StatusError; i++ {
+		restore = helperReconcile(t, r, cr)
+	}
+
+	assert.Equal(api.StatusDone, restore.Status.Phase)
+	assert.Equal([]string{"demo.events", "demo.users"}, restore.Status.Tables)
+
+	//Tables are truncated once for the cluster then loaded on each pod
+	assert.Equal([]string{"truncate [demo events]", "truncate [demo users]",
+		"loadNewSSTables [demo events]", "loadNewSSTables [demo users]"},
+		operations["cassandra-demo-dc1-rack1-0.cassandra-demo"])
+	assert.Equal([]string{"loadNewSSTables [demo users]"}, operations["cassandra-demo-dc1-rack2-0.cassandra-demo"])
+
+	//System keyspaces are not restored in an existing cluster
+	assert.Equal(map[string]string{
+		"demo/users-9999/mc-1-big-Data.db":  "rack1 users data",
+		"demo/users-9999/mc-1-big-Index.db": "rack1 users index",
+		"demo/events-9999/mc-1-big-Data.db": "rack1 events data",
+	}, files["cassandra-demo-dc1-rack1-0"])
+	assert.Equal(map[string]string{"demo/users-9999/mc-2-big-Data.db": "rack2 users data"},
+		files["cassandra-demo-dc1-rack2-0"])
+
+	for dcRackName, rackStatus := range restore.Status.CassandraRackStatus {
+		podName := "cassandra-demo-" + dcRackName + "-0"
+		assert.Equal(api.OperationRestore, rackStatus.Name)
+		assert.Equal(api.StatusDone, rackStatus.Status)
+		assert.Equal([]string{podName}, rackStatus.Pods)
+		assert.Equal([]string{podName}, rackStatus.PodsOK)
+		assert.Empty(rackStatus.PodsKO)
+	}
+}
+
+func TestCassandraRestoreExistingClusterWaitsForReadyPods(t *testing.T) {
+	assert := assert.New(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	cr, cb, cc := helperLoadObjects(t)
+	files := fakePodTableFiles{}
+	r := helperInitRestore([]runtime.Object{cr, cb, cc,
+		helperPod(cc, "dc1", "rack1", 0, true), helperPod(cc, "dc1", "rack2", 0, false)},
+		helperBackupObjects(), files)
+	helperJolokia(cc)
+
+	var restore *api.CassandraRestore
+	for i := 0; i < 5; i++ {
+		restore = helperReconcile(t, r, cr)
+	}
+
+	assert.Equal(api.StatusOngoing, restore.Status.Phase)
+	assert.Equal(api.StatusDone, restore.Status.CassandraRackStatus["dc1-rack1"].Status)
+	assert.Equal(api.StatusToDo, restore.Status.CassandraRackStatus["dc1-rack2"].Status)
+	assert.Nil(files["cassandra-demo-dc1-rack2-0"])
+}
+
+func TestCassandraRestoreMissingRack(t *testing.T) {
+	assert := assert.New(t)
+
+	cr, cb, cc := helperLoadObjects(t)
+	cc.Spec.Topology.DC[0].Rack = cc.Spec.Topology.DC[0].Rack[:1]
+	r := helperInitRestore([]runtime.Object{cr, cb, cc}, helperBackupObjects(), fakePodTableFiles{})
+
+	restore := helperReconcile(t, r, cr)
+
+	assert.Equal(api.StatusError, restore.Status.Phase)
+	assert.Equal("Rack dc1-rack2 of backup backup1 does not exist in cluster cassandra-demo", restore.Status.Error)
+}
+
+func TestCassandraRestoreNewCluster(t *testing.T) {
+	assert := assert.New(t)
+
+	cr, cb, cc := helperLoadObjects(t)
+	cr.Spec.ClusterSpec = cc.Spec.DeepCopy()
+	r := helperInitRestore([]runtime.Object{cr, cb}, helperBackupObjects(), fakePodTableFiles{})
+
+	restore := helperReconcile(t, r, cr)
+	assert.Equal(api.StatusOngoing, restore.Status.Phase)
+
+	newCC := &api.CassandraCluster{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cc.Name, Namespace: cc.Namespace}, newCC)
+	assert.Nil(err)
+	assert.Equal(&api.RestoreFrom{Backup: cb.Name, Storage: cb.Spec.Storage}, newCC.Spec.RestoreFrom)
+
+	//Pods are restored once they are ready
+	r.client.Create(context.TODO(), helperPod(cc, "dc1", "rack1", 0, true))
+	r.client.Create(context.TODO(), helperPod(cc, "dc1", "rack2", 0, false))
+	restore = helperReconcile(t, r, cr)
+	assert.Equal(api.StatusOngoing, restore.Status.Phase)
+	assert.Equal(api.StatusDone, restore.Status.CassandraRackStatus["dc1-rack1"].Status)
+	assert.Equal([]string{"cassandra-demo-dc1-rack1-0"}, restore.Status.CassandraRackStatus["dc1-rack1"].PodsOK)
+	assert.Equal(api.StatusOngoing, restore.Status.CassandraRackStatus["dc1-rack2"].Status)
+
+	r.client.Update(context.TODO(), helperPod(cc, "dc1", "rack2", 0, true))
+	restore = helperReconcile(t, r, cr)
+	assert.Equal(api.StatusDone, restore.Status.Phase)
+	assert.Equal([]string{"cassandra-demo-dc1-rack2-0"}, restore.Status.CassandraRackStatus["dc1-rack2"].PodsOK)
+
+	//The restore is not replayed on the nodes added later
+	newCC = &api.CassandraCluster{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cc.Name, Namespace: cc.Namespace}, newCC)
+	assert.Nil(err)
+	assert.Nil(newCC.Spec.RestoreFrom)
+}
+
+func TestCassandraRestoreNewClusterRestoreFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	cr, cb, cc := helperLoadObjects(t)
+	cr.Spec.ClusterSpec = cc.Spec.DeepCopy()
+	failedPod := helperPod(cc, "dc1", "rack2", 0, false)
+	failedPod.Status.InitContainerStatuses = []v1.ContainerStatus{v1.ContainerStatus{
+		Name:                 cassandracluster.RestoreContainerName,
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}},
+	}}
+	r := helperInitRestore([]runtime.Object{cr, cb, helperPod(cc, "dc1", "rack1", 0, true), failedPod},
+		helperBackupObjects(), fakePodTableFiles{})
+
+	helperReconcile(t, r, cr)
+	restore := helperReconcile(t, r, cr)
+
+	assert.Equal(api.StatusError, restore.Status.Phase)
+	assert.Equal([]string{"cassandra-demo-dc1-rack2-0"}, restore.Status.CassandraRackStatus["dc1-rack2"].PodsKO)
+}
+
+func TestCassandraRestoreNewClusterBadName(t *testing.T) {
+	assert := assert.New(t)
+
+	cr, cb, cc := helperLoadObjects(t)
+	cr.Spec.Cluster = "cassandra-other"
+	cr.Spec.ClusterSpec = cc.Spec.DeepCopy()
+	r := helperInitRestore([]runtime.Object{cr, cb}, helperBackupObjects(), fakePodTableFiles{})
+
+	restore := helperReconcile(t, r, cr)
+
+	assert.Equal(api.StatusError, restore.Status.Phase)
+	assert.Equal("A new cluster must be named cassandra-demo like the cluster of backup backup1",
+		restore.Status.Error)
+}
+
+func TestTableName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("users", tableName("users-1c42e8b0b0a811e9a4b3b5f2c1a3c0a4"))
+	assert.Equal("user_events", tableName("user_events-1c42e8b0b0a811e9a4b3b5f2c1a3c0a4"))
+	assert.Equal("users", tableName("users"))
+}
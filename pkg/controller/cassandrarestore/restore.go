@@ -0,0 +1,482 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandrabackup"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var reEndingNumber = regexp.MustCompile("[0-9]+$")
+
+//podOrdinal returns the ordinal of a pod of a statefulset
+func podOrdinal(podName string) (int, error) {
+	return strconv.Atoi(reEndingNumber.FindString(podName))
+}
+
+func (r *ReconcileCassandraRestore) listPods(cc *api.CassandraCluster) (*v1.PodList, error) {
+	opt := &client.ListOptions{
+		Namespace:     cc.Namespace,
+		LabelSelector: labels.SelectorFromSet(k8s.LabelsForCassandra(cc)),
+	}
+	pl := &v1.PodList{}
+	return pl, r.client.List(context.TODO(), opt, pl)
+}
+
+func (r *ReconcileCassandraRestore) getPod(namespace, name string) (*v1.Pod, error) {
+	pod := &v1.Pod{}
+	return pod, r.client.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, pod)
+}
+
+func (r *ReconcileCassandraRestore) jolokiaClient(cc *api.CassandraCluster,
+	pod *v1.Pod) (*cassandracluster.JolokiaClient, error) {
+	hostName := fmt.Sprintf("%s.%s", pod.Spec.Hostname, pod.Spec.Subdomain)
+	return cassandracluster.NewJolokiaClientWithK8sClient(hostName, cassandracluster.JolokiaPort, r.client,
+		cc.Spec.ImageJolokiaSecret, cc.Namespace)
+}
+
+//updateCassandraRestoreStatus updates the CassandraRestore if its status has changed
+func (r *ReconcileCassandraRestore) updateCassandraRestoreStatus(cr *api.CassandraRestore,
+	status *api.CassandraRestoreStatus) error {
+	if reflect.DeepEqual(cr.Status, *status) {
+		return nil
+	}
+	cr.Status = *status
+	err := r.client.Update(context.TODO(), cr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"restore": cr.Name}).Errorf("Issue when updating CassandraRestore: %v", err)
+	}
+	return err
+}
+
+//initRestore checks that the backup can be restored in the cluster and prepares the restore
+//A new cluster is created with its nodes seeded by the backup, the tables of an existing cluster are truncated
+func (r *ReconcileCassandraRestore) initRestore(cr *api.CassandraRestore, cb *api.CassandraBackup,
+	status *api.CassandraRestoreStatus) error {
+	now := metav1.Now()
+	status.Phase = api.StatusOngoing
+	status.StartTime = &now
+
+	cc := &api.CassandraCluster{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Cluster, Namespace: cr.Namespace}, cc)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !cr.IsNewCluster() {
+		if err != nil {
+			return fmt.Errorf("CassandraCluster %s does not exist", cr.Spec.Cluster)
+		}
+		if err = initRacks(cb, cc, status); err != nil {
+			return err
+		}
+		return r.truncateTables(cr, cb, cc, status)
+	}
+
+	if err == nil {
+		return fmt.Errorf("CassandraCluster %s already exists", cr.Spec.Cluster)
+	}
+	cc, err = newCassandraCluster(cr, cb)
+	if err != nil {
+		return err
+	}
+	if err = initRacks(cb, cc, status); err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"restore": cr.Name, "cluster": cc.Name,
+		"backup": cb.Name}).Info("Create CassandraCluster seeded with backup")
+	return r.client.Create(context.TODO(), cc)
+}
+
+//newCassandraCluster returns the cluster to create with its nodes seeded by the backup
+func newCassandraCluster(cr *api.CassandraRestore, cb *api.CassandraBackup) (*api.CassandraCluster, error) {
+	//The name of the cluster is stored in the system keyspaces of the backup
+	if cr.Spec.Cluster != cb.Spec.Cluster {
+		return nil, fmt.Errorf("A new cluster must be named %s like the cluster of backup %s",
+			cb.Spec.Cluster, cb.Name)
+	}
+	spec := cr.Spec.ClusterSpec.DeepCopy()
+	//Every DC must have persistent volumes, dataCapacity can be set per DC
+	missingDataCapacity := spec.DataCapacity == "" && len(spec.Topology.DC) == 0
+	for _, dc := range spec.Topology.DC {
+		if spec.DataCapacity == "" && dc.DataCapacity == "" {
+			missingDataCapacity = true
+		}
+	}
+	if missingDataCapacity {
+		return nil, fmt.Errorf("dataCapacity must be set to seed the nodes of a new cluster")
+	}
+	spec.RestoreFrom = &api.RestoreFrom{
+		Backup:  cb.Name,
+		Storage: cb.Spec.Storage,
+		Image:   cr.Spec.Image,
+	}
+	return &api.CassandraCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CassandraCluster",
+			APIVersion: api.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Spec.Cluster,
+			Namespace: cr.Namespace,
+		},
+		Spec: *spec,
+	}, nil
+}
+
+//initRacks maps each pod of the backup to the pod with the same ordinal in the same dc-rack of the cluster
+func initRacks(cb *api.CassandraBackup, cc *api.CassandraCluster, status *api.CassandraRestoreStatus) error {
+	cc = cc.DeepCopy()
+	cc.InitCassandraRackList()
+
+	status.CassandraRackStatus = map[string]*api.PodLastOperation{}
+	for podName, podStatus := range cb.Status.Pods {
+		dcRackName := podStatus.DCRackName
+		if _, ok := cc.Status.CassandraRackStatus[dcRackName]; !ok {
+			return fmt.Errorf("Rack %s of backup %s does not exist in cluster %s", dcRackName, cb.Name, cc.Name)
+		}
+		ordinal, err := podOrdinal(podName)
+		if err != nil {
+			return fmt.Errorf("Cannot get ordinal of pod %s: %v", podName, err)
+		}
+		if int32(ordinal) >= cc.GetNodesPerRacks(dcRackName) {
+			return fmt.Errorf("Rack %s of cluster %s has not enough nodes to restore pod %s", dcRackName,
+				cc.Name, podName)
+		}
+		rackStatus, ok := status.CassandraRackStatus[dcRackName]
+		if !ok {
+			rackStatus = &api.PodLastOperation{Name: api.OperationRestore, Status: api.StatusToDo}
+			status.CassandraRackStatus[dcRackName] = rackStatus
+		}
+		rackStatus.Pods = append(rackStatus.Pods, fmt.Sprintf("%s-%s-%d", cc.Name, dcRackName, ordinal))
+	}
+	if len(status.CassandraRackStatus) == 0 {
+		return fmt.Errorf("Backup %s has no pod to restore", cb.Name)
+	}
+	for _, rackStatus := range status.CassandraRackStatus {
+		sort.Strings(rackStatus.Pods)
+	}
+	return nil
+}
+
+//keyspaceToRestore returns true if the keyspace has to be restored in an existing cluster
+//The system keyspaces are only restored when seeding a new cluster
+func keyspaceToRestore(cr *api.CassandraRestore, keyspace string) bool {
+	if len(cr.Spec.Keyspaces) > 0 {
+		return k8s.Contains(cr.Spec.Keyspaces, keyspace)
+	}
+	return !strings.HasPrefix(keyspace, "system")
+}
+
+//truncateTables finds the tables of the backup and truncates them in the cluster
+func (r *ReconcileCassandraRestore) truncateTables(cr *api.CassandraRestore, cb *api.CassandraBackup,
+	cc *api.CassandraCluster, status *api.CassandraRestoreStatus) error {
+	storage, err := r.newStorage(r.client, cb.Namespace, cb.Spec.Storage)
+	if err != nil {
+		return err
+	}
+	status.Tables = []string{}
+	for dcRackName := range status.CassandraRackStatus {
+		prefix := cb.Spec.Storage.RackKeyPrefix(cb.Name, dcRackName)
+		keys, err := storage.ListObjects(prefix)
+		if err != nil {
+			return fmt.Errorf("Cannot list files of backup %s: %v", cb.Name, err)
+		}
+		for _, key := range keys {
+			//key is <prefix><ordinal>/<keyspace>/<table>-<id>/<file>
+			parts := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 4)
+			if len(parts) != 4 || !keyspaceToRestore(cr, parts[1]) {
+				continue
+			}
+			table := parts[1] + "." + tableName(parts[2])
+			if !k8s.Contains(status.Tables, table) {
+				status.Tables = append(status.Tables, table)
+			}
+		}
+	}
+	if len(status.Tables) == 0 {
+		return fmt.Errorf("Backup %s has no table to restore", cb.Name)
+	}
+	sort.Strings(status.Tables)
+
+	pod, err := r.firstReadyPod(cc)
+	if err != nil {
+		return err
+	}
+	jolokiaClient, err := r.jolokiaClient(cc, pod)
+	if err != nil {
+		return err
+	}
+	for _, table := range status.Tables {
+		keyspaceTable := strings.SplitN(table, ".", 2)
+		if err = jolokiaClient.NodeTruncate(keyspaceTable[0], keyspaceTable[1]); err != nil {
+			return err
+		}
+	}
+	logrus.WithFields(logrus.Fields{"restore": cr.Name, "cluster": cc.Name}).Infof("Truncated tables %v",
+		status.Tables)
+	return nil
+}
+
+func (r *ReconcileCassandraRestore) firstReadyPod(cc *api.CassandraCluster) (*v1.Pod, error) {
+	podsList, err := r.listPods(cc)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(podsList.Items, func(i, j int) bool { return podsList.Items[i].Name < podsList.Items[j].Name })
+	for i := range podsList.Items {
+		if cassandracluster.PodContainersReady(&podsList.Items[i]) {
+			return &podsList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("There is no ready pod in cluster %s", cc.Name)
+}
+
+func podDone(rackStatus *api.PodLastOperation, podName string) bool {
+	return k8s.Contains(rackStatus.PodsOK, podName) || k8s.Contains(rackStatus.PodsKO, podName)
+}
+
+//nextPodToRestore returns the first pod to restore in the first rack, in alphabetical order, not yet restored
+func nextPodToRestore(status *api.CassandraRestoreStatus) (string, string) {
+	dcRackNames := []string{}
+	for dcRackName := range status.CassandraRackStatus {
+		dcRackNames = append(dcRackNames, dcRackName)
+	}
+	sort.Strings(dcRackNames)
+	for _, dcRackName := range dcRackNames {
+		rackStatus := status.CassandraRackStatus[dcRackName]
+		for _, podName := range rackStatus.Pods {
+			if !podDone(rackStatus, podName) {
+				return dcRackName, podName
+			}
+		}
+	}
+	return "", ""
+}
+
+//restorePod loads the files of the backup in a pod of an existing cluster
+//It returns false if the pod is not ready yet
+func (r *ReconcileCassandraRestore) restorePod(cr *api.CassandraRestore, cb *api.CassandraBackup,
+	cc *api.CassandraCluster, dcRackName, podName string, status *api.CassandraRestoreStatus) bool {
+	rackStatus := status.CassandraRackStatus[dcRackName]
+	logger := logrus.WithFields(logrus.Fields{"restore": cr.Name, "cluster": cc.Name, "pod": podName})
+
+	pod, err := r.getPod(cc.Namespace, podName)
+	if err == nil && !cassandracluster.PodContainersReady(pod) {
+		logger.Info("Pod is not ready, waiting..")
+		return false
+	}
+
+	if rackStatus.Status == api.StatusToDo {
+		now := metav1.Now()
+		rackStatus.Status = api.StatusOngoing
+		rackStatus.StartTime = &now
+	}
+
+	if err == nil {
+		err = r.restorePodFiles(cb, cc, pod, dcRackName, status.Tables)
+	}
+	if err != nil {
+		logger.Errorf("Restore failed: %v", err)
+		rackStatus.PodsKO = append(rackStatus.PodsKO, podName)
+	} else {
+		logger.Info("Restore done")
+		rackStatus.PodsOK = append(rackStatus.PodsOK, podName)
+	}
+	finalizeRack(rackStatus)
+	return true
+}
+
+func (r *ReconcileCassandraRestore) restorePodFiles(cb *api.CassandraBackup, cc *api.CassandraCluster, pod *v1.Pod,
+	dcRackName string, tables []string) error {
+	ordinal, err := podOrdinal(pod.Name)
+	if err != nil {
+		return fmt.Errorf("Cannot get ordinal of pod %s: %v", pod.Name, err)
+	}
+	storage, err := r.newStorage(r.client, cb.Namespace, cb.Spec.Storage)
+	if err != nil {
+		return err
+	}
+	prefix := cb.Spec.Storage.PodKeyPrefix(cb.Name, dcRackName, ordinal)
+	keys, err := storage.ListObjects(prefix)
+	if err != nil {
+		return fmt.Errorf("Cannot list files of backup %s: %v", cb.Name, err)
+	}
+
+	tableDirs := map[string]string{}
+	for _, key := range keys {
+		//key is <prefix><keyspace>/<table>-<id>/<file>
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		table := parts[0] + "." + tableName(parts[1])
+		if !k8s.Contains(tables, table) {
+			continue
+		}
+		//The id of the table may have changed since the backup
+		tableDir, ok := tableDirs[table]
+		if !ok {
+			if tableDir, err = r.files.TableDir(pod, parts[0], tableName(parts[1])); err != nil {
+				return err
+			}
+			tableDirs[table] = tableDir
+		}
+		if err = r.downloadFile(pod, storage, key, tableDir+"/"+parts[2]); err != nil {
+			return err
+		}
+	}
+
+	jolokiaClient, err := r.jolokiaClient(cc, pod)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, ok := tableDirs[table]; !ok {
+			continue
+		}
+		keyspaceTable := strings.SplitN(table, ".", 2)
+		if err = jolokiaClient.NodeLoadNewSSTables(keyspaceTable[0], keyspaceTable[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//downloadFile streams a file from the object store to a pod
+func (r *ReconcileCassandraRestore) downloadFile(pod *v1.Pod, storage cassandrabackup.ObjectStorage,
+	key, path string) error {
+	object, err := storage.GetObject(key)
+	if err != nil {
+		return fmt.Errorf("Cannot download %s: %v", key, err)
+	}
+	defer object.Close()
+	return r.files.WriteFile(pod, path, object)
+}
+
+//checkSeededPods updates the status of the pods of a new cluster, a pod is restored once it is ready
+//It returns true once all pods are either restored or failed
+func (r *ReconcileCassandraRestore) checkSeededPods(cr *api.CassandraRestore, cc *api.CassandraCluster,
+	status *api.CassandraRestoreStatus) bool {
+	done := true
+	for _, rackStatus := range status.CassandraRackStatus {
+		if rackStatus.Status == api.StatusDone {
+			continue
+		}
+		if rackStatus.Status == api.StatusToDo {
+			now := metav1.Now()
+			rackStatus.Status = api.StatusOngoing
+			rackStatus.StartTime = &now
+		}
+		for _, podName := range rackStatus.Pods {
+			if podDone(rackStatus, podName) {
+				continue
+			}
+			pod, err := r.getPod(cc.Namespace, podName)
+			if err != nil {
+				continue
+			}
+			if restoreContainerFailed(pod) {
+				logrus.WithFields(logrus.Fields{"restore": cr.Name, "cluster": cc.Name,
+					"pod": podName}).Error("Restore failed")
+				rackStatus.PodsKO = append(rackStatus.PodsKO, podName)
+			} else if cassandracluster.PodContainersReady(pod) {
+				logrus.WithFields(logrus.Fields{"restore": cr.Name, "cluster": cc.Name,
+					"pod": podName}).Info("Restore done")
+				rackStatus.PodsOK = append(rackStatus.PodsOK, podName)
+			}
+		}
+		finalizeRack(rackStatus)
+		done = done && rackStatus.Status == api.StatusDone
+	}
+	return done
+}
+
+//clearRestoreFrom removes RestoreFrom from the spec of a new cluster once its pods are seeded, so that the nodes added
+//later don't download the files of the backup
+func (r *ReconcileCassandraRestore) clearRestoreFrom(cc *api.CassandraCluster) error {
+	if cc.Spec.RestoreFrom == nil {
+		return nil
+	}
+	cc.Spec.RestoreFrom = nil
+	return r.client.Update(context.TODO(), cc)
+}
+
+//restoreContainerFailed returns true if the init container downloading the files of the pod has failed
+func restoreContainerFailed(pod *v1.Pod) bool {
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		if containerStatus.Name != cassandracluster.RestoreContainerName {
+			continue
+		}
+		for _, terminated := range []*v1.ContainerStateTerminated{containerStatus.State.Terminated,
+			containerStatus.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.ExitCode != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//finalizeRack sets the rack to Done once all its pods are either restored or failed
+func finalizeRack(rackStatus *api.PodLastOperation) {
+	if len(rackStatus.PodsOK)+len(rackStatus.PodsKO) < len(rackStatus.Pods) {
+		return
+	}
+	now := metav1.Now()
+	rackStatus.Status = api.StatusDone
+	rackStatus.EndTime = &now
+}
+
+func setRestoreError(cr *api.CassandraRestore, status *api.CassandraRestoreStatus, err error) {
+	logrus.WithFields(logrus.Fields{"restore": cr.Name}).Errorf("Restore failed: %v", err)
+	now := metav1.Now()
+	status.Phase = api.StatusError
+	status.Error = err.Error()
+	status.EndTime = &now
+}
+
+//finalizeRestore sets the phase of the restore once all racks are Done
+func finalizeRestore(cr *api.CassandraRestore, status *api.CassandraRestoreStatus) {
+	now := metav1.Now()
+	status.Phase = api.StatusDone
+	status.EndTime = &now
+	for dcRackName, rackStatus := range status.CassandraRackStatus {
+		if len(rackStatus.PodsKO) > 0 {
+			logrus.WithFields(logrus.Fields{"restore": cr.Name,
+				"rack": dcRackName}).Errorf("Restore failed on pods %v", rackStatus.PodsKO)
+			status.Phase = api.StatusError
+		}
+	}
+	logrus.WithFields(logrus.Fields{"restore": cr.Name}).Infof("Restore ended with status %s", status.Phase)
+}
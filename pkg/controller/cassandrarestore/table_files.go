@@ -0,0 +1,69 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarestore
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandrabackup"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+)
+
+//PodTableFiles gives access to the table directories of the cassandra container of a pod
+type PodTableFiles interface {
+	//TableDir returns the directory of a table, relative to CassandraDataDir
+	TableDir(pod *v1.Pod, keyspace, table string) (string, error)
+	//WriteFile writes the content of reader to the file at path (relative to CassandraDataDir)
+	WriteFile(pod *v1.Pod, path string, reader io.Reader) error
+}
+
+//execPodTableFiles accesses the files by executing commands in the cassandra container
+type execPodTableFiles struct{}
+
+//TableDir returns the most recent directory of the table as the directories of dropped tables are kept
+func (execPodTableFiles) TableDir(pod *v1.Pod, keyspace, table string) (string, error) {
+	k8s.InitClient()
+	stdout, stderr, err := k8s.ExecPod(pod.Namespace, pod, []string{"sh", "-c", `ls -td "$0"/"$1"-* | head -1`,
+		cassandrabackup.CassandraDataDir + "/" + keyspace, table})
+	if err != nil {
+		return "", fmt.Errorf("Cannot find directory of table %s.%s: %v %s", keyspace, table, err, stderr)
+	}
+	dir := strings.TrimSpace(stdout)
+	if dir == "" {
+		return "", fmt.Errorf("Table %s.%s does not exist on pod %s", keyspace, table, pod.Name)
+	}
+	return strings.TrimPrefix(dir, cassandrabackup.CassandraDataDir+"/"), nil
+}
+
+func (execPodTableFiles) WriteFile(pod *v1.Pod, path string, reader io.Reader) error {
+	k8s.InitClient()
+	stderr, err := k8s.ExecPodStream(pod.Namespace, pod, []string{"sh", "-c", `mkdir -p "$(dirname "$0")" && cat > "$0"`,
+		cassandrabackup.CassandraDataDir + "/" + path}, reader, nil)
+	if err != nil {
+		return fmt.Errorf("Cannot write file %s: %v %s", path, err, stderr)
+	}
+	return nil
+}
+
+//tableName returns the name of a table from its directory <table>-<id>
+func tableName(tableDir string) string {
+	if i := strings.LastIndex(tableDir, "-"); i > 0 {
+		return tableDir[:i]
+	}
+	return tableDir
+}
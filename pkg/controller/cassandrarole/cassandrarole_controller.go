@@ -0,0 +1,188 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarole
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_cassandrarole")
+
+// Add creates a new CassandraRole Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCassandraRole{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		recorder: mgr.GetRecorder("cassandrarole-controller"), cqlClient: cql.NewClient()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("cassandrarole-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CassandraRole
+	err = c.Watch(&source.Kind{Type: &api.CassandraRole{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the CassandraClusters so that the roles are created once their cluster is running
+	err = c.Watch(&source.Kind{Type: &api.CassandraCluster{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			return rolesOf(mgr.GetClient(), object.Meta.GetNamespace(), func(cr *api.CassandraRole) bool {
+				return cr.Spec.Cluster == object.Meta.GetName()
+			})
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Secrets so that the password of a role is changed as soon as its Secret is updated
+	return c.Watch(&source.Kind{Type: &v1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			return rolesOf(mgr.GetClient(), object.Meta.GetNamespace(), func(cr *api.CassandraRole) bool {
+				return cr.Spec.PasswordSecret != nil && cr.Spec.PasswordSecret.Name == object.Meta.GetName()
+			})
+		}),
+	})
+}
+
+//rolesOf returns the requests of the CassandraRoles of a namespace matching filter
+func rolesOf(k8sClient client.Client, namespace string, filter func(*api.CassandraRole) bool) []reconcile.Request {
+	roles := &api.CassandraRoleList{}
+	if err := k8sClient.List(context.TODO(), &client.ListOptions{Namespace: namespace}, roles); err != nil {
+		logrus.WithFields(logrus.Fields{"namespace": namespace}).Errorf("Can't list CassandraRoles: %v", err)
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range roles.Items {
+		if filter(&roles.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: roles.Items[i].Namespace, Name: roles.Items[i].Name}})
+		}
+	}
+	return requests
+}
+
+var _ reconcile.Reconciler = &ReconcileCassandraRole{}
+
+// ReconcileCassandraRole reconciles a CassandraRole object
+type ReconcileCassandraRole struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+
+	// recorder records the events of the roles
+	recorder record.EventRecorder
+	// cqlClient opens the CQL sessions creating and altering the roles
+	cqlClient cql.Client
+}
+
+// Reconcile creates the role of a CassandraRole in its CassandraCluster once the cluster is running, then alters it
+// and its grants each time they change or its password Secret is updated. The applied state is stored in
+// CassandraRole.Status
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCassandraRole) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraRole")
+
+	requeue30 := reconcile.Result{RequeueAfter: 30 * time.Second}
+	forget := reconcile.Result{}
+
+	// Fetch the CassandraRole instance
+	cr := &api.CassandraRole{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, cr)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return forget, nil
+		}
+		return forget, err
+	}
+
+	status := cr.Status.DeepCopy()
+
+	//We Update Status at the end
+	defer r.updateCassandraRoleStatus(cr, status)
+
+	cc := &api.CassandraCluster{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Cluster, Namespace: cr.Namespace}, cc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logrus.WithFields(logrus.Fields{"role": cr.Name,
+				"cluster": cr.Spec.Cluster}).Warn("CassandraCluster of role does not exist, waiting..")
+			setRolePending(status)
+			return requeue30, nil
+		}
+		return forget, err
+	}
+
+	if err = validateRole(cr, cc); err != nil {
+		r.setRoleError(cr, status, err)
+		return forget, nil
+	}
+
+	password, passwordSecretVersion, err := r.getPassword(cr)
+	if err != nil {
+		r.setRoleError(cr, status, err)
+		return requeue30, nil
+	}
+
+	if cr.IsApplied(passwordSecretVersion) {
+		return forget, nil
+	}
+
+	if cc.Status.Phase != api.ClusterPhaseRunning {
+		logrus.WithFields(logrus.Fields{"role": cr.Name,
+			"cluster": cc.Name}).Info("CassandraCluster of role is not running yet, waiting..")
+		setRolePending(status)
+		return requeue30, nil
+	}
+
+	if err = r.applyRole(cr, cc, password, passwordSecretVersion, status); err != nil {
+		r.setRoleError(cr, status, err)
+		return requeue30, nil
+	}
+	return forget, nil
+}
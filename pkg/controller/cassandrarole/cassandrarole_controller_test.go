@@ -0,0 +1,176 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarole
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	cqlfake "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql/fake"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	path := filepath.Join("testdata", name) // relative path
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+func helperInitRole(t *testing.T) (*ReconcileCassandraRole, *api.CassandraRole, *v1.Secret, *cqlfake.Client) {
+	var cc api.CassandraCluster
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandracluster.yaml"), &cc); err != nil {
+		t.Fatal(err)
+	}
+	var cr api.CassandraRole
+	if err := yaml.Unmarshal(helperLoadBytes(t, "cassandrarole.yaml"), &cr); err != nil {
+		t.Fatal(err)
+	}
+	secret := &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-app-password", Namespace: "ns", ResourceVersion: "1"},
+		Data:       map[string][]byte{api.CredentialsPasswordKey: []byte("it's secret")},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraCluster{}, &api.CassandraClusterList{},
+		&api.CassandraRole{}, &api.CassandraRoleList{})
+	cl := fake.NewFakeClient([]runtime.Object{&cc, &cr, secret}...)
+	cqlClient := cqlfake.NewClient()
+	r := &ReconcileCassandraRole{client: cl, scheme: s, recorder: record.NewFakeRecorder(10), cqlClient: cqlClient}
+	return r, &cr, secret, cqlClient
+}
+
+func helperReconcileRole(t *testing.T, r *ReconcileCassandraRole, cr *api.CassandraRole) *api.CassandraRole {
+	name := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	cr = &api.CassandraRole{}
+	if err := r.client.Get(context.TODO(), name, cr); err != nil {
+		t.Fatalf("get role: (%v)", err)
+	}
+	return cr
+}
+
+func helperUpdate(t *testing.T, r *ReconcileCassandraRole, object runtime.Object) {
+	if err := r.client.Update(context.TODO(), object); err != nil {
+		t.Fatalf("update: (%v)", err)
+	}
+}
+
+func TestCassandraRole(t *testing.T) {
+	assert := assert.New(t)
+
+	r, cr, secret, cqlClient := helperInitRole(t)
+
+	//The role is created with its password and its grants
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal(api.StatusDone, cr.Status.Phase)
+	assert.Equal(&cqlfake.Role{Password: "it's secret", Login: true}, cqlClient.Roles["demo_app"])
+	assert.Equal([]string{
+		`GRANT SELECT ON KEYSPACE "demo" TO 'demo_app'`,
+		`GRANT MODIFY ON TABLE "demo"."users" TO 'demo_app'`,
+	}, cqlClient.Executed("GRANT"))
+	assert.Equal("demo_app", cr.Status.Name)
+	assert.Equal(cr.Spec.Grants, cr.Status.Grants)
+	assert.Equal(secret.ResourceVersion, cr.Status.PasswordSecretVersion)
+
+	//Nothing is done while the role is applied
+	statements := len(cqlClient.Statements)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal(statements, len(cqlClient.Statements))
+
+	//The password is changed when the Secret is updated
+	secret.Data[api.CredentialsPasswordKey] = []byte("new secret")
+	secret.ResourceVersion = "2"
+	helperUpdate(t, r, secret)
+	assert.Equal([]reconcile.Request{{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}}},
+		rolesOf(r.client, "ns", func(cr *api.CassandraRole) bool {
+			return cr.Spec.PasswordSecret.Name == secret.Name
+		}))
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal("new secret", cqlClient.Roles["demo_app"].Password)
+	assert.Equal("2", cr.Status.PasswordSecretVersion)
+	assert.Equal(2, len(cqlClient.Executed("GRANT")))
+
+	//The grants removed are revoked and the new ones granted
+	cr.Spec.Grants = []api.RoleGrant{{Permission: "SELECT", Keyspace: "demo"}, {Permission: "SELECT"}}
+	cr.Spec.Superuser = true
+	helperUpdate(t, r, cr)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal([]string{`REVOKE MODIFY ON TABLE "demo"."users" FROM 'demo_app'`}, cqlClient.Executed("REVOKE"))
+	assert.Equal(`GRANT SELECT ON ALL KEYSPACES TO 'demo_app'`, cqlClient.Executed("GRANT")[2])
+	assert.True(cqlClient.Roles["demo_app"].Superuser)
+	assert.Equal(cr.Spec.Grants, cr.Status.Grants)
+}
+
+func TestCassandraRoleErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	r, cr, _, cqlClient := helperInitRole(t)
+
+	//The default superuser is managed by the CassandraCluster
+	cr.Spec.Name = "cassandra"
+	helperUpdate(t, r, cr)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal(api.StatusError, cr.Status.Phase)
+	assert.Equal("Role cassandra is managed by CassandraCluster cassandra-demo", cr.Status.Message)
+	assert.Empty(cqlClient.Statements)
+
+	//A role logging in must have a password
+	cr.Spec.Name = "demo_app"
+	cr.Spec.PasswordSecret = nil
+	helperUpdate(t, r, cr)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal("Role demo_app must have a passwordSecret to log in", cr.Status.Message)
+
+	cr.Spec.PasswordSecret = &v1.LocalObjectReference{Name: "unknown"}
+	helperUpdate(t, r, cr)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal(api.StatusError, cr.Status.Phase)
+	assert.Empty(cqlClient.Statements)
+
+	//The role is applied once the spec is fixed
+	cr.Spec.PasswordSecret.Name = "demo-app-password"
+	helperUpdate(t, r, cr)
+	cr = helperReconcileRole(t, r, cr)
+	assert.Equal(api.StatusDone, cr.Status.Phase)
+	assert.Empty(cr.Status.Message)
+}
+
+func TestRoleGrantValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(api.RoleGrant{Permission: "select", Keyspace: "demo", Table: "users"}.Validate())
+	assert.Nil(api.RoleGrant{Permission: "ALL"}.Validate())
+	assert.NotNil(api.RoleGrant{Permission: "EXECUTE", Keyspace: "demo"}.Validate())
+	assert.NotNil(api.RoleGrant{Permission: "SELECT", Table: "users"}.Validate())
+	assert.NotNil(api.RoleGrant{Permission: "SELECT", Keyspace: `demo"; DROP KEYSPACE demo`}.Validate())
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandrarole
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/controller/cassandracluster"
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//Reasons of the events recorded on the roles
+const (
+	reasonRoleApplied         = "RoleApplied"
+	reasonRoleFailed          = "RoleFailed"
+	reasonRolePasswordChanged = "RolePasswordChanged"
+)
+
+//updateCassandraRoleStatus updates the CassandraRole if its status has changed
+func (r *ReconcileCassandraRole) updateCassandraRoleStatus(cr *api.CassandraRole,
+	status *api.CassandraRoleStatus) error {
+	if reflect.DeepEqual(cr.Status, *status) {
+		return nil
+	}
+	cr.Status = *status
+	err := r.client.Update(context.TODO(), cr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"role": cr.Name}).Errorf("Issue when updating CassandraRole: %v", err)
+	}
+	return err
+}
+
+//recordEvent records an event on the role if the reconciler has a recorder
+func (r *ReconcileCassandraRole) recordEvent(cr *api.CassandraRole, eventType, reason, messageFmt string,
+	args ...interface{}) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Eventf(cr, eventType, reason, messageFmt, args...)
+}
+
+//setRolePending sets the phase of a role which has never been applied to Pending
+func setRolePending(status *api.CassandraRoleStatus) {
+	if status.Phase == "" {
+		status.Phase = api.RolePhasePending
+	}
+}
+
+//setRoleError stores err in the status of the role and records it as an event
+func (r *ReconcileCassandraRole) setRoleError(cr *api.CassandraRole, status *api.CassandraRoleStatus, err error) {
+	logrus.WithFields(logrus.Fields{"role": cr.Name, "cluster": cr.Spec.Cluster}).Errorf(
+		"Role %s not applied: %v", cr.Spec.Name, err)
+	if status.Phase != api.StatusError || status.Message != err.Error() {
+		now := metav1.Now()
+		status.LastUpdateTime = &now
+		r.recordEvent(cr, v1.EventTypeWarning, reasonRoleFailed, "Role %s not applied: %v", cr.Spec.Name, err)
+	}
+	status.Phase = api.StatusError
+	status.Message = err.Error()
+}
+
+//validateRole returns an error if the role is invalid or is a superuser managed by the CassandraCluster
+func validateRole(cr *api.CassandraRole, cc *api.CassandraCluster) error {
+	if err := cr.Validate(); err != nil {
+		return err
+	}
+	if cr.Spec.Name == cql.DefaultUsername ||
+		(cc.Status.Authentication != nil && cr.Spec.Name == cc.Status.Authentication.Superuser) {
+		return fmt.Errorf("Role %s is managed by CassandraCluster %s", cr.Spec.Name, cc.Name)
+	}
+	return nil
+}
+
+//getPassword returns the password of the role and the resource version of its Secret, or empty strings if the role
+//has no password
+func (r *ReconcileCassandraRole) getPassword(cr *api.CassandraRole) (string, string, error) {
+	if cr.Spec.PasswordSecret == nil || cr.Spec.PasswordSecret.Name == "" {
+		return "", "", nil
+	}
+	secret := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace,
+		Name: cr.Spec.PasswordSecret.Name}, secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get password secret %s: %v", cr.Spec.PasswordSecret.Name, err)
+	}
+	password := string(secret.Data[api.CredentialsPasswordKey])
+	if password == "" {
+		return "", "", fmt.Errorf("Secret %s must have a %s key", secret.Name, api.CredentialsPasswordKey)
+	}
+	return password, secret.ResourceVersion, nil
+}
+
+//roleOptions returns the options of the role in CQL
+func roleOptions(cr *api.CassandraRole, password string) string {
+	options := []string{}
+	if password != "" {
+		options = append(options, fmt.Sprintf("PASSWORD = %s", cql.Literal(password)))
+	}
+	options = append(options, fmt.Sprintf("LOGIN = %t", cr.Spec.Login),
+		fmt.Sprintf("SUPERUSER = %t", cr.Spec.Superuser))
+	return strings.Join(options, " AND ")
+}
+
+//applyRole creates the role if it does not exist then alters it so that an existing role gets the requested options
+//and password. The grants removed from the spec since the last apply are revoked and the new ones granted.
+//The applied state is stored in the status
+func (r *ReconcileCassandraRole) applyRole(cr *api.CassandraRole, cc *api.CassandraCluster, password,
+	passwordSecretVersion string, status *api.CassandraRoleStatus) error {
+	cqlClient := r.cqlClient
+	if cqlClient == nil {
+		cqlClient = cql.NewClient()
+	}
+	username, superuserPassword, err := cassandracluster.CQLSuperuserCredentials(r.client, cc)
+	if err != nil {
+		return err
+	}
+	session, err := cassandracluster.NewCQLSession(cqlClient, r.client, cc, username, superuserPassword)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CassandraCluster %s: %v", cc.Name, err)
+	}
+	defer session.Close()
+
+	logrus.WithFields(logrus.Fields{"role": cr.Name, "cluster": cc.Name}).Infof(
+		"Apply role %s with LOGIN = %t AND SUPERUSER = %t", cr.Spec.Name, cr.Spec.Login, cr.Spec.Superuser)
+	options := roleOptions(cr, password)
+	statements := []string{
+		fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s WITH %s", cql.Literal(cr.Spec.Name), options),
+		fmt.Sprintf("ALTER ROLE %s WITH %s", cql.Literal(cr.Spec.Name), options),
+	}
+	for _, grant := range status.Grants {
+		if !api.HasGrant(cr.Spec.Grants, grant) {
+			statements = append(statements, fmt.Sprintf("REVOKE %s ON %s FROM %s", strings.ToUpper(grant.Permission),
+				grant.Resource(), cql.Literal(cr.Spec.Name)))
+		}
+	}
+	for _, grant := range cr.Spec.Grants {
+		if !api.HasGrant(status.Grants, grant) || status.Name == "" {
+			statements = append(statements, fmt.Sprintf("GRANT %s ON %s TO %s", strings.ToUpper(grant.Permission),
+				grant.Resource(), cql.Literal(cr.Spec.Name)))
+		}
+	}
+	for _, statement := range statements {
+		if err = session.Exec(statement); err != nil {
+			//The password must not be logged
+			return fmt.Errorf("failed to apply role %s: %v", cr.Spec.Name, err)
+		}
+	}
+
+	if status.PasswordSecretVersion != "" && status.PasswordSecretVersion != passwordSecretVersion {
+		r.recordEvent(cr, v1.EventTypeNormal, reasonRolePasswordChanged, "Password of role %s changed from Secret %s",
+			cr.Spec.Name, cr.Spec.PasswordSecret.Name)
+	}
+
+	now := metav1.Now()
+	status.Phase = api.StatusDone
+	status.Message = ""
+	status.Name = cr.Spec.Name
+	status.Login = cr.Spec.Login
+	status.Superuser = cr.Spec.Superuser
+	status.PasswordSecretVersion = passwordSecretVersion
+	status.Grants = append([]api.RoleGrant{}, cr.Spec.Grants...)
+	status.LastUpdateTime = &now
+	r.recordEvent(cr, v1.EventTypeNormal, reasonRoleApplied, "Role %s applied", cr.Spec.Name)
+	return nil
+}
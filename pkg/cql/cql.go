@@ -0,0 +1,119 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+//Package cql executes the CQL statements of the Operator on the Cassandra clusters
+package cql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	//DefaultUsername and DefaultPassword are the credentials of the superuser created by Cassandra
+	DefaultUsername = "cassandra"
+	DefaultPassword = "cassandra"
+
+	connectTimeout = 10 * time.Second
+	queryTimeout   = 30 * time.Second
+)
+
+//Session executes CQL statements on a Cassandra cluster
+type Session interface {
+	Exec(statement string, values ...interface{}) error
+	Close()
+}
+
+//Client opens Sessions on Cassandra clusters, it is an interface so that it can be faked in tests
+type Client interface {
+	NewSession(hosts []string, port int, username, password string, tlsConfig *tls.Config) (Session, error)
+}
+
+//NewClient returns a Client connecting to the clusters with gocql
+func NewClient() Client {
+	return &gocqlClient{}
+}
+
+type gocqlClient struct{}
+
+type gocqlSession struct {
+	session *gocql.Session
+}
+
+//NewSession opens a session authenticated with username and password, tlsConfig is used if it is not nil
+func (c *gocqlClient) NewSession(hosts []string, port int, username, password string,
+	tlsConfig *tls.Config) (Session, error) {
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Port = port
+	cluster.Consistency = gocql.Quorum
+	cluster.ConnectTimeout = connectTimeout
+	cluster.Timeout = queryTimeout
+	cluster.Authenticator = gocql.PasswordAuthenticator{Username: username, Password: password}
+	if tlsConfig != nil {
+		cluster.SslOpts = &gocql.SslOptions{Config: tlsConfig}
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, err
+	}
+	return &gocqlSession{session: session}, nil
+}
+
+//Exec executes statement with the values of its bind markers
+func (s *gocqlSession) Exec(statement string, values ...interface{}) error {
+	return s.session.Query(statement, values...).Exec()
+}
+
+//Close closes the session
+func (s *gocqlSession) Close() {
+	s.session.Close()
+}
+
+//TLSConfig returns a configuration accepting the certificates signed by ca
+//The host names are not verified, as the nodes are reached through a service
+func TLSConfig(ca *x509.Certificate) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("No certificate presented by the node")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			intermediates := x509.NewCertPool()
+			for _, rawCert := range rawCerts[1:] {
+				if intermediate, err := x509.ParseCertificate(rawCert); err == nil {
+					intermediates.AddCert(intermediate)
+				}
+			}
+			_, err = cert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates,
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+			return err
+		},
+	}
+}
+
+//Literal returns s as a CQL string literal
+func Literal(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cql
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiteral(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("'admin'", Literal("admin"))
+	assert.Equal("'it''s'", Literal("it's"))
+}
+
+func helperCertificate(t *testing.T, serial int64, isCA bool, parent *x509.Certificate,
+	parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestTLSConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	ca, caKey := helperCertificate(t, 1, true, nil, nil)
+	node, _ := helperCertificate(t, 2, false, ca, caKey)
+	otherCA, otherKey := helperCertificate(t, 3, true, nil, nil)
+	other, _ := helperCertificate(t, 4, false, otherCA, otherKey)
+
+	verify := TLSConfig(ca).VerifyPeerCertificate
+	assert.Nil(verify([][]byte{node.Raw}, nil))
+	assert.NotNil(verify([][]byte{other.Raw}, nil))
+	assert.NotNil(verify(nil, nil))
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+//Package fake provides a cql.Client keeping a Cassandra cluster in memory for the tests
+package fake
+
+import (
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/cql"
+)
+
+const literal = `'((?:[^']|'')*)'`
+
+var (
+	createRole = regexp.MustCompile(`(?i)^CREATE ROLE (?:IF NOT EXISTS )?` + literal + `(?: WITH (.*))?$`)
+	alterRole  = regexp.MustCompile(`(?i)^ALTER ROLE ` + literal + ` WITH (.*)$`)
+	dropRole   = regexp.MustCompile(`(?i)^DROP ROLE (?:IF EXISTS )?` + literal + `$`)
+	password   = regexp.MustCompile(`(?i)PASSWORD = ` + literal)
+	login      = regexp.MustCompile(`(?i)LOGIN = (true|false)`)
+	superuser  = regexp.MustCompile(`(?i)SUPERUSER = (true|false)`)
+)
+
+//Role is a role of the fake cluster
+type Role struct {
+	Password  string
+	Login     bool
+	Superuser bool
+}
+
+//Client is a cql.Client whose sessions record the statements executed and apply the CREATE ROLE, ALTER ROLE and
+//DROP ROLE statements to its roles
+type Client struct {
+	mu sync.Mutex
+	//Roles of the cluster, it starts with the default superuser
+	Roles map[string]*Role
+	//Statements executed by all the sessions
+	Statements []string
+	//Err is returned when a session is opened if it is set
+	Err error
+}
+
+var _ cql.Client = &Client{}
+
+//NewClient returns a Client whose cluster only has the default superuser
+func NewClient() *Client {
+	return &Client{
+		Roles: map[string]*Role{
+			cql.DefaultUsername: &Role{Password: cql.DefaultPassword, Login: true, Superuser: true},
+		},
+	}
+}
+
+//NewSession opens a session if the role username can log in with password
+func (c *Client) NewSession(hosts []string, port int, username, password string,
+	tlsConfig *tls.Config) (cql.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	role, ok := c.Roles[username]
+	if !ok || !role.Login || role.Password != password {
+		return nil, fmt.Errorf("Provided username %s and/or password are incorrect", username)
+	}
+	return &session{client: c, username: username}, nil
+}
+
+//Executed returns the statements executed which start with prefix
+func (c *Client) Executed(prefix string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var statements []string
+	for _, statement := range c.Statements {
+		if strings.HasPrefix(statement, prefix) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+type session struct {
+	client   *Client
+	username string
+}
+
+func unquote(s string) string {
+	return strings.Replace(s, "''", "'", -1)
+}
+
+//Exec records statement and applies it if it changes a role
+func (s *session) Exec(statement string, values ...interface{}) error {
+	c := s.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Statements = append(c.Statements, statement)
+
+	var role *Role
+	var options string
+	if m := createRole.FindStringSubmatch(statement); m != nil {
+		if !c.Roles[s.username].Superuser {
+			return fmt.Errorf("Only superusers can create roles")
+		}
+		if _, ok := c.Roles[unquote(m[1])]; !ok {
+			c.Roles[unquote(m[1])] = &Role{}
+		} else if !strings.Contains(strings.ToUpper(statement), "IF NOT EXISTS") {
+			return fmt.Errorf("%s already exists", unquote(m[1]))
+		}
+		role, options = c.Roles[unquote(m[1])], m[2]
+	} else if m := alterRole.FindStringSubmatch(statement); m != nil {
+		var ok bool
+		if role, ok = c.Roles[unquote(m[1])]; !ok {
+			return fmt.Errorf("%s doesn't exist", unquote(m[1]))
+		}
+		if !c.Roles[s.username].Superuser && unquote(m[1]) != s.username {
+			return fmt.Errorf("Only superusers can alter other roles")
+		}
+		options = m[2]
+	} else if m := dropRole.FindStringSubmatch(statement); m != nil {
+		if !c.Roles[s.username].Superuser {
+			return fmt.Errorf("Only superusers can drop roles")
+		}
+		delete(c.Roles, unquote(m[1]))
+		return nil
+	} else {
+		return nil
+	}
+
+	if m := password.FindStringSubmatch(options); m != nil {
+		role.Password = unquote(m[1])
+	}
+	if m := login.FindStringSubmatch(options); m != nil {
+		role.Login = strings.ToLower(m[1]) == "true"
+	}
+	if m := superuser.FindStringSubmatch(options); m != nil {
+		role.Superuser = strings.ToLower(m[1]) == "true"
+	}
+	return nil
+}
+
+//Close does nothing
+func (s *session) Close() {}
@@ -25,6 +25,7 @@ package k8s
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net"
 	"os"
 
@@ -42,6 +43,9 @@ import (
 var clientset kubernetes.Interface
 var cfg *rest.Config
 
+//cassandraContainerName is the name of the container running cassandra in the pods
+const cassandraContainerName = "cassandra"
+
 //InitClient allow to setup an additional client to kubernetes API while operator-sdk don't gives us access to oit
 func InitClient() {
 	if clientset == nil {
@@ -111,9 +115,35 @@ func ExecPodFromName(namespace string, name string, cmd []string) (string, strin
 //https://github.com/kubernetes/kubernetes/blob/master/pkg/kubectl/cmd/exec.go
 //func ExecPod(clientset *kubernetes.Clientset, cfg *rest.Config, namespace string, pod *corev1.Pod, cmd []string) (string, string, error) {
 func ExecPod(namespace string, pod *corev1.Pod, cmd []string) (string, string, error) {
+	var stdout bytes.Buffer
+	stderr, err := ExecPodStream(namespace, pod, cmd, nil, &stdout)
+	return stdout.String(), stderr, err
+}
 
-	if len(pod.Spec.Containers) != 1 {
-		return "", "", fmt.Errorf("could not determine which container to use")
+//execContainerName returns the container of the pod in which the commands are run: its only container, or the
+//cassandra container if it has sidecars
+func execContainerName(pod *corev1.Pod) (string, error) {
+	if len(pod.Spec.Containers) == 1 {
+		return pod.Spec.Containers[0].Name, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == cassandraContainerName {
+			return container.Name, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine which container to use")
+}
+
+//ExecPodStream runs cmd in the pod and copies its standard output to stdout while it runs,
+//which allows to read large outputs such as files without buffering them. If stdin is not nil, it is
+//sent as the standard input of cmd and if stdout is nil the standard output is discarded.
+//It returns the standard error
+func ExecPodStream(namespace string, pod *corev1.Pod, cmd []string, stdin io.Reader,
+	stdout io.Writer) (string, error) {
+
+	container, err := execContainerName(pod)
+	if err != nil {
+		return "", err
 	}
 
 	// build the remoteexec
@@ -124,26 +154,27 @@ func ExecPod(namespace string, pod *corev1.Pod, cmd []string) (string, string, e
 		SubResource("exec")
 
 	req.VersionedParams(&corev1.PodExecOptions{
-		Container: pod.Spec.Containers[0].Name,
+		Container: container,
 		Command:   cmd,
-		Stdin:     false,
-		Stdout:    true,
+		Stdin:     stdin != nil,
+		Stdout:    stdout != nil,
 		Stderr:    true,
 		TTY:       false,
 	}, scheme.ParameterCodec)
 
 	exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
 	if err != nil {
-		return "", "", fmt.Errorf("could not init remote executor: %v", err)
+		return "", fmt.Errorf("could not init remote executor: %v", err)
 	}
 
-	var stdout, stderr bytes.Buffer
+	var stderr bytes.Buffer
 	err = exec.Stream(remotecommand.StreamOptions{
-		Stdout: &stdout,
+		Stdin:  stdin,
+		Stdout: stdout,
 		Stderr: &stderr,
 		Tty:    false,
 	})
 
-	return stdout.String(), stderr.String(), err
+	return stderr.String(), err
 
 }
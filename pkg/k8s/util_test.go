@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	api "github.com/Orange-OpenSource/cassandra-k8s-operator/pkg/apis/db/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestLabelTime(t *testing.T) {
@@ -213,3 +214,22 @@ func TestMergeSlice(t *testing.T) {
 	result = MergeSlice(a, b)
 	assert.Equal(want, result)
 }
+
+func TestExecContainerName(t *testing.T) {
+	assert := assert.New(t)
+
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cassandra"}}}}
+	container, err := execContainerName(pod)
+	assert.Nil(err)
+	assert.Equal("cassandra", container)
+
+	//The commands are run in the cassandra container of a pod with sidecars
+	pod.Spec.Containers = []corev1.Container{{Name: "logs"}, {Name: "cassandra"}}
+	container, err = execContainerName(pod)
+	assert.Nil(err)
+	assert.Equal("cassandra", container)
+
+	pod.Spec.Containers = []corev1.Container{{Name: "logs"}, {Name: "metrics"}}
+	_, err = execContainerName(pod)
+	assert.NotNil(err)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// 	See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	//port of the webhook server, the service in front of it listens on 443
+	webhookPort int32 = 9876
+	//directory where the certificates of the webhook server are written
+	certDir = "/tmp/cert"
+)
+
+// AddToManagerFuncs is a list of functions to create webhooks for the CassandraClusters of a namespace (all
+// namespaces if empty)
+var AddToManagerFuncs []func(manager.Manager, string) (*admission.Webhook, error)
+
+// AddToManager creates a webhook server serving all webhooks for the CassandraClusters of watchNamespace and adds it
+// to the Manager. The server installs its certificates, the service selecting the operator pods labeled with
+// name=operatorName in namespace and the webhook configurations
+func AddToManager(m manager.Manager, watchNamespace, namespace, operatorName string) error {
+	name := fmt.Sprintf("%s-webhook", operatorName)
+	svr, err := crwebhook.NewServer(name, m, crwebhook.ServerOptions{
+		Port:    webhookPort,
+		CertDir: certDir,
+		BootstrapOptions: &crwebhook.BootstrapOptions{
+			//Webhook configurations are not namespaced, they are suffixed with namespace to allow
+			//an operator per namespace
+			MutatingWebhookConfigName:   fmt.Sprintf("%s-%s", name, namespace),
+			ValidatingWebhookConfigName: fmt.Sprintf("%s-%s", name, namespace),
+			Secret:                      &types.NamespacedName{Namespace: namespace, Name: name},
+			Service: &crwebhook.Service{
+				Namespace: namespace,
+				Name:      name,
+				Selectors: map[string]string{"name": operatorName},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var webhooks []crwebhook.Webhook
+	for _, f := range AddToManagerFuncs {
+		wh, err := f(m, watchNamespace)
+		if err != nil {
+			return err
+		}
+		webhooks = append(webhooks, wh)
+	}
+	return svr.Register(webhooks...)
+}